@@ -0,0 +1,98 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+var ErrNotOpened = errors.New("account stream does not start with an opened event")
+
+// Account is the state of an account as its events leave it.
+type Account struct {
+	ID              uint
+	Number          string
+	CustomerID      uint
+	ProductType     string
+	Currency        string
+	Status          string
+	OverdraftLimit  int64
+	OverdraftRateBP int64
+	OpenedAt        time.Time
+	Balance         int64
+	// Version is the version of the last event applied.
+	Version uint
+}
+
+// Load continues from the projections of an account: its row and its
+// balance, nil before the account was opened.
+func Load(account *entity.Account, balance *entity.AccountBalance) *Account {
+	a := &Account{
+		ID:              account.ID,
+		Number:          account.Number,
+		CustomerID:      account.CustomerID,
+		ProductType:     account.ProductType,
+		Currency:        account.Currency,
+		Status:          account.Status,
+		OverdraftLimit:  account.OverdraftLimit,
+		OverdraftRateBP: account.OverdraftRateBP,
+	}
+	if account.OpenedAt != nil {
+		a.OpenedAt = *account.OpenedAt
+	}
+	if balance != nil {
+		a.Balance, a.Version = balance.Balance, balance.Version
+	}
+	return a
+}
+
+// Apply moves the account to the state after event, which must be the next
+// version of its stream.
+func (a *Account) Apply(version uint, event Event) error {
+	if version != a.Version+1 {
+		return fmt.Errorf("account %d: event version %d does not follow %d", a.ID, version, a.Version)
+	}
+	if a.Version == 0 {
+		if _, ok := event.(*Opened); !ok {
+			return ErrNotOpened
+		}
+	}
+
+	switch e := event.(type) {
+	case *Opened:
+		a.Number, a.CustomerID, a.ProductType, a.Currency = e.Number, e.CustomerID, e.ProductType, e.Currency
+		a.Status, a.OverdraftLimit, a.OverdraftRateBP = e.Status, e.OverdraftLimit, e.OverdraftRateBP
+		a.OpenedAt = e.OpenedAt
+	case *Imported:
+		a.Balance = e.Balance
+	case *Booked:
+		if entity.IsCredit(e.TransactionType) {
+			a.Balance += e.Amount
+		} else {
+			a.Balance -= e.Amount
+		}
+	case *StatusChanged:
+		a.Status = e.To
+	case *OverdraftChanged:
+		a.OverdraftLimit, a.OverdraftRateBP = e.Limit, e.RateBP
+	case *Linked, *Reversed:
+		// Only the history changes.
+	default:
+		return fmt.Errorf("account %d: unexpected event %T", a.ID, event)
+	}
+	a.Version = version
+	return nil
+}
+
+// ApplyTo copies the state that the accounts row projects onto it.
+func (a *Account) ApplyTo(account *entity.Account) {
+	account.Status = a.Status
+	account.OverdraftLimit = a.OverdraftLimit
+	account.OverdraftRateBP = a.OverdraftRateBP
+	if !a.OpenedAt.IsZero() {
+		openedAt := a.OpenedAt
+		account.OpenedAt = &openedAt
+	}
+}
@@ -0,0 +1,53 @@
+package aggregate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+func TestReplay(t *testing.T) {
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	stream := []Event{
+		&Opened{Number: "000000000197", CustomerID: 1, ProductType: entity.ProductChecking, Currency: "USD", Status: entity.AccountActive, OpenedAt: now},
+		&Booked{TransactionID: 1, TransactionType: entity.TransactionDeposit, Amount: 10000, Currency: "USD", Date: now},
+		&Booked{TransactionID: 2, TransactionType: entity.TransactionWithdraw, Amount: 2500, Currency: "USD", Date: now},
+		&Linked{TransactionID: 2, LinkedTransactionID: 3},
+		&OverdraftChanged{Limit: 5000, RateBP: 1500},
+		&StatusChanged{From: entity.AccountActive, To: entity.AccountFrozen},
+	}
+
+	a := &Account{ID: 1}
+	for i, event := range stream {
+		// Events go through the store and back.
+		stored, err := Encode(1, uint(i+1), event, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Apply(stored.Version, decoded); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a.Balance != 7500 || a.Status != entity.AccountFrozen || a.OverdraftLimit != 5000 || a.Version != 6 || a.Number != "000000000197" {
+		t.Errorf("Unexpected state %+v", a)
+	}
+
+	if err := a.Apply(6, &Imported{Balance: 1}); err == nil {
+		t.Errorf("Expected an event out of order to be rejected")
+	}
+	if err := a.Apply(7, &Imported{Balance: 1}); err != nil || a.Balance != 1 {
+		t.Errorf("Expected the imported balance to replace the balance, got %d (%v)", a.Balance, err)
+	}
+	if err := (&Account{ID: 2}).Apply(1, &Booked{Amount: 1}); !errors.Is(err, ErrNotOpened) {
+		t.Errorf("Expected ErrNotOpened, got %v", err)
+	}
+	if _, err := Decode(&entity.AccountEvent{Type: "unknown"}); err == nil {
+		t.Errorf("Expected an unknown event type to be rejected")
+	}
+}
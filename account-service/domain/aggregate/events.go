@@ -0,0 +1,168 @@
+// Package aggregate holds the account aggregate and the events of its stream.
+// The state of an account is what its events add up to; the accounts row,
+// the balance and the history are projections of them.
+package aggregate
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// Event types, as stored in entity.AccountEvent.Type.
+const (
+	TypeOpened           = "opened"
+	TypeImported         = "imported"
+	TypeBooked           = "transaction_booked"
+	TypeLinked           = "transaction_linked"
+	TypeReversed         = "transaction_reversed"
+	TypeStatusChanged    = "status_changed"
+	TypeOverdraftChanged = "overdraft_changed"
+)
+
+// Event is something that happened to an account.
+type Event interface {
+	Type() string
+}
+
+// Opened starts the stream of an account. Accounts that existed before the
+// event store are opened with the status and overdraft they had then.
+type Opened struct {
+	Number          string    `json:"number"`
+	CustomerID      uint      `json:"customer_id"`
+	ProductType     string    `json:"product_type"`
+	Currency        string    `json:"currency"`
+	Status          string    `json:"status"`
+	OverdraftLimit  int64     `json:"overdraft_limit,omitempty"`
+	OverdraftRateBP int64     `json:"overdraft_rate_bp,omitempty"`
+	OpenedAt        time.Time `json:"opened_at"`
+}
+
+// Imported starts the replay of the transactions an account had before the
+// event store with the balance it had before them: the ledger's balance less
+// what they changed it by, which carries the balances from before the ledger
+// too.
+type Imported struct {
+	Balance int64 `json:"balance"`
+}
+
+// Booked records a transaction on the account.
+type Booked struct {
+	TransactionID         uint      `json:"transaction_id"`
+	CustomerID            uint      `json:"customer_id"`
+	JournalEntryID        uint      `json:"journal_entry_id,omitempty"`
+	LinkedTransactionID   uint      `json:"linked_transaction_id,omitempty"`
+	ReversesTransactionID uint      `json:"reverses_transaction_id,omitempty"`
+	TransactionType       string    `json:"transaction_type"`
+	Amount                int64     `json:"amount"`
+	Currency              string    `json:"currency"`
+	Reference             string    `json:"reference,omitempty"`
+	ReasonCode            string    `json:"reason_code,omitempty"`
+	Actor                 string    `json:"actor,omitempty"`
+	Date                  time.Time `json:"date"`
+}
+
+// NewBooked describes a transaction that has just been created.
+func NewBooked(t *entity.Transaction) Booked {
+	return Booked{
+		TransactionID:         t.ID,
+		CustomerID:            t.CustomerID,
+		JournalEntryID:        t.JournalEntryID,
+		LinkedTransactionID:   optionalID(t.LinkedTransactionID),
+		ReversesTransactionID: optionalID(t.ReversesTransactionID),
+		TransactionType:       t.Type,
+		Amount:                t.Amount,
+		Currency:              t.Currency,
+		Reference:             t.Reference,
+		ReasonCode:            t.ReasonCode,
+		Actor:                 t.Actor,
+		Date:                  t.Date,
+	}
+}
+
+// Linked points a transaction booked earlier at its counterpart, e.g. the
+// outgoing leg of a transfer at the incoming one.
+type Linked struct {
+	TransactionID       uint `json:"transaction_id"`
+	LinkedTransactionID uint `json:"linked_transaction_id"`
+}
+
+// Reversed marks a transaction as undone by a reversal booked on the same
+// account.
+type Reversed struct {
+	TransactionID         uint `json:"transaction_id"`
+	ReversalTransactionID uint `json:"reversal_transaction_id"`
+}
+
+type StatusChanged struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+type OverdraftChanged struct {
+	Limit  int64  `json:"limit"`
+	RateBP int64  `json:"rate_bp"`
+	Actor  string `json:"actor"`
+}
+
+func (Opened) Type() string           { return TypeOpened }
+func (Imported) Type() string         { return TypeImported }
+func (Booked) Type() string           { return TypeBooked }
+func (Linked) Type() string           { return TypeLinked }
+func (Reversed) Type() string         { return TypeReversed }
+func (StatusChanged) Type() string    { return TypeStatusChanged }
+func (OverdraftChanged) Type() string { return TypeOverdraftChanged }
+
+// Encode turns an event into the entry that stores it as the given version
+// of the stream of an account.
+func Encode(accountID, version uint, event Event, at time.Time) (*entity.AccountEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AccountEvent{
+		AccountID:  accountID,
+		Version:    version,
+		Type:       event.Type(),
+		Data:       string(data),
+		OccurredAt: at,
+	}, nil
+}
+
+// Decode returns the event a stream entry stores.
+func Decode(stored *entity.AccountEvent) (Event, error) {
+	var event Event
+	switch stored.Type {
+	case TypeOpened:
+		event = &Opened{}
+	case TypeImported:
+		event = &Imported{}
+	case TypeBooked:
+		event = &Booked{}
+	case TypeLinked:
+		event = &Linked{}
+	case TypeReversed:
+		event = &Reversed{}
+	case TypeStatusChanged:
+		event = &StatusChanged{}
+	case TypeOverdraftChanged:
+		event = &OverdraftChanged{}
+	default:
+		return nil, fmt.Errorf("unknown account event type %q", stored.Type)
+	}
+	if err := json.Unmarshal([]byte(stored.Data), event); err != nil {
+		return nil, fmt.Errorf("decode %s event %d: %w", stored.Type, stored.ID, err)
+	}
+	return event, nil
+}
+
+func optionalID(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+// ListChain returns the transactions of an account in chain order, starting
+// after the given sequence number.
+func (r *accountRepository) ListChain(ctx context.Context, accountID, afterSequence uint, limit int) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := r.db.WithContext(ctx).
+		Where("account_id = ? AND sequence > ?", accountID, afterSequence).
+		Order("sequence, id").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// ChainHeads returns, for every account with transactions dated before the
+// given time, the last of them in chain order, and how many there are in all.
+func (r *accountRepository) ChainHeads(ctx context.Context, before time.Time) ([]entity.ChainHead, int64, error) {
+	var heads []entity.ChainHead
+	err := r.db.WithContext(ctx).Table("transactions").
+		Joins("JOIN (SELECT account_id, MAX(sequence) AS sequence FROM transactions WHERE date < ? GROUP BY account_id) heads ON heads.account_id = transactions.account_id AND heads.sequence = transactions.sequence", before).
+		Order("transactions.account_id").
+		Select("transactions.account_id, transactions.sequence, transactions.hash").
+		Scan(&heads).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	var count int64
+	err = r.db.WithContext(ctx).Model(&entity.Transaction{}).Where("date < ?", before).Count(&count).Error
+	return heads, count, err
+}
+
+func (r *accountRepository) CreateChainAnchor(ctx context.Context, anchor *entity.ChainAnchor) error {
+	return r.db.WithContext(ctx).Create(anchor).Error
+}
+
+// LatestChainAnchor returns the anchor of the latest day; false when there is
+// none.
+func (r *accountRepository) LatestChainAnchor(ctx context.Context) (*entity.ChainAnchor, bool, error) {
+	var anchor entity.ChainAnchor
+	err := r.db.WithContext(ctx).Order("day DESC").Take(&anchor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	return &anchor, err == nil, err
+}
+
+// ListChainAnchors returns every anchor, oldest day first.
+func (r *accountRepository) ListChainAnchors(ctx context.Context) ([]entity.ChainAnchor, error) {
+	var anchors []entity.ChainAnchor
+	err := r.db.WithContext(ctx).Order("day").Find(&anchors).Error
+	return anchors, err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AppendAccountEvent adds an event to the stream of its account. It returns
+// ErrConcurrentUpdate when the version was appended by someone else first.
+func (r *accountRepository) AppendAccountEvent(ctx context.Context, event *entity.AccountEvent) error {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// LastAccountEventVersion returns the version of the last event of an
+// account, zero for an empty stream.
+func (r *accountRepository) LastAccountEventVersion(ctx context.Context, accountID uint) (uint, error) {
+	var version uint
+	err := r.db.WithContext(ctx).Model(&entity.AccountEvent{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+// ListAccountEvents returns the events of an account in stream order,
+// starting after the given version.
+func (r *accountRepository) ListAccountEvents(ctx context.Context, accountID, afterVersion uint, limit int) ([]entity.AccountEvent, error) {
+	var events []entity.AccountEvent
+	err := r.db.WithContext(ctx).
+		Where("account_id = ? AND version > ?", accountID, afterVersion).
+		Order("version").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// ListAccountsWithoutEvents pages through the accounts whose stream has not
+// been started.
+func (r *accountRepository) ListAccountsWithoutEvents(ctx context.Context, afterID uint, limit int) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND NOT EXISTS (SELECT 1 FROM account_events WHERE account_events.account_id = accounts.id)", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// GetAccountBalance returns the balance projection of an account; false
+// when there is none.
+func (r *accountRepository) GetAccountBalance(ctx context.Context, accountID uint) (*entity.AccountBalance, bool, error) {
+	var balance entity.AccountBalance
+	err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Take(&balance).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	return &balance, err == nil, err
+}
+
+func (r *accountRepository) SaveAccountBalance(ctx context.Context, balance *entity.AccountBalance) error {
+	return r.db.WithContext(ctx).Save(balance).Error
+}
+
+func (r *accountRepository) CreateHistoryEntry(ctx context.Context, entry *entity.HistoryEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *accountRepository) LinkHistoryEntry(ctx context.Context, transactionID, linkedTransactionID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.HistoryEntry{}).
+		Where("transaction_id = ?", transactionID).
+		Update("linked_transaction_id", linkedTransactionID).Error
+}
+
+func (r *accountRepository) ReverseHistoryEntry(ctx context.Context, transactionID, reversalTransactionID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.HistoryEntry{}).
+		Where("transaction_id = ?", transactionID).
+		Update("reversed_by_transaction_id", reversalTransactionID).Error
+}
+
+// ListHistory returns the history entries matching the filter in
+// transaction ID order. AfterID is a transaction ID.
+func (r *accountRepository) ListHistory(ctx context.Context, filter TransactionFilter) ([]entity.HistoryEntry, error) {
+	var entries []entity.HistoryEntry
+	err := filter.apply(r.db.WithContext(ctx).Model(&entity.HistoryEntry{}), "transaction_id").Find(&entries).Error
+	return entries, err
+}
+
+// DeleteProjections removes the balance and history projections of an
+// account so that they can be rebuilt from its events.
+func (r *accountRepository) DeleteProjections(ctx context.Context, accountID uint) error {
+	if err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Delete(&entity.HistoryEntry{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("account_id = ?", accountID).Delete(&entity.AccountBalance{}).Error
+}
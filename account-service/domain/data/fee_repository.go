@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// ListAccountsByProduct pages through the open accounts of the given product
+// types in ID order.
+func (r *accountRepository) ListAccountsByProduct(ctx context.Context, productTypes []string, afterID uint, limit int) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).
+		Where("product_type IN ? AND status <> ? AND id > ?", productTypes, entity.AccountClosed, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *accountRepository) CreateFeeCharge(ctx context.Context, charge *entity.FeeCharge) error {
+	return r.db.WithContext(ctx).Create(charge).Error
+}
+
+// HasFeeCharge reports whether a monthly fee rule was already applied to an
+// account for a period.
+func (r *accountRepository) HasFeeCharge(ctx context.Context, accountID uint, rule, period string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&entity.FeeCharge{}).
+		Where("account_id = ? AND rule = ? AND period = ?", accountID, rule, period).
+		Count(&n).Error
+	return n > 0, err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+func (r *accountRepository) CreateHold(ctx context.Context, hold *entity.Hold) error {
+	return r.db.WithContext(ctx).Create(hold).Error
+}
+
+func (r *accountRepository) GetHold(ctx context.Context, id uint) (*entity.Hold, error) {
+	var hold entity.Hold
+	err := r.db.WithContext(ctx).First(&hold, id).Error
+	return &hold, err
+}
+
+func (r *accountRepository) UpdateHold(ctx context.Context, hold *entity.Hold) error {
+	return r.db.WithContext(ctx).Save(hold).Error
+}
+
+// SumActiveHolds returns the amount reserved on an account at the given time.
+// Holds past their expiry no longer count even before they are swept.
+func (r *accountRepository) SumActiveHolds(ctx context.Context, accountID uint, now time.Time) (int64, error) {
+	var sum int64
+	err := r.db.WithContext(ctx).Model(&entity.Hold{}).
+		Where("account_id = ? AND status = ? AND expires_at > ?", accountID, entity.HoldActive, now).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// GetExpiredHolds returns active holds whose expiry has passed.
+func (r *accountRepository) GetExpiredHolds(ctx context.Context, now time.Time, limit int) ([]entity.Hold, error) {
+	var holds []entity.Hold
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", entity.HoldActive, now).
+		Order("id").
+		Limit(limit).
+		Find(&holds).Error
+	return holds, err
+}
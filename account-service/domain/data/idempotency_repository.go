@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+// CreateIdempotencyKey stores a request ID. The unique index makes it fail
+// when the customer already used the request ID.
+func (r *accountRepository) CreateIdempotencyKey(ctx context.Context, key *entity.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetIdempotencyKey returns what was stored under a customer's request ID;
+// false when nothing was.
+func (r *accountRepository) GetIdempotencyKey(ctx context.Context, customerID uint, requestID string) (*entity.IdempotencyKey, bool, error) {
+	var key entity.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("customer_id = ? AND request_id = ?", customerID, requestID).Take(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	return &key, err == nil, err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *accountRepository) CreateInterestAccrual(ctx context.Context, accrual *entity.InterestAccrual) error {
+	return r.db.WithContext(ctx).Create(accrual).Error
+}
+
+// LastInterestAccrualDate returns the latest day interest was accrued for on
+// an account; false when it never was.
+func (r *accountRepository) LastInterestAccrualDate(ctx context.Context, accountID uint) (time.Time, bool, error) {
+	var accrual entity.InterestAccrual
+	err := r.db.WithContext(ctx).
+		Where("account_id = ?", accountID).
+		Order("date DESC").
+		Take(&accrual).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	return accrual.Date, err == nil, err
+}
+
+// GetUnpostedInterestAccruals returns the accruals of an account for days
+// before the given time that have not been posted yet, oldest first.
+func (r *accountRepository) GetUnpostedInterestAccruals(ctx context.Context, accountID uint, before time.Time) ([]entity.InterestAccrual, error) {
+	var accruals []entity.InterestAccrual
+	err := r.db.WithContext(ctx).
+		Where("account_id = ? AND posted_at IS NULL AND date < ?", accountID, before).
+		Order("date").
+		Find(&accruals).Error
+	return accruals, err
+}
+
+func (r *accountRepository) MarkInterestAccrualsPosted(ctx context.Context, ids []uint, postedAt time.Time, transactionID *uint) error {
+	return r.db.WithContext(ctx).Model(&entity.InterestAccrual{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"posted_at": postedAt, "transaction_id": transactionID}).Error
+}
+
+// LastPostedInterestAccrual returns the latest posted accrual of an account;
+// false when none was posted yet.
+func (r *accountRepository) LastPostedInterestAccrual(ctx context.Context, accountID uint) (*entity.InterestAccrual, bool, error) {
+	var accrual entity.InterestAccrual
+	err := r.db.WithContext(ctx).
+		Where("account_id = ? AND posted_at IS NOT NULL", accountID).
+		Order("date DESC").
+		Take(&accrual).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	return &accrual, err == nil, err
+}
+
+func (r *accountRepository) SetInterestRemainder(ctx context.Context, id uint, micros, overdraftMicros int64) error {
+	return r.db.WithContext(ctx).Model(&entity.InterestAccrual{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"remainder_micros": micros, "overdraft_remainder_micros": overdraftMicros}).Error
+}
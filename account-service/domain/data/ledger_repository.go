@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *accountRepository) GetLedgerAccountByCode(ctx context.Context, code, currency string) (*entity.LedgerAccount, error) {
+	var ledgerAccount entity.LedgerAccount
+	err := r.db.WithContext(ctx).Where("code = ? AND currency = ?", code, currency).First(&ledgerAccount).Error
+	return &ledgerAccount, err
+}
+
+func (r *accountRepository) CreateLedgerAccount(ctx context.Context, ledgerAccount *entity.LedgerAccount) error {
+	return r.db.WithContext(ctx).Create(ledgerAccount).Error
+}
+
+// CreateJournalEntry stores the journal together with its postings. Gorm
+// saves the association in the same database transaction.
+func (r *accountRepository) CreateJournalEntry(ctx context.Context, journal *entity.JournalEntry) error {
+	return r.db.WithContext(ctx).Create(journal).Error
+}
+
+func (r *accountRepository) GetPostingsByJournalEntryID(ctx context.Context, journalEntryID uint) ([]entity.Posting, error) {
+	var postings []entity.Posting
+	err := r.db.WithContext(ctx).Where("journal_entry_id = ?", journalEntryID).Order("id").Find(&postings).Error
+	return postings, err
+}
+
+func (r *accountRepository) SumPostings(ctx context.Context, ledgerAccountID uint) (int64, error) {
+	var sum int64
+	err := r.db.WithContext(ctx).Model(&entity.Posting{}).
+		Where("ledger_account_id = ?", ledgerAccountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// SumPostingsBefore sums the postings of a ledger account whose journal entry
+// is dated before the given time.
+func (r *accountRepository) SumPostingsBefore(ctx context.Context, ledgerAccountID uint, before time.Time) (int64, error) {
+	var sum int64
+	err := r.db.WithContext(ctx).Model(&entity.Posting{}).
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Where("postings.ledger_account_id = ? AND journal_entries.date < ?", ledgerAccountID, before).
+		Select("COALESCE(SUM(postings.amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// FirstPostingDate returns the date of the earliest journal entry that posts
+// to a ledger account; false when there is none.
+func (r *accountRepository) FirstPostingDate(ctx context.Context, ledgerAccountID uint) (time.Time, bool, error) {
+	var journal entity.JournalEntry
+	err := r.db.WithContext(ctx).
+		Joins("JOIN postings ON postings.journal_entry_id = journal_entries.id").
+		Where("postings.ledger_account_id = ?", ledgerAccountID).
+		Order("journal_entries.date").
+		Take(&journal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	return journal.Date, err == nil, err
+}
+
+// LegacyBalances reads the balance column that accounts carried before the
+// ledger existed. It returns nothing once the column has been dropped.
+func (r *accountRepository) LegacyBalances(ctx context.Context) (map[uint]float64, error) {
+	balances := make(map[uint]float64)
+	if !r.db.WithContext(ctx).Migrator().HasColumn(&entity.Account{}, "balance") {
+		return balances, nil
+	}
+
+	var rows []struct {
+		ID      uint
+		Balance float64
+	}
+	if err := r.db.WithContext(ctx).Table("accounts").Select("id, balance").Where("balance <> 0").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		balances[row.ID] = row.Balance
+	}
+	return balances, nil
+}
+
+func (r *accountRepository) DropLegacyBalances(ctx context.Context) error {
+	if !r.db.WithContext(ctx).Migrator().HasColumn(&entity.Account{}, "balance") {
+		return nil
+	}
+	return r.db.WithContext(ctx).Migrator().DropColumn(&entity.Account{}, "balance")
+}
+
+// JournalMovement is what one journal entry posted to a ledger account.
+type JournalMovement struct {
+	JournalEntryID uint
+	Description    string
+	Date           time.Time
+	Amount         int64
+}
+
+// ListJournalMovements sums the postings of a ledger account per journal
+// entry, in journal entry ID order.
+func (r *accountRepository) ListJournalMovements(ctx context.Context, ledgerAccountID uint) ([]JournalMovement, error) {
+	var movements []JournalMovement
+	err := r.db.WithContext(ctx).Model(&entity.Posting{}).
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Where("postings.ledger_account_id = ?", ledgerAccountID).
+		Group("journal_entries.id, journal_entries.description, journal_entries.date").
+		Order("journal_entries.id").
+		Select("journal_entries.id AS journal_entry_id, journal_entries.description, journal_entries.date, SUM(postings.amount) AS amount").
+		Scan(&movements).Error
+	return movements, err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+func (r *accountRepository) CreateAccountStatusChange(ctx context.Context, change *entity.AccountStatusChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+// ListAccountStatusChanges returns the status changes of an account, oldest
+// first.
+func (r *accountRepository) ListAccountStatusChanges(ctx context.Context, accountID uint) ([]entity.AccountStatusChange, error) {
+	var changes []entity.AccountStatusChange
+	err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Order("id").Find(&changes).Error
+	return changes, err
+}
+
+// ListIdleAccounts pages through the active accounts, in ID order, opened
+// before since that have no transaction of the given types since then.
+func (r *accountRepository) ListIdleAccounts(ctx context.Context, since time.Time, activityTypes []string, afterID uint, limit int) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND opened_at < ? AND id > ?", entity.AccountActive, since, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.account_id = accounts.id AND transactions.type IN ? AND transactions.date >= ?)", activityTypes, since).
+		Order("id").
+		Limit(limit).
+		Find(&accounts).Error
+	return accounts, err
+}
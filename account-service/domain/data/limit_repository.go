@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// limitedTypes are the transactions that count against withdrawal limits:
+// money the customer moved out of the account, whether withdrawn, sent on
+// or captured by a card payment.
+var limitedTypes = []string{entity.TransactionWithdraw, entity.TransactionTransferOut, entity.TransactionHoldCapture}
+
+// WithdrawalFilter selects the withdrawals counted against a limit: those of
+// an account, or of every account of a customer, in a currency since a time.
+// Holds still active at Now count as withdrawals from when they were placed.
+type WithdrawalFilter struct {
+	AccountID  uint
+	CustomerID uint
+	Currency   string
+	Since      time.Time
+	Now        time.Time
+}
+
+// WithdrawalUsage sums the withdrawals a filter selects. First is the date of
+// the oldest one.
+type WithdrawalUsage struct {
+	Total int64
+	Count int64
+	First time.Time
+}
+
+func (r *accountRepository) CreateWithdrawalLimit(ctx context.Context, limit *entity.WithdrawalLimit) error {
+	return r.db.WithContext(ctx).Create(limit).Error
+}
+
+// GetWithdrawalLimits returns the limits that may apply to withdrawals from
+// an account at now: its own and its customer's in its currency that have not
+// expired, newest first.
+func (r *accountRepository) GetWithdrawalLimits(ctx context.Context, account *entity.Account, now time.Time) ([]entity.WithdrawalLimit, error) {
+	var limits []entity.WithdrawalLimit
+	err := r.db.WithContext(ctx).
+		Where("(account_id = ? OR (account_id IS NULL AND customer_id = ? AND currency = ?))", account.ID, account.CustomerID, account.Currency).
+		Where("(expires_at IS NULL OR expires_at > ?)", now).
+		Order("id DESC").
+		Find(&limits).Error
+	return limits, err
+}
+
+// LockCustomerAccounts locks every account of a customer until the
+// transaction ends, so that units of work checking the customer's limits run
+// one after the other and each sees the withdrawals of the one before. SQLite
+// has no row locks, but lets only one transaction write at a time anyway.
+func (r *accountRepository) LockCustomerAccounts(ctx context.Context, customerID uint) error {
+	var ids []uint
+	return r.db.WithContext(ctx).Model(&entity.Account{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ?", customerID).
+		Order("id").
+		Pluck("id", &ids).Error
+}
+
+// SumWithdrawals adds up the withdrawals a filter selects: withdrawals,
+// outgoing transfers and hold captures that were not reversed, and active
+// holds, which a capture replaces.
+func (r *accountRepository) SumWithdrawals(ctx context.Context, filter WithdrawalFilter) (WithdrawalUsage, error) {
+	transactions := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&entity.Transaction{}).
+			Where("type IN ? AND currency = ? AND date >= ? AND reversed_by_transaction_id IS NULL", limitedTypes, filter.Currency, filter.Since)
+		if filter.AccountID != 0 {
+			query = query.Where("account_id = ?", filter.AccountID)
+		}
+		if filter.CustomerID != 0 {
+			query = query.Where("customer_id = ?", filter.CustomerID)
+		}
+		return query
+	}
+	holds := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&entity.Hold{}).
+			Where("status = ? AND currency = ? AND created_at >= ? AND expires_at > ?", entity.HoldActive, filter.Currency, filter.Since, filter.Now)
+		if filter.AccountID != 0 {
+			query = query.Where("account_id = ?", filter.AccountID)
+		}
+		if filter.CustomerID != 0 {
+			query = query.Where("account_id IN (?)", r.db.Model(&entity.Account{}).Select("id").Where("customer_id = ?", filter.CustomerID))
+		}
+		return query
+	}
+
+	var usage WithdrawalUsage
+	for _, query := range []func() *gorm.DB{transactions, holds} {
+		var row struct {
+			Total int64
+			Count int64
+		}
+		if err := query().Select("COALESCE(SUM(amount), 0) AS total, COUNT(*) AS count").Scan(&row).Error; err != nil {
+			return usage, err
+		}
+		usage.Total += row.Total
+		usage.Count += row.Count
+	}
+	if usage.Count == 0 {
+		return usage, nil
+	}
+
+	var first []entity.Transaction
+	if err := transactions().Order("date").Limit(1).Find(&first).Error; err != nil {
+		return usage, err
+	}
+	if len(first) > 0 {
+		usage.First = first[0].Date
+	}
+	var hold []entity.Hold
+	if err := holds().Order("created_at").Limit(1).Find(&hold).Error; err != nil {
+		return usage, err
+	}
+	if len(hold) > 0 && (usage.First.IsZero() || hold[0].CreatedAt.Before(usage.First)) {
+		usage.First = hold[0].CreatedAt
+	}
+	return usage, nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+var models = []interface{}{
+	&entity.Account{},
+	&entity.Transaction{},
+	&entity.LedgerAccount{},
+	&entity.JournalEntry{},
+	&entity.Posting{},
+	&entity.OutboxMessage{},
+	&entity.Hold{},
+	&entity.InterestAccrual{},
+	&entity.FeeCharge{},
+	&entity.WithdrawalLimit{},
+	&entity.StandingOrder{},
+	&entity.StandingOrderExecution{},
+	&entity.AccountStatusChange{},
+	&entity.BalanceSnapshot{},
+	&entity.ChainAnchor{},
+	&entity.AccountEvent{},
+	&entity.AccountBalance{},
+	&entity.HistoryEntry{},
+	&entity.IdempotencyKey{},
+	&entity.Reconciliation{},
+	&entity.ReconciliationItem{},
+	&entity.PayoutBatch{},
+	&entity.PayoutLine{},
+}
+
+// Migrate brings the schema up to date with the entities.
+func Migrate(db *gorm.DB) error {
+	if err := rescaleFloatAmounts(db); err != nil {
+		return err
+	}
+	if err := deferTransactionSequenceIndex(db); err != nil {
+		return err
+	}
+	if db.Migrator().HasIndex(&entity.LedgerAccount{}, "idx_ledger_accounts_code") {
+		if err := db.Migrator().DropIndex(&entity.LedgerAccount{}, "idx_ledger_accounts_code"); err != nil {
+			return err
+		}
+	}
+	// Request IDs were unique across customers before they were scoped to
+	// one.
+	if db.Migrator().HasIndex(&entity.IdempotencyKey{}, "idx_idempotency_keys_request_id") {
+		if err := db.Migrator().DropIndex(&entity.IdempotencyKey{}, "idx_idempotency_keys_request_id"); err != nil {
+			return err
+		}
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		return err
+	}
+	if err := fillDefaultCurrency(db); err != nil {
+		return err
+	}
+	if err := fillAccountNumbers(db); err != nil {
+		return err
+	}
+	if err := fillAccountLifecycle(db); err != nil {
+		return err
+	}
+	if err := chainTransactions(db); err != nil {
+		return err
+	}
+	return uniqueTransactionSequenceIndex(db)
+}
+
+// rescaleFloatAmounts converts amounts written before the Money type, which
+// were floats in major units, to minor units of the default currency. It
+// turns the columns into integers in the same transaction, so that a failed
+// migration cannot leave rescaled amounts in a float column to be rescaled
+// again on the next run. It is a no-op once the columns are integers.
+func rescaleFloatAmounts(db *gorm.DB) error {
+	exponent, err := entity.CurrencyExponent(entity.DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	factor := math.Pow10(exponent)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&entity.Transaction{}, &entity.Posting{}} {
+			if !tx.Migrator().HasTable(model) {
+				continue
+			}
+			columnTypes, err := tx.Migrator().ColumnTypes(model)
+			if err != nil {
+				return err
+			}
+			for _, column := range columnTypes {
+				if column.Name() != "amount" || !isFloatType(column.DatabaseTypeName()) {
+					continue
+				}
+				if err := tx.Model(model).Where("1 = 1").Update("amount", gorm.Expr("ROUND(amount * ?)", factor)).Error; err != nil {
+					return err
+				}
+				if err := tx.Migrator().AlterColumn(model, "Amount"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func isFloatType(name string) bool {
+	switch strings.ToLower(name) {
+	case "float4", "float8", "real", "double precision", "numeric", "decimal", "float", "double":
+		return true
+	}
+	return false
+}
+
+func fillDefaultCurrency(db *gorm.DB) error {
+	for _, model := range []interface{}{&entity.Account{}, &entity.Transaction{}, &entity.LedgerAccount{}, &entity.Posting{}} {
+		err := db.Model(model).
+			Where("currency IS NULL OR currency = ''").
+			Update("currency", entity.DefaultCurrency).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillAccountNumbers numbers the accounts created when a customer could only
+// have one, and attributes their transactions to that account.
+func fillAccountNumbers(db *gorm.DB) error {
+	var accounts []entity.Account
+	if err := db.Where("number IS NULL OR number = ''").Find(&accounts).Error; err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		err := db.Model(&account).Updates(map[string]interface{}{
+			"number":       entity.AccountNumber(account.ID),
+			"product_type": entity.ProductChecking,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Model(&entity.Transaction{}).
+		Where("account_id IS NULL OR account_id = 0").
+		Update("account_id", gorm.Expr("(SELECT MIN(accounts.id) FROM accounts WHERE accounts.customer_id = transactions.customer_id)")).Error
+}
+
+// fillAccountLifecycle makes the accounts opened before account statuses
+// active, and dates their opening by their first transaction, or by now if
+// they have none, so that the dormancy job can tell how long they have been
+// idle.
+func fillAccountLifecycle(db *gorm.DB) error {
+	err := db.Model(&entity.Account{}).
+		Where("status IS NULL OR status = ''").
+		Update("status", entity.AccountActive).Error
+	if err != nil {
+		return err
+	}
+
+	var accounts []entity.Account
+	if err := db.Where("opened_at IS NULL").Find(&accounts).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, account := range accounts {
+		var first []entity.Transaction
+		if err := db.Where("account_id = ?", account.ID).Order("date").Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+		openedAt := now
+		if len(first) > 0 {
+			openedAt = first[0].Date
+		}
+		if err := db.Model(&account).Update("opened_at", openedAt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainTransactions links the transactions recorded before the hash chain
+// into it, in ID order, after whatever part of the chain of their account
+// already exists. Each account is chained in a transaction of its own.
+func chainTransactions(db *gorm.DB) error {
+	var accountIDs []uint
+	if err := db.Model(&entity.Transaction{}).Where("sequence = 0").Distinct().Pluck("account_id", &accountIDs).Error; err != nil {
+		return err
+	}
+	for _, accountID := range accountIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var head []entity.Transaction
+			if err := tx.Where("account_id = ? AND sequence > 0", accountID).Order("sequence DESC").Limit(1).Find(&head).Error; err != nil {
+				return err
+			}
+			var sequence uint
+			var prevHash string
+			if len(head) > 0 {
+				sequence, prevHash = head[0].Sequence, head[0].Hash
+			}
+
+			var transactions []entity.Transaction
+			if err := tx.Where("account_id = ? AND sequence = 0", accountID).Order("id").Find(&transactions).Error; err != nil {
+				return err
+			}
+			for _, t := range transactions {
+				sequence++
+				t.Sequence, t.PrevHash = sequence, prevHash
+				t.Hash = t.ComputeHash()
+				err := tx.Model(&t).UpdateColumns(map[string]interface{}{
+					"sequence":  t.Sequence,
+					"prev_hash": t.PrevHash,
+					"hash":      t.Hash,
+				}).Error
+				if err != nil {
+					return err
+				}
+				prevHash = t.Hash
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const transactionSequenceIndex = "idx_transactions_account_sequence"
+
+// deferTransactionSequenceIndex keeps migrations from creating the unique
+// sequence index on a transactions table whose rows are not chained yet and
+// all have sequence zero. It creates a plain index of the same name in its
+// place, which uniqueTransactionSequenceIndex replaces once the rows are
+// chained.
+func deferTransactionSequenceIndex(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&entity.Transaction{}) || migrator.HasIndex(&entity.Transaction{}, transactionSequenceIndex) {
+		return nil
+	}
+	for _, field := range []string{"AccountID", "Sequence"} {
+		if migrator.HasColumn(&entity.Transaction{}, field) {
+			continue
+		}
+		if err := migrator.AddColumn(&entity.Transaction{}, field); err != nil {
+			return err
+		}
+	}
+	return db.Exec("CREATE INDEX " + transactionSequenceIndex + " ON transactions (account_id, sequence)").Error
+}
+
+// uniqueTransactionSequenceIndex turns the sequence index into the unique
+// one the Transaction entity declares, unless it already is. It fails if an
+// account's chain was forked before the index was unique.
+func uniqueTransactionSequenceIndex(db *gorm.DB) error {
+	indexes, err := db.Migrator().GetIndexes(&entity.Transaction{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name() != transactionSequenceIndex {
+			continue
+		}
+		if unique, _ := index.Unique(); unique {
+			return nil
+		}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasIndex(&entity.Transaction{}, transactionSequenceIndex) {
+			if err := tx.Migrator().DropIndex(&entity.Transaction{}, transactionSequenceIndex); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().CreateIndex(&entity.Transaction{}, transactionSequenceIndex)
+	})
+}
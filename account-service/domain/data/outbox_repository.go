@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *accountRepository) CreateOutboxMessage(ctx context.Context, message *entity.OutboxMessage) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+// GetPendingOutboxMessages returns the oldest messages that have not been
+// published yet, in the order they were written.
+func (r *accountRepository) GetPendingOutboxMessages(ctx context.Context, limit int) ([]entity.OutboxMessage, error) {
+	var messages []entity.OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("published_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+func (r *accountRepository) MarkOutboxMessagePublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"published_at": publishedAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		}).Error
+}
+
+func (r *accountRepository) MarkOutboxMessageFailed(ctx context.Context, id uint, reason string) error {
+	return r.db.WithContext(ctx).Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": reason,
+		}).Error
+}
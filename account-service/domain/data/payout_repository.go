@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+// payoutLineBatchSize bounds the lines written per statement.
+const payoutLineBatchSize = 500
+
+// CreatePayoutBatch stores a batch and its lines.
+func (r *accountRepository) CreatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch, lines []entity.PayoutLine) error {
+	if err := r.db.WithContext(ctx).Create(batch).Error; err != nil {
+		return err
+	}
+	for i := range lines {
+		lines[i].BatchID = batch.ID
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(lines, payoutLineBatchSize).Error
+}
+
+func (r *accountRepository) GetPayoutBatch(ctx context.Context, id uint) (*entity.PayoutBatch, error) {
+	var batch entity.PayoutBatch
+	err := r.db.WithContext(ctx).First(&batch, id).Error
+	return &batch, err
+}
+
+// UpdatePayoutBatch saves the status of a batch and bumps its version,
+// provided it is still the version that was read.
+func (r *accountRepository) UpdatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch) error {
+	result := r.db.WithContext(ctx).Model(&entity.PayoutBatch{}).
+		Where("id = ? AND version = ?", batch.ID, batch.Version).
+		Updates(map[string]interface{}{
+			"status":       batch.Status,
+			"completed_at": batch.CompletedAt,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	batch.Version++
+	return nil
+}
+
+// ListOpenPayoutBatches returns the batches that are not completed yet,
+// oldest first, leaving out the batches in skip.
+func (r *accountRepository) ListOpenPayoutBatches(ctx context.Context, skip []uint, limit int) ([]entity.PayoutBatch, error) {
+	var batches []entity.PayoutBatch
+	query := r.db.WithContext(ctx).Where("status <> ?", entity.PayoutBatchCompleted)
+	if len(skip) > 0 {
+		query = query.Where("id NOT IN ?", skip)
+	}
+	err := query.
+		Order("id").
+		Limit(limit).
+		Find(&batches).Error
+	return batches, err
+}
+
+func (r *accountRepository) GetPayoutLine(ctx context.Context, id uint) (*entity.PayoutLine, error) {
+	var line entity.PayoutLine
+	err := r.db.WithContext(ctx).First(&line, id).Error
+	return &line, err
+}
+
+// ListPayoutLines returns the lines of a batch in file order, or only those
+// with the given status when it is set.
+func (r *accountRepository) ListPayoutLines(ctx context.Context, batchID uint, status string, limit int) ([]entity.PayoutLine, error) {
+	query := r.db.WithContext(ctx).Where("batch_id = ?", batchID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var lines []entity.PayoutLine
+	err := query.Order("line, id").Find(&lines).Error
+	return lines, err
+}
+
+// FinishPayoutLine records the outcome of a pending line. It fails with
+// ErrConcurrentUpdate when the line is no longer pending, so that a line is
+// never paid twice.
+func (r *accountRepository) FinishPayoutLine(ctx context.Context, line *entity.PayoutLine) error {
+	result := r.db.WithContext(ctx).Model(&entity.PayoutLine{}).
+		Where("id = ? AND status = ?", line.ID, entity.PayoutLinePending).
+		Updates(map[string]interface{}{
+			"status":         line.Status,
+			"attempts":       line.Attempts,
+			"transaction_id": line.TransactionID,
+			"message":        line.Message,
+			"executed_at":    line.ExecutedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// AddPayoutLineAttempt counts a failed attempt of a pending line.
+func (r *accountRepository) AddPayoutLineAttempt(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Model(&entity.PayoutLine{}).
+		Where("id = ? AND status = ?", id, entity.PayoutLinePending).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// CountPayoutLines returns how many lines of a batch have each status.
+func (r *accountRepository) CountPayoutLines(ctx context.Context, batchID uint) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := r.db.WithContext(ctx).Model(&entity.PayoutLine{}).
+		Select("status, COUNT(*) AS count").
+		Where("batch_id = ?", batchID).
+		Group("status").
+		Scan(&rows).Error
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// reconciliationBatchSize bounds the rows written, and the values looked up,
+// per statement.
+const reconciliationBatchSize = 500
+
+func (r *accountRepository) CreateReconciliation(ctx context.Context, reconciliation *entity.Reconciliation) error {
+	return r.db.WithContext(ctx).Create(reconciliation).Error
+}
+
+// CreateReconciliationItems stores the lines of a reconciliation. The unique
+// index on the transaction ID makes it fail when one of them claims a
+// transaction matched before.
+func (r *accountRepository) CreateReconciliationItems(ctx context.Context, items []entity.ReconciliationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(items, reconciliationBatchSize).Error
+}
+
+func (r *accountRepository) GetReconciliation(ctx context.Context, id uint) (*entity.Reconciliation, error) {
+	var reconciliation entity.Reconciliation
+	err := r.db.WithContext(ctx).First(&reconciliation, id).Error
+	return &reconciliation, err
+}
+
+// ListReconciliationItems returns the lines of a reconciliation in file
+// order.
+func (r *accountRepository) ListReconciliationItems(ctx context.Context, reconciliationID uint) ([]entity.ReconciliationItem, error) {
+	var items []entity.ReconciliationItem
+	err := r.db.WithContext(ctx).Where("reconciliation_id = ?", reconciliationID).Order("line, id").Find(&items).Error
+	return items, err
+}
+
+// ListUnreconciledTransactions returns the transactions of the given types
+// dated from from up to, but excluding, to that no reconciliation matched
+// yet.
+func (r *accountRepository) ListUnreconciledTransactions(ctx context.Context, types []string, from, to time.Time) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := r.db.WithContext(ctx).
+		Where("type IN ? AND date >= ? AND date < ?", types, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM reconciliation_items WHERE reconciliation_items.transaction_id = transactions.id)").
+		Order("id").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// MatchedExternalIDs returns, for those of the given bank references that
+// earlier reconciliations matched, the transaction they were matched to.
+func (r *accountRepository) MatchedExternalIDs(ctx context.Context, externalIDs []string) (map[string]uint, error) {
+	matched := make(map[string]uint)
+	for start := 0; start < len(externalIDs); start += reconciliationBatchSize {
+		end := min(start+reconciliationBatchSize, len(externalIDs))
+		var items []entity.ReconciliationItem
+		err := r.db.WithContext(ctx).
+			Where("external_id IN ? AND status = ?", externalIDs[start:end], entity.ReconciliationMatched).
+			Find(&items).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			matched[item.ExternalID] = *item.TransactionID
+		}
+	}
+	return matched, nil
+}
@@ -2,20 +2,114 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/m-dehghani/account-service/domain/entity"
 	"gorm.io/gorm"
 )
 
+// ErrConcurrentUpdate is returned when an account changed after it was read
+// in the current transaction.
+var ErrConcurrentUpdate = errors.New("account was changed concurrently")
+
 type AccountRepository interface {
 	CreateAccount(ctx context.Context, account *entity.Account) error
 	GetAccountByCustomerID(ctx context.Context, customerID uint) (*entity.Account, error)
-	UpdateAccount(ctx context.Context, account *entity.Account) error
+	GetAccountByNumber(ctx context.Context, number string) (*entity.Account, error)
+	GetAccountByID(ctx context.Context, id uint) (*entity.Account, error)
+	ListAccountsByCustomerID(ctx context.Context, customerID uint) ([]entity.Account, error)
+	ListAccounts(ctx context.Context, afterID uint, limit int) ([]entity.Account, error)
+	SaveAccountState(ctx context.Context, account *entity.Account) error
+	BumpAccountVersion(ctx context.Context, account *entity.Account) error
 	CreateTransaction(ctx context.Context, transaction *entity.Transaction) error
-	GetTransactionsByCustomerID(ctx context.Context, customerID uint) ([]entity.Transaction, error)
-	Begin() *gorm.DB
-	Commit() error
-	Rollback() error
+	UpdateTransaction(ctx context.Context, transaction *entity.Transaction) error
+	GetTransaction(ctx context.Context, id uint) (*entity.Transaction, error)
+	GetTransactionsByJournalEntryID(ctx context.Context, journalEntryID uint) ([]entity.Transaction, error)
+	ListTransactions(ctx context.Context, filter TransactionFilter) ([]entity.Transaction, error)
+	GetLedgerAccountByCode(ctx context.Context, code, currency string) (*entity.LedgerAccount, error)
+	CreateLedgerAccount(ctx context.Context, ledgerAccount *entity.LedgerAccount) error
+	CreateJournalEntry(ctx context.Context, journal *entity.JournalEntry) error
+	GetPostingsByJournalEntryID(ctx context.Context, journalEntryID uint) ([]entity.Posting, error)
+	SumPostings(ctx context.Context, ledgerAccountID uint) (int64, error)
+	SumPostingsBefore(ctx context.Context, ledgerAccountID uint, before time.Time) (int64, error)
+	ListJournalMovements(ctx context.Context, ledgerAccountID uint) ([]JournalMovement, error)
+	LegacyBalances(ctx context.Context) (map[uint]float64, error)
+	DropLegacyBalances(ctx context.Context) error
+	CreateOutboxMessage(ctx context.Context, message *entity.OutboxMessage) error
+	GetPendingOutboxMessages(ctx context.Context, limit int) ([]entity.OutboxMessage, error)
+	MarkOutboxMessagePublished(ctx context.Context, id uint, publishedAt time.Time) error
+	MarkOutboxMessageFailed(ctx context.Context, id uint, reason string) error
+	CreateHold(ctx context.Context, hold *entity.Hold) error
+	GetHold(ctx context.Context, id uint) (*entity.Hold, error)
+	UpdateHold(ctx context.Context, hold *entity.Hold) error
+	SumActiveHolds(ctx context.Context, accountID uint, now time.Time) (int64, error)
+	GetExpiredHolds(ctx context.Context, now time.Time, limit int) ([]entity.Hold, error)
+	ListInterestAccounts(ctx context.Context, productTypes []string, afterID uint, limit int) ([]entity.Account, error)
+	FirstPostingDate(ctx context.Context, ledgerAccountID uint) (time.Time, bool, error)
+	CreateInterestAccrual(ctx context.Context, accrual *entity.InterestAccrual) error
+	LastInterestAccrualDate(ctx context.Context, accountID uint) (time.Time, bool, error)
+	GetUnpostedInterestAccruals(ctx context.Context, accountID uint, before time.Time) ([]entity.InterestAccrual, error)
+	MarkInterestAccrualsPosted(ctx context.Context, ids []uint, postedAt time.Time, transactionID *uint) error
+	LastPostedInterestAccrual(ctx context.Context, accountID uint) (*entity.InterestAccrual, bool, error)
+	SetInterestRemainder(ctx context.Context, id uint, micros, overdraftMicros int64) error
+	ListAccountsByProduct(ctx context.Context, productTypes []string, afterID uint, limit int) ([]entity.Account, error)
+	CreateFeeCharge(ctx context.Context, charge *entity.FeeCharge) error
+	HasFeeCharge(ctx context.Context, accountID uint, rule, period string) (bool, error)
+	CreateWithdrawalLimit(ctx context.Context, limit *entity.WithdrawalLimit) error
+	GetWithdrawalLimits(ctx context.Context, account *entity.Account, now time.Time) ([]entity.WithdrawalLimit, error)
+	LockCustomerAccounts(ctx context.Context, customerID uint) error
+	SumWithdrawals(ctx context.Context, filter WithdrawalFilter) (WithdrawalUsage, error)
+	CreateStandingOrder(ctx context.Context, order *entity.StandingOrder) error
+	GetStandingOrder(ctx context.Context, id uint) (*entity.StandingOrder, error)
+	UpdateStandingOrder(ctx context.Context, order *entity.StandingOrder) error
+	ListStandingOrders(ctx context.Context, customerID, accountID uint) ([]entity.StandingOrder, error)
+	GetDueStandingOrders(ctx context.Context, now time.Time, skip []uint, limit int) ([]entity.StandingOrder, error)
+	CreateStandingOrderExecution(ctx context.Context, execution *entity.StandingOrderExecution) error
+	ListStandingOrderExecutions(ctx context.Context, standingOrderID uint) ([]entity.StandingOrderExecution, error)
+	CreateAccountStatusChange(ctx context.Context, change *entity.AccountStatusChange) error
+	ListAccountStatusChanges(ctx context.Context, accountID uint) ([]entity.AccountStatusChange, error)
+	ListIdleAccounts(ctx context.Context, since time.Time, activityTypes []string, afterID uint, limit int) ([]entity.Account, error)
+	CreateBalanceSnapshot(ctx context.Context, snapshot *entity.BalanceSnapshot) error
+	LatestBalanceSnapshot(ctx context.Context, accountID uint, at time.Time) (*entity.BalanceSnapshot, bool, error)
+	SumTransactions(ctx context.Context, accountID uint, from, to time.Time) (int64, error)
+	ListBalanceSnapshots(ctx context.Context, accountID uint) ([]entity.BalanceSnapshot, error)
+	CreateIdempotencyKey(ctx context.Context, key *entity.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, customerID uint, requestID string) (*entity.IdempotencyKey, bool, error)
+	AppendAccountEvent(ctx context.Context, event *entity.AccountEvent) error
+	LastAccountEventVersion(ctx context.Context, accountID uint) (uint, error)
+	ListAccountEvents(ctx context.Context, accountID, afterVersion uint, limit int) ([]entity.AccountEvent, error)
+	ListAccountsWithoutEvents(ctx context.Context, afterID uint, limit int) ([]entity.Account, error)
+	GetAccountBalance(ctx context.Context, accountID uint) (*entity.AccountBalance, bool, error)
+	SaveAccountBalance(ctx context.Context, balance *entity.AccountBalance) error
+	CreateHistoryEntry(ctx context.Context, entry *entity.HistoryEntry) error
+	LinkHistoryEntry(ctx context.Context, transactionID, linkedTransactionID uint) error
+	ReverseHistoryEntry(ctx context.Context, transactionID, reversalTransactionID uint) error
+	ListHistory(ctx context.Context, filter TransactionFilter) ([]entity.HistoryEntry, error)
+	DeleteProjections(ctx context.Context, accountID uint) error
+	ListChain(ctx context.Context, accountID, afterSequence uint, limit int) ([]entity.Transaction, error)
+	ChainHeads(ctx context.Context, before time.Time) ([]entity.ChainHead, int64, error)
+	CreateChainAnchor(ctx context.Context, anchor *entity.ChainAnchor) error
+	LatestChainAnchor(ctx context.Context) (*entity.ChainAnchor, bool, error)
+	ListChainAnchors(ctx context.Context) ([]entity.ChainAnchor, error)
+	CreateReconciliation(ctx context.Context, reconciliation *entity.Reconciliation) error
+	CreateReconciliationItems(ctx context.Context, items []entity.ReconciliationItem) error
+	GetReconciliation(ctx context.Context, id uint) (*entity.Reconciliation, error)
+	ListReconciliationItems(ctx context.Context, reconciliationID uint) ([]entity.ReconciliationItem, error)
+	ListUnreconciledTransactions(ctx context.Context, types []string, from, to time.Time) ([]entity.Transaction, error)
+	MatchedExternalIDs(ctx context.Context, externalIDs []string) (map[string]uint, error)
+	CreatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch, lines []entity.PayoutLine) error
+	GetPayoutBatch(ctx context.Context, id uint) (*entity.PayoutBatch, error)
+	UpdatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch) error
+	ListOpenPayoutBatches(ctx context.Context, skip []uint, limit int) ([]entity.PayoutBatch, error)
+	GetPayoutLine(ctx context.Context, id uint) (*entity.PayoutLine, error)
+	ListPayoutLines(ctx context.Context, batchID uint, status string, limit int) ([]entity.PayoutLine, error)
+	FinishPayoutLine(ctx context.Context, line *entity.PayoutLine) error
+	AddPayoutLineAttempt(ctx context.Context, id uint) error
+	CountPayoutLines(ctx context.Context, batchID uint) (map[string]int, error)
+	WithTx(ctx context.Context, fn func(repo AccountRepository) error) error
+	WithSnapshot(ctx context.Context, fn func(repo AccountRepository) error) error
 }
 
 type accountRepository struct {
@@ -27,37 +121,164 @@ func NewAccountRepository(db *gorm.DB) AccountRepository {
 }
 
 func (r *accountRepository) CreateAccount(ctx context.Context, account *entity.Account) error {
-	return r.db.Create(account).Error
+	return r.db.WithContext(ctx).Create(account).Error
 }
 
+// GetAccountByCustomerID returns the customer's oldest account, the one
+// opened at registration.
 func (r *accountRepository) GetAccountByCustomerID(ctx context.Context, customerID uint) (*entity.Account, error) {
 	var account entity.Account
-	err := r.db.Where("Customer_ID = ?", customerID).First(&account).Error
+	err := r.db.WithContext(ctx).Where("Customer_ID = ?", customerID).First(&account).Error
+	return &account, err
+}
+
+func (r *accountRepository) GetAccountByNumber(ctx context.Context, number string) (*entity.Account, error) {
+	var account entity.Account
+	err := r.db.WithContext(ctx).Where("number = ?", number).First(&account).Error
+	return &account, err
+}
+
+func (r *accountRepository) GetAccountByID(ctx context.Context, id uint) (*entity.Account, error) {
+	var account entity.Account
+	err := r.db.WithContext(ctx).First(&account, id).Error
 	return &account, err
 }
 
-func (r *accountRepository) UpdateAccount(ctx context.Context, account *entity.Account) error {
-	return r.db.Save(account).Error
+func (r *accountRepository) ListAccountsByCustomerID(ctx context.Context, customerID uint) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+// ListAccounts pages through every account, closed ones included, in ID
+// order.
+func (r *accountRepository) ListAccounts(ctx context.Context, afterID uint, limit int) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&accounts).Error
+	return accounts, err
+}
+
+// ListInterestAccounts pages through the open accounts, in ID order, that are
+// of one of the given product types or are charged overdraft interest.
+func (r *accountRepository) ListInterestAccounts(ctx context.Context, productTypes []string, afterID uint, limit int) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := r.db.WithContext(ctx).
+		Where("(product_type IN ? OR overdraft_rate_bp > 0) AND status <> ? AND id > ?", productTypes, entity.AccountClosed, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// SaveAccountState writes the columns of an account that project its event
+// stream. The version is left alone.
+func (r *accountRepository) SaveAccountState(ctx context.Context, account *entity.Account) error {
+	return r.db.WithContext(ctx).Model(&entity.Account{}).
+		Where("id = ?", account.ID).
+		Select("status", "overdraft_limit", "overdraft_rate_bp", "opened_at").
+		Updates(account).Error
+}
+
+// BumpAccountVersion increments the version of an account, provided it is
+// still the one that was read. Balance changes call it inside their
+// transaction: of two transactions that read the same version only the first
+// to commit succeeds, the other gets ErrConcurrentUpdate and rolls back.
+func (r *accountRepository) BumpAccountVersion(ctx context.Context, account *entity.Account) error {
+	result := r.db.WithContext(ctx).Model(&entity.Account{}).
+		Where("id = ? AND version = ?", account.ID, account.Version).
+		Update("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	account.Version++
+	return nil
 }
 
 func (r *accountRepository) CreateTransaction(ctx context.Context, transaction *entity.Transaction) error {
-	return r.db.Create(transaction).Error
+	return r.db.WithContext(ctx).Create(transaction).Error
+}
+
+func (r *accountRepository) UpdateTransaction(ctx context.Context, transaction *entity.Transaction) error {
+	return r.db.WithContext(ctx).Save(transaction).Error
+}
+
+func (r *accountRepository) GetTransaction(ctx context.Context, id uint) (*entity.Transaction, error) {
+	var transaction entity.Transaction
+	err := r.db.WithContext(ctx).First(&transaction, id).Error
+	return &transaction, err
+}
+
+// GetTransactionsByJournalEntryID returns the history rows of one journal
+// entry: one for most entries, both legs for a transfer.
+func (r *accountRepository) GetTransactionsByJournalEntryID(ctx context.Context, journalEntryID uint) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := r.db.WithContext(ctx).Where("journal_entry_id = ?", journalEntryID).Order("id").Find(&transactions).Error
+	return transactions, err
+}
+
+// TransactionFilter selects a page of transactions. Zero fields do not
+// filter; AfterID continues after the last transaction of the previous page.
+type TransactionFilter struct {
+	CustomerID uint
+	AccountID  uint
+	Types      []string
+	From       time.Time
+	To         time.Time
+	AfterID    uint
+	Limit      int
 }
 
-func (r *accountRepository) GetTransactionsByCustomerID(ctx context.Context, customerID uint) ([]entity.Transaction, error) {
+// ListTransactions returns transactions matching the filter in ID order,
+// which is also the order they were booked in.
+func (r *accountRepository) ListTransactions(ctx context.Context, filter TransactionFilter) ([]entity.Transaction, error) {
 	var transactions []entity.Transaction
-	err := r.db.Where("customer_id = ?", customerID).Find(&transactions).Error
+	err := filter.apply(r.db.WithContext(ctx).Model(&entity.Transaction{}), "id").Find(&transactions).Error
 	return transactions, err
 }
 
-func (r *accountRepository) Begin() *gorm.DB {
-	return r.db.Begin()
+// apply narrows query down to the filter, ordered by idColumn.
+func (filter TransactionFilter) apply(query *gorm.DB, idColumn string) *gorm.DB {
+	if filter.CustomerID != 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.AccountID != 0 {
+		query = query.Where("account_id = ?", filter.AccountID)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date < ?", filter.To)
+	}
+	if filter.AfterID != 0 {
+		query = query.Where(idColumn+" > ?", filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	return query.Order(idColumn)
 }
 
-func (r *accountRepository) Commit() error {
-	return r.db.Commit().Error
+// WithTx runs fn in a database transaction. The repository passed to fn is
+// bound to that transaction; it is committed when fn returns nil and rolled
+// back otherwise.
+func (r *accountRepository) WithTx(ctx context.Context, fn func(repo AccountRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&accountRepository{db: tx})
+	})
 }
 
-func (r *accountRepository) Rollback() error {
-	return r.db.Rollback().Error
+// WithSnapshot runs fn in a read-only repeatable read transaction, so that
+// every query fn makes sees the database as it was at its first query, no
+// matter what commits in between.
+func (r *accountRepository) WithSnapshot(ctx context.Context, fn func(repo AccountRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&accountRepository{db: tx})
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
 }
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *accountRepository) CreateBalanceSnapshot(ctx context.Context, snapshot *entity.BalanceSnapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+// LatestBalanceSnapshot returns the newest snapshot of an account taken as of
+// at or earlier; false when there is none.
+func (r *accountRepository) LatestBalanceSnapshot(ctx context.Context, accountID uint, at time.Time) (*entity.BalanceSnapshot, bool, error) {
+	var snapshot entity.BalanceSnapshot
+	err := r.db.WithContext(ctx).
+		Where("account_id = ? AND as_of <= ?", accountID, at).
+		Order("as_of DESC").
+		Take(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	return &snapshot, err == nil, err
+}
+
+// SumTransactions replays the transactions of an account dated from from up
+// to, but excluding, to: credits count positive and everything else
+// negative.
+func (r *accountRepository) SumTransactions(ctx context.Context, accountID uint, from, to time.Time) (int64, error) {
+	var sum int64
+	err := r.db.WithContext(ctx).Model(&entity.Transaction{}).
+		Where("account_id = ? AND date >= ? AND date < ?", accountID, from, to).
+		Select("COALESCE(SUM(CASE WHEN type IN ? THEN amount ELSE -amount END), 0)", entity.CreditTypes).
+		Scan(&sum).Error
+	return sum, err
+}
+
+// ListBalanceSnapshots returns the snapshots of an account, oldest first.
+func (r *accountRepository) ListBalanceSnapshots(ctx context.Context, accountID uint) ([]entity.BalanceSnapshot, error) {
+	var snapshots []entity.BalanceSnapshot
+	err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Order("as_of").Find(&snapshots).Error
+	return snapshots, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *accountRepository) CreateStandingOrder(ctx context.Context, order *entity.StandingOrder) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r *accountRepository) GetStandingOrder(ctx context.Context, id uint) (*entity.StandingOrder, error) {
+	var order entity.StandingOrder
+	err := r.db.WithContext(ctx).First(&order, id).Error
+	return &order, err
+}
+
+// UpdateStandingOrder saves a standing order and bumps its version, provided
+// it is still the version that was read.
+func (r *accountRepository) UpdateStandingOrder(ctx context.Context, order *entity.StandingOrder) error {
+	result := r.db.WithContext(ctx).Model(&entity.StandingOrder{}).
+		Where("id = ? AND version = ?", order.ID, order.Version).
+		Updates(map[string]interface{}{
+			"due_date":    order.DueDate,
+			"next_run_at": order.NextRunAt,
+			"attempts":    order.Attempts,
+			"status":      order.Status,
+			"version":     gorm.Expr("version + 1"),
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	order.Version++
+	return nil
+}
+
+// ListStandingOrders returns the standing orders of a customer, or of one of
+// its accounts when accountID is set, oldest first.
+func (r *accountRepository) ListStandingOrders(ctx context.Context, customerID, accountID uint) ([]entity.StandingOrder, error) {
+	query := r.db.WithContext(ctx).Where("customer_id = ?", customerID)
+	if accountID != 0 {
+		query = query.Where("account_id = ?", accountID)
+	}
+	var orders []entity.StandingOrder
+	err := query.Order("id").Find(&orders).Error
+	return orders, err
+}
+
+// GetDueStandingOrders returns active standing orders whose next run is due
+// at now, earliest first, leaving out the orders in skip.
+func (r *accountRepository) GetDueStandingOrders(ctx context.Context, now time.Time, skip []uint, limit int) ([]entity.StandingOrder, error) {
+	var orders []entity.StandingOrder
+	query := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", entity.StandingOrderActive, now)
+	if len(skip) > 0 {
+		query = query.Where("id NOT IN ?", skip)
+	}
+	err := query.
+		Order("next_run_at, id").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *accountRepository) CreateStandingOrderExecution(ctx context.Context, execution *entity.StandingOrderExecution) error {
+	return r.db.WithContext(ctx).Create(execution).Error
+}
+
+// ListStandingOrderExecutions returns the execution history of a standing
+// order, oldest first.
+func (r *accountRepository) ListStandingOrderExecutions(ctx context.Context, standingOrderID uint) ([]entity.StandingOrderExecution, error) {
+	var executions []entity.StandingOrderExecution
+	err := r.db.WithContext(ctx).
+		Where("standing_order_id = ?", standingOrderID).
+		Order("id").
+		Find(&executions).Error
+	return executions, err
+}
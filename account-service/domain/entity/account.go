@@ -1,7 +1,149 @@
 package entity
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Product types a customer can open an account for.
+const (
+	ProductChecking = "checking"
+	ProductSavings  = "savings"
+)
+
+// Account statuses. Active accounts accept every operation, frozen and
+// dormant ones credits only, and closed ones nothing.
+const (
+	AccountActive  = "active"
+	AccountFrozen  = "frozen"
+	AccountDormant = "dormant"
+	AccountClosed  = "closed"
+)
+
+var (
+	ErrUnknownProduct    = errors.New("unknown product type")
+	ErrUnknownStatus     = errors.New("unknown account status")
+	ErrInvalidTransition = errors.New("account status change is not allowed")
+	ErrAccountFrozen     = errors.New("account is frozen")
+	ErrAccountDormant    = errors.New("account is dormant")
+	ErrAccountClosed     = errors.New("account is closed")
+)
+
+// statusTransitions lists the statuses an account may move to from each
+// status. Closing is final.
+var statusTransitions = map[string][]string{
+	AccountActive:  {AccountFrozen, AccountDormant, AccountClosed},
+	AccountFrozen:  {AccountActive, AccountClosed},
+	AccountDormant: {AccountActive, AccountFrozen, AccountClosed},
+}
+
+// Account is one of possibly several accounts a customer holds. Number is the
+// identifier shown to customers and used by every money-moving call. Version
+// is bumped by every balance change so that concurrent changes conflict.
+// An arranged overdraft lets the balance go down to -OverdraftLimit, in minor
+// units; the negative balance is charged OverdraftRateBP basis points a year.
+// Status changes are recorded as AccountStatusChange rows.
 type Account struct {
-	ID         uint `gorm:"primaryKey"`
-	CustomerID uint
-	Balance    float64
+	ID              uint   `gorm:"primaryKey"`
+	CustomerID      uint   `gorm:"index"`
+	Number          string `gorm:"size:12;uniqueIndex;default:null"`
+	ProductType     string
+	Currency        string `gorm:"size:3"`
+	Version         uint   `gorm:"not null;default:0"`
+	OverdraftLimit  int64  `gorm:"not null;default:0"`
+	OverdraftRateBP int64  `gorm:"not null;default:0"`
+	Status          string `gorm:"size:16;index"`
+	OpenedAt        *time.Time
+}
+
+// AccountStatusChange records who moved an account from one status to
+// another, and why.
+type AccountStatusChange struct {
+	ID         uint   `gorm:"primaryKey"`
+	AccountID  uint   `gorm:"index"`
+	FromStatus string `gorm:"size:16"`
+	ToStatus   string `gorm:"size:16"`
+	Reason     string
+	Actor      string
+	CreatedAt  time.Time
+}
+
+// AfterCreate assigns the account number as soon as the ID is known.
+func (a *Account) AfterCreate(tx *gorm.DB) error {
+	a.Number = AccountNumber(a.ID)
+	return tx.Model(a).Update("number", a.Number).Error
+}
+
+func ValidProductType(productType string) bool {
+	return productType == ProductChecking || productType == ProductSavings
+}
+
+func ValidAccountStatus(status string) bool {
+	switch status {
+	case AccountActive, AccountFrozen, AccountDormant, AccountClosed:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether the account may move to status.
+func (a *Account) CanTransition(status string) bool {
+	for _, allowed := range statusTransitions[a.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanDebit returns the reason money cannot leave the account, if any.
+func (a *Account) CanDebit() error {
+	switch a.Status {
+	case AccountFrozen:
+		return ErrAccountFrozen
+	case AccountDormant:
+		return ErrAccountDormant
+	case AccountClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// CanCredit returns the reason money cannot be paid into the account, if
+// any.
+func (a *Account) CanCredit() error {
+	if a.Status == AccountClosed {
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// AccountNumber derives the account number of an account from its ID: ten
+// digits followed by two ISO 7064 MOD 97-10 check digits, so that a mistyped
+// number is rejected instead of reaching another customer's account.
+func AccountNumber(id uint) string {
+	base := fmt.Sprintf("%010d", id)
+	return base + fmt.Sprintf("%02d", 98-mod97(base+"00"))
+}
+
+// ValidAccountNumber verifies the check digits of an account number.
+func ValidAccountNumber(number string) bool {
+	if len(number) != 12 {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return mod97(number) == 1
+}
+
+func mod97(digits string) int64 {
+	n, _ := strconv.ParseInt(digits, 10, 64)
+	return n % 97
 }
@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+// AccountEvent is one entry of the event stream of an account. Streams are
+// append-only: Version numbers the events of an account from 1 without gaps,
+// and the unique index keeps two writers from appending the same version.
+// Data is the JSON encoding of the event, see the aggregate package.
+type AccountEvent struct {
+	ID         uint   `gorm:"primaryKey"`
+	AccountID  uint   `gorm:"not null;uniqueIndex:idx_account_events_stream"`
+	Version    uint   `gorm:"not null;uniqueIndex:idx_account_events_stream"`
+	Type       string `gorm:"size:64"`
+	Data       string `gorm:"type:text"`
+	OccurredAt time.Time
+}
+
+// AccountBalance is the balance projection of an account: its balance as of
+// the event Version of its stream.
+type AccountBalance struct {
+	AccountID uint `gorm:"primaryKey;autoIncrement:false"`
+	Balance   int64
+	Currency  string `gorm:"size:3"`
+	Version   uint
+	UpdatedAt time.Time
+}
+
+// HistoryEntry is the history projection of a transaction: the transaction
+// as booked, its later links and the balance of the account right after it.
+type HistoryEntry struct {
+	ID                      uint `gorm:"primaryKey"`
+	TransactionID           uint `gorm:"uniqueIndex"`
+	AccountID               uint `gorm:"index"`
+	CustomerID              uint `gorm:"index"`
+	JournalEntryID          uint
+	LinkedTransactionID     *uint
+	ReversesTransactionID   *uint
+	ReversedByTransactionID *uint
+	Type                    string
+	Amount                  int64
+	Currency                string `gorm:"size:3"`
+	Reference               string
+	ReasonCode              string
+	Actor                   string
+	Date                    time.Time `gorm:"index"`
+	BalanceAfter            int64
+}
+
+// TableName keeps the projection apart from the transactions it is built
+// from.
+func (HistoryEntry) TableName() string {
+	return "account_history"
+}
+
+// Money returns the amount of the entry with its currency.
+func (h *HistoryEntry) Money() Money {
+	return Money{Units: h.Amount, Currency: h.Currency}
+}
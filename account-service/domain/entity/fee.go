@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// FeeCharge records that a monthly fee rule was applied to an account for a
+// period, a YYYY-MM month, so that it is charged once however often the job
+// runs. TransactionID points at the fee transaction; it is nil when the fee
+// was waived.
+type FeeCharge struct {
+	ID            uint   `gorm:"primaryKey"`
+	AccountID     uint   `gorm:"uniqueIndex:idx_fee_charges_account_rule_period"`
+	Rule          string `gorm:"uniqueIndex:idx_fee_charges_account_rule_period"`
+	Period        string `gorm:"size:7;uniqueIndex:idx_fee_charges_account_rule_period"`
+	TransactionID *uint
+	Waived        bool
+	CreatedAt     time.Time
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Hold statuses. Only active holds reduce the available balance.
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+var (
+	ErrHoldNotActive   = errors.New("hold is no longer active")
+	ErrCaptureTooLarge = errors.New("capture amount exceeds the hold")
+)
+
+// Hold reserves funds of an account before they are settled, e.g. for a
+// pending card payment. The reserved amount stays in the ledger balance but
+// is not available for other withdrawals.
+type Hold struct {
+	ID            uint `gorm:"primaryKey"`
+	AccountID     uint `gorm:"index"`
+	Amount        int64
+	Currency      string `gorm:"size:3"`
+	Reference     string
+	Status        string    `gorm:"index"`
+	ExpiresAt     time.Time `gorm:"index"`
+	TransactionID *uint
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Money returns the reserved amount.
+func (h *Hold) Money() Money {
+	return Money{Units: h.Amount, Currency: h.Currency}
+}
+
+// ActiveAt reports whether the hold still reserves funds at the given time.
+func (h *Hold) ActiveAt(now time.Time) bool {
+	return h.Status == HoldActive && now.Before(h.ExpiresAt)
+}
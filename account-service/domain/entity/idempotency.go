@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// MaxRequestIDLength bounds the request IDs clients may send.
+const MaxRequestIDLength = 128
+
+// IdempotencyKey remembers a request that moved money under a client's
+// request ID: which request it was, as a hash, and the response it got.
+// Request IDs are chosen by the clients, so they are unique per customer
+// only.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	CustomerID  uint   `gorm:"not null;default:0;uniqueIndex:idx_idempotency_keys_customer_request"`
+	RequestID   string `gorm:"size:128;not null;uniqueIndex:idx_idempotency_keys_customer_request"`
+	Operation   string `gorm:"size:64"`
+	RequestHash string `gorm:"size:64"`
+	Response    []byte
+	CreatedAt   time.Time
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// InterestAccrual records the interest an account earned on one day, and the
+// overdraft interest it was charged, in millionths of a minor unit. There is
+// one row per account and day, also for days that earned nothing, so the
+// last row tells how far accrual has got.
+// Accruals are posted to the account once a month; PostedAt is set then and
+// TransactionID points at the interest transaction, if the month's interest
+// came to at least one minor unit. What a posted month left below one minor
+// unit is kept on its last accrual, as RemainderMicros and
+// OverdraftRemainderMicros, and carried into the next month's posting.
+type InterestAccrual struct {
+	ID              uint      `gorm:"primaryKey"`
+	AccountID       uint      `gorm:"uniqueIndex:idx_interest_accruals_account_date"`
+	Date            time.Time `gorm:"uniqueIndex:idx_interest_accruals_account_date"`
+	Balance         int64
+	Micros          int64
+	OverdraftMicros int64
+	Currency        string     `gorm:"size:3"`
+	PostedAt        *time.Time `gorm:"index"`
+	TransactionID   *uint
+
+	RemainderMicros          int64
+	OverdraftRemainderMicros int64
+}
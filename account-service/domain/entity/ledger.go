@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Internal ledger accounts kept by the bank next to the customer accounts.
+const (
+	LedgerCash            = "cash"
+	LedgerFees            = "fees"
+	LedgerSuspense        = "suspense"
+	LedgerInterestExpense = "interest_expense"
+	LedgerWithholdingTax  = "withholding_tax"
+	LedgerInterestIncome  = "interest_income"
+)
+
+// Ledger account types. Assets and expenses carry a debit balance,
+// liabilities and income a credit balance.
+const (
+	LedgerTypeAsset     = "asset"
+	LedgerTypeLiability = "liability"
+	LedgerTypeIncome    = "income"
+	LedgerTypeExpense   = "expense"
+)
+
+var (
+	ErrUnbalancedJournal = errors.New("journal entry does not balance")
+	ErrEmptyJournal      = errors.New("journal entry needs at least two postings")
+	ErrZeroPosting       = errors.New("posting amount must not be zero")
+)
+
+type LedgerAccount struct {
+	ID        uint   `gorm:"primaryKey"`
+	Code      string `gorm:"uniqueIndex:idx_ledger_accounts_code_currency"`
+	Type      string
+	Currency  string `gorm:"size:3;uniqueIndex:idx_ledger_accounts_code_currency"`
+	AccountID *uint  `gorm:"index"`
+}
+
+// JournalEntry groups the postings of one business event. Posting amounts are
+// signed minor units: debits are positive and credits negative, so a valid
+// journal sums to zero in every currency it touches.
+type JournalEntry struct {
+	ID          uint `gorm:"primaryKey"`
+	Description string
+	Date        time.Time
+	Postings    []Posting
+}
+
+type Posting struct {
+	ID              uint `gorm:"primaryKey"`
+	JournalEntryID  uint `gorm:"index"`
+	LedgerAccountID uint `gorm:"index"`
+	Amount          int64
+	Currency        string `gorm:"size:3"`
+}
+
+func (j *JournalEntry) Validate() error {
+	if len(j.Postings) < 2 {
+		return ErrEmptyJournal
+	}
+
+	sums := make(map[string]int64)
+	for _, p := range j.Postings {
+		if p.Amount == 0 {
+			return ErrZeroPosting
+		}
+		sums[p.Currency] += p.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return ErrUnbalancedJournal
+		}
+	}
+	return nil
+}
+
+// BeforeCreate refuses to persist a journal that breaks the double-entry
+// invariant, whichever code path created it.
+func (j *JournalEntry) BeforeCreate(tx *gorm.DB) error {
+	return j.Validate()
+}
+
+// Balance returns the balance of a ledger account in its natural sign, given
+// the raw sum of its postings.
+func (a *LedgerAccount) Balance(postingSum int64) int64 {
+	if a.Type == LedgerTypeAsset || a.Type == LedgerTypeExpense {
+		return postingSum
+	}
+	return -postingSum
+}
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// Scopes of a withdrawal limit.
+const (
+	LimitScopeAccount  = "account"
+	LimitScopeCustomer = "customer"
+)
+
+// Reasons a withdrawal is rejected by a limit.
+const (
+	LimitSingleAmount = "single_amount"
+	LimitHourlyCount  = "hourly_count"
+	LimitDailyTotal   = "daily_total"
+	LimitMonthlyTotal = "monthly_total"
+)
+
+// WithdrawalLimit caps the withdrawals of one account, when AccountID is set,
+// or of all accounts of a customer in Currency. Amounts are minor units of
+// Currency; zero means no limit. A limit without ExpiresAt is the standing
+// policy of its scope, and the newest one wins. A limit with ExpiresAt is an
+// override that replaces the policy until it expires. Rows are never changed,
+// so they double as the history of the limits.
+type WithdrawalLimit struct {
+	ID         uint   `gorm:"primaryKey"`
+	AccountID  *uint  `gorm:"index"`
+	CustomerID uint   `gorm:"index"`
+	Currency   string `gorm:"size:3"`
+	MaxSingle  int64
+	MaxDaily   int64
+	MaxMonthly int64
+	MaxPerHour int64
+	ExpiresAt  *time.Time
+	Actor      string
+	CreatedAt  time.Time
+}
+
+// Scope tells whether the limit applies to an account or a customer.
+func (l *WithdrawalLimit) Scope() string {
+	if l.AccountID != nil {
+		return LimitScopeAccount
+	}
+	return LimitScopeCustomer
+}
+
+// IsOverride reports whether the limit only applies until it expires.
+func (l *WithdrawalLimit) IsOverride() bool {
+	return l.ExpiresAt != nil
+}
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for accounts opened without an explicit currency and
+// for rows written before amounts carried one.
+const DefaultCurrency = "USD"
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency does not match the account currency")
+	ErrNonPositive      = errors.New("amount must be positive")
+)
+
+// currencyExponents maps ISO 4217 codes to the number of minor unit digits.
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "INR": 2, "IRR": 2,
+	"JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2, "TRY": 2, "USD": 2, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of minor unit digits of a currency.
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return exponent, nil
+}
+
+// Money is an exact amount in the minor unit of its currency.
+type Money struct {
+	Units    int64
+	Currency string
+}
+
+func NewMoney(units int64, currency string) (Money, error) {
+	if _, err := CurrencyExponent(currency); err != nil {
+		return Money{}, err
+	}
+	return Money{Units: units, Currency: currency}, nil
+}
+
+// String formats the amount as a decimal number followed by its currency,
+// e.g. "10.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount as a decimal number with as many fraction
+// digits as the currency has, e.g. "10.50".
+func (m Money) Decimal() string {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil || exponent == 0 {
+		return fmt.Sprintf("%d", m.Units)
+	}
+
+	sign := ""
+	units := m.Units
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	digits := fmt.Sprintf("%0*d", exponent+1, units)
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// ParseDecimal reads a decimal amount in major units, e.g. "-10.50", into
+// minor units of the currency. Fraction digits beyond the currency's may
+// only be zeros.
+func ParseDecimal(value, currency string) (int64, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return 0, fmt.Errorf("%v %q", err, currency)
+	}
+	sign := int64(1)
+	digits := value
+	switch {
+	case strings.HasPrefix(digits, "-"):
+		sign, digits = -1, digits[1:]
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	fraction = strings.TrimRight(fraction, "0")
+	if whole == "" || len(fraction) > exponent || strings.ContainsAny(whole+fraction, "+-") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return sign * units, nil
+}
+
+// NormalizeCurrency upper-cases a currency code and falls back to the default
+// currency when none was given.
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
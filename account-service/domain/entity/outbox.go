@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// OutboxMessage is a domain event waiting to be published. It is written in
+// the same database transaction as the change it describes, so an event is
+// published if and only if the change was committed.
+type OutboxMessage struct {
+	ID          uint   `gorm:"primaryKey"`
+	EventType   string `gorm:"index"`
+	AggregateID string `gorm:"index"`
+	Payload     string `gorm:"type:text"`
+	CreatedAt   time.Time
+	PublishedAt *time.Time `gorm:"index"`
+	Attempts    int
+	LastError   string
+}
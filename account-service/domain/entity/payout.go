@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+// Payout batch statuses. A batch is pending until its first line is
+// executed and completed once every line succeeded or failed.
+const (
+	PayoutBatchPending    = "pending"
+	PayoutBatchProcessing = "processing"
+	PayoutBatchCompleted  = "completed"
+)
+
+// Payout line statuses.
+const (
+	PayoutLinePending   = "pending"
+	PayoutLineSucceeded = "succeeded"
+	PayoutLineFailed    = "failed"
+)
+
+// PayoutBatch is an uploaded payout file, paid from one account of a
+// customer. Total is the sum of its lines in the account currency. Version
+// is bumped by every update, so that two runs cannot both complete it.
+type PayoutBatch struct {
+	ID          uint   `gorm:"primaryKey"`
+	CustomerID  uint   `gorm:"index"`
+	AccountID   uint   `gorm:"index"`
+	Format      string `gorm:"size:16"`
+	Source      string
+	Status      string `gorm:"index"`
+	Lines       int
+	Total       int64
+	Currency    string `gorm:"size:3"`
+	Version     uint   `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// PayoutLine is one payment of a payout batch. Line is its line number in
+// the uploaded file. TransactionID points at the withdrawal, or the outgoing
+// transfer leg, of a line that succeeded; Message says why a line failed.
+// Attempts counts the runs that could not execute a pending line for an
+// internal reason.
+type PayoutLine struct {
+	ID              uint `gorm:"primaryKey"`
+	BatchID         uint `gorm:"index"`
+	Line            int
+	Type            string `gorm:"size:16"`
+	ToAccountNumber string
+	Name            string
+	Amount          int64
+	Currency        string `gorm:"size:3"`
+	Reference       string
+	EndToEndID      string
+	Status          string `gorm:"index"`
+	Attempts        int    `gorm:"not null;default:0"`
+	TransactionID   *uint
+	Message         string
+	ExecutedAt      *time.Time
+}
+
+func (b *PayoutBatch) Money() Money {
+	return Money{Units: b.Total, Currency: b.Currency}
+}
+
+func (l *PayoutLine) Money() Money {
+	return Money{Units: l.Amount, Currency: l.Currency}
+}
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// Reconciliation is one settlement file matched against the transactions,
+// with how many of its lines ended up in each state.
+type Reconciliation struct {
+	ID         uint   `gorm:"primaryKey"`
+	Format     string `gorm:"size:16"`
+	Source     string
+	Actor      string
+	Lines      int
+	Matched    int
+	Unmatched  int
+	Ambiguous  int
+	Duplicates int
+	CreatedAt  time.Time
+}
+
+// Reconciliation item states. A duplicate line was matched by an earlier
+// reconciliation already.
+const (
+	ReconciliationMatched   = "matched"
+	ReconciliationUnmatched = "unmatched"
+	ReconciliationAmbiguous = "ambiguous"
+	ReconciliationDuplicate = "duplicate"
+)
+
+// ReconciliationItem is a line of a settlement file and what it was matched
+// to. TransactionID is unique, so that no transaction settles two lines;
+// for duplicate lines it stays empty and Candidates holds the transaction
+// the line was matched to before. Candidates of ambiguous lines lists the
+// transactions that fit, comma separated.
+type ReconciliationItem struct {
+	ID               uint `gorm:"primaryKey"`
+	ReconciliationID uint `gorm:"index"`
+	Line             int
+	ExternalID       string `gorm:"index"`
+	Amount           int64
+	Currency         string `gorm:"size:3"`
+	Date             time.Time
+	Reference        string
+	Status           string `gorm:"size:16"`
+	TransactionID    *uint  `gorm:"uniqueIndex"`
+	Candidates       string
+}
+
+func (i *ReconciliationItem) Money() Money {
+	return Money{Units: i.Amount, Currency: i.Currency}
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// BalanceSnapshot is the ledger balance of an account from every journal
+// entry dated before AsOf, a UTC midnight. Balances at later times are the
+// snapshot plus the transactions dated from AsOf on.
+type BalanceSnapshot struct {
+	ID        uint      `gorm:"primaryKey"`
+	AccountID uint      `gorm:"uniqueIndex:idx_balance_snapshots_account_as_of"`
+	AsOf      time.Time `gorm:"uniqueIndex:idx_balance_snapshots_account_as_of"`
+	Balance   int64
+	Currency  string `gorm:"size:3"`
+	CreatedAt time.Time
+}
+
+func (s *BalanceSnapshot) Money() Money {
+	return Money{Units: s.Balance, Currency: s.Currency}
+}
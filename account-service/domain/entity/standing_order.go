@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Standing order frequencies. Monthly orders run on DayOfMonth, or on the
+// last day of shorter months; weekly and fortnightly ones on the weekday of
+// StartDate.
+const (
+	FrequencyMonthly  = "monthly"
+	FrequencyWeekly   = "weekly"
+	FrequencyBiweekly = "biweekly"
+)
+
+// Standing order statuses.
+const (
+	StandingOrderActive    = "active"
+	StandingOrderCancelled = "cancelled"
+	StandingOrderCompleted = "completed"
+)
+
+// Outcomes of a standing order execution attempt.
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionRetrying  = "retrying"
+	ExecutionFailed    = "failed"
+)
+
+var (
+	ErrUnknownFrequency     = errors.New("frequency must be monthly, weekly or biweekly")
+	ErrInvalidDayOfMonth    = errors.New("day of month must be between 1 and 31")
+	ErrStandingOrderStopped = errors.New("standing order is no longer active")
+)
+
+// StandingOrder transfers Amount from an account to another on a schedule,
+// from StartDate until EndDate, if set, inclusive. DueDate is the occurrence
+// being executed; NextRunAt is when it is tried next, which is later than
+// DueDate while failed attempts are retried. Attempts counts the failed
+// attempts of the current occurrence. Version is bumped by every update, so
+// that an execution and a cancellation, or two executions, cannot both win.
+type StandingOrder struct {
+	ID              uint `gorm:"primaryKey"`
+	CustomerID      uint `gorm:"index"`
+	AccountID       uint `gorm:"index"`
+	ToAccountNumber string
+	Amount          int64
+	Currency        string `gorm:"size:3"`
+	Reference       string
+	Frequency       string
+	DayOfMonth      int
+	StartDate       time.Time
+	EndDate         *time.Time
+	DueDate         time.Time
+	NextRunAt       time.Time `gorm:"index"`
+	Attempts        int
+	Status          string `gorm:"index"`
+	Version         uint   `gorm:"not null;default:0"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// StandingOrderExecution records one attempt to execute an occurrence of a
+// standing order. TransactionID points at the outgoing transfer leg of a
+// successful attempt.
+type StandingOrderExecution struct {
+	ID              uint `gorm:"primaryKey"`
+	StandingOrderID uint `gorm:"index"`
+	DueDate         time.Time
+	Attempt         int
+	Status          string
+	TransactionID   *uint
+	Message         string
+	ExecutedAt      time.Time
+}
+
+func ValidFrequency(frequency string) bool {
+	switch frequency {
+	case FrequencyMonthly, FrequencyWeekly, FrequencyBiweekly:
+		return true
+	}
+	return false
+}
+
+// Money returns the amount transferred by each execution.
+func (o *StandingOrder) Money() Money {
+	return Money{Units: o.Amount, Currency: o.Currency}
+}
+
+// FirstOccurrence is the first day on or after StartDate the order runs.
+func (o *StandingOrder) FirstOccurrence() time.Time {
+	if o.Frequency != FrequencyMonthly {
+		return o.StartDate
+	}
+	day := o.monthlyDay(o.StartDate.Year(), o.StartDate.Month())
+	if day.Before(o.StartDate) {
+		next := o.StartDate.AddDate(0, 0, 1-o.StartDate.Day()).AddDate(0, 1, 0)
+		day = o.monthlyDay(next.Year(), next.Month())
+	}
+	return day
+}
+
+// NextOccurrence is the occurrence after the one on day.
+func (o *StandingOrder) NextOccurrence(day time.Time) time.Time {
+	switch o.Frequency {
+	case FrequencyWeekly:
+		return day.AddDate(0, 0, 7)
+	case FrequencyBiweekly:
+		return day.AddDate(0, 0, 14)
+	}
+	next := day.AddDate(0, 0, 1-day.Day()).AddDate(0, 1, 0)
+	return o.monthlyDay(next.Year(), next.Month())
+}
+
+// Ends reports whether the order has no occurrence on day or later.
+func (o *StandingOrder) Ends(day time.Time) bool {
+	return o.EndDate != nil && day.After(*o.EndDate)
+}
+
+// monthlyDay is the day of a month a monthly order runs, DayOfMonth or the
+// last day of the month if it is shorter.
+func (o *StandingOrder) monthlyDay(year int, month time.Month) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	day := o.DayOfMonth
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -1,13 +1,102 @@
 package entity
 
 import (
+	"errors"
 	"time"
 )
 
+// Transaction types recorded in the history.
+const (
+	TransactionDeposit           = "deposit"
+	TransactionWithdraw          = "withdraw"
+	TransactionTransferOut       = "transfer_out"
+	TransactionTransferIn        = "transfer_in"
+	TransactionHoldCapture       = "hold_capture"
+	TransactionReversalCredit    = "reversal_credit"
+	TransactionReversalDebit     = "reversal_debit"
+	TransactionInterest          = "interest"
+	TransactionWithholdingTax    = "withholding_tax"
+	TransactionOverdraftInterest = "overdraft_interest"
+	TransactionWithdrawalFee     = "withdrawal_fee"
+	TransactionDepositFee        = "deposit_fee"
+	TransactionTransferFee       = "transfer_fee"
+	TransactionMaintenanceFee    = "maintenance_fee"
+)
+
+// Reason codes a reversal must give.
+const (
+	ReasonCustomerRequest = "customer_request"
+	ReasonOperatorError   = "operator_error"
+	ReasonDuplicate       = "duplicate"
+	ReasonFraud           = "fraud"
+)
+
+var ErrUnknownReason = errors.New("unknown reason code")
+
+// Transaction is a customer facing history entry. Both legs of a transfer are
+// recorded and point at each other through LinkedTransactionID. A reversal
+// points at the transaction it compensates through ReversesTransactionID,
+// which is unique so that nothing is reversed twice, and the original points
+// back through ReversedByTransactionID. Sequence, PrevHash and Hash chain the
+// transactions of each account; see ComputeHash. A sequence number is unique
+// within its account, so that two writers cannot fork the chain.
 type Transaction struct {
-	ID         uint `gorm:"primaryKey"`
-	CustomerID uint
-	Type       string
-	Amount     float64
-	Date       time.Time
+	ID                      uint `gorm:"primaryKey"`
+	CustomerID              uint `gorm:"index"`
+	AccountID               uint `gorm:"index;uniqueIndex:idx_transactions_account_sequence"`
+	JournalEntryID          uint `gorm:"index"`
+	LinkedTransactionID     *uint
+	ReversesTransactionID   *uint `gorm:"uniqueIndex"`
+	ReversedByTransactionID *uint
+	Type                    string
+	Amount                  int64
+	Currency                string `gorm:"size:3"`
+	Reference               string
+	ReasonCode              string
+	Actor                   string
+	Date                    time.Time `gorm:"index"`
+	Sequence                uint      `gorm:"not null;default:0;uniqueIndex:idx_transactions_account_sequence"`
+	PrevHash                string    `gorm:"size:64"`
+	Hash                    string    `gorm:"size:64"`
+}
+
+func (t *Transaction) Money() Money {
+	return Money{Units: t.Amount, Currency: t.Currency}
+}
+
+// CreditTypes are the transaction types that add money to the account; all
+// others take money out.
+var CreditTypes = []string{TransactionDeposit, TransactionTransferIn, TransactionReversalCredit, TransactionInterest}
+
+// IsCredit reports whether transactions of the given type add money to the
+// account.
+func IsCredit(transactionType string) bool {
+	for _, credit := range CreditTypes {
+		if transactionType == credit {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReversal reports whether the transaction compensates another one.
+func (t *Transaction) IsReversal() bool {
+	return t.ReversesTransactionID != nil
+}
+
+// ReversalType is the type of the transaction that compensates one of the
+// given type: credits are reversed by a debit and debits by a credit.
+func ReversalType(transactionType string) string {
+	if IsCredit(transactionType) {
+		return TransactionReversalDebit
+	}
+	return TransactionReversalCredit
+}
+
+func ValidReasonCode(code string) bool {
+	switch code {
+	case ReasonCustomerRequest, ReasonOperatorError, ReasonDuplicate, ReasonFraud:
+		return true
+	}
+	return false
 }
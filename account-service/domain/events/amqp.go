@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DefaultExchange is the topic exchange events are published to. The routing
+// key is the event type.
+const DefaultExchange = "accounts"
+
+var ErrNotConfirmed = errors.New("broker did not confirm the message")
+
+// AMQPPublisher publishes messages to a RabbitMQ topic exchange with
+// publisher confirms. It connects lazily and reconnects after the connection
+// was lost.
+type AMQPPublisher struct {
+	url      string
+	exchange string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+func NewAMQPPublisher(url, exchange string) *AMQPPublisher {
+	return &AMQPPublisher{url: url, exchange: exchange}
+}
+
+func (p *AMQPPublisher) Publish(ctx context.Context, message Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, p.exchange, message.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    strconv.FormatUint(uint64(message.ID), 10),
+		Type:         message.Type,
+		Timestamp:    message.OccurredAt,
+		Headers:      amqp.Table{"aggregate_id": message.AggregateID},
+		Body:         message.Payload,
+	})
+	if err != nil {
+		p.reset()
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// The channel may be broken, or still owe this confirm; drop it so
+		// that the next publish reconnects.
+		p.reset()
+		return err
+	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
+}
+
+// channel returns the open channel, connecting and declaring the exchange
+// first if needed. The caller holds p.mu.
+func (p *AMQPPublisher) channel() (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() && !p.conn.IsClosed() {
+		return p.ch, nil
+	}
+	p.reset()
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return nil, fmt.Errorf("connect to broker: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open channel: %w", err)
+	}
+	if err := ch.ExchangeDeclare(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("declare exchange: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("enable confirms: %w", err)
+	}
+
+	p.conn, p.ch = conn, ch
+	return ch, nil
+}
+
+func (p *AMQPPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.ch = nil, nil
+}
+
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
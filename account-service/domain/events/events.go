@@ -0,0 +1,334 @@
+// Package events defines the domain events the account service publishes and
+// the publishers that deliver them.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// Event types, also used as AMQP routing keys.
+const (
+	TypeAccountCreated   = "account.created"
+	TypeDeposited        = "account.deposited"
+	TypeWithdrawn        = "account.withdrawn"
+	TypeTransferred      = "account.transferred"
+	TypeHoldPlaced       = "account.hold_placed"
+	TypeHoldCaptured     = "account.hold_captured"
+	TypeHoldReleased     = "account.hold_released"
+	TypeReversed         = "account.transaction_reversed"
+	TypeInterestPosted   = "account.interest_posted"
+	TypeOverdrawn        = "account.overdrawn"
+	TypeOverdraftSet     = "account.overdraft_set"
+	TypeOverdraftCharged = "account.overdraft_interest_charged"
+	TypeFeeCharged       = "account.fee_charged"
+	TypeLimitSet         = "account.withdrawal_limit_set"
+	TypeStandingCreated  = "account.standing_order_created"
+	TypeStandingCanceled = "account.standing_order_cancelled"
+	TypeStandingFailed   = "account.standing_order_failed"
+	TypeStatusChanged    = "account.status_changed"
+	TypePayoutCompleted  = "account.payout_batch_completed"
+	TypeChainAnchored    = "ledger.chain_anchored"
+)
+
+// Event is a fact about an account that other services may react to.
+type Event interface {
+	// Type names the event, e.g. "account.deposited".
+	Type() string
+	// AggregateID is the number of the account the event belongs to.
+	AggregateID() string
+}
+
+// Amount is the wire form of entity.Money.
+type Amount struct {
+	Units    int64  `json:"units"`
+	Currency string `json:"currency"`
+}
+
+func NewAmount(money entity.Money) Amount {
+	return Amount{Units: money.Units, Currency: money.Currency}
+}
+
+type AccountCreated struct {
+	AccountNumber string    `json:"account_number"`
+	CustomerID    uint      `json:"customer_id"`
+	ProductType   string    `json:"product_type"`
+	Currency      string    `json:"currency"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e AccountCreated) Type() string        { return TypeAccountCreated }
+func (e AccountCreated) AggregateID() string { return e.AccountNumber }
+
+type Deposited struct {
+	AccountNumber string    `json:"account_number"`
+	CustomerID    uint      `json:"customer_id"`
+	TransactionID uint      `json:"transaction_id"`
+	Amount        Amount    `json:"amount"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e Deposited) Type() string        { return TypeDeposited }
+func (e Deposited) AggregateID() string { return e.AccountNumber }
+
+type Withdrawn struct {
+	AccountNumber string    `json:"account_number"`
+	CustomerID    uint      `json:"customer_id"`
+	TransactionID uint      `json:"transaction_id"`
+	Amount        Amount    `json:"amount"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e Withdrawn) Type() string        { return TypeWithdrawn }
+func (e Withdrawn) AggregateID() string { return e.AccountNumber }
+
+// Transferred belongs to the source account; consumers interested in the
+// target account find it in ToAccountNumber.
+type Transferred struct {
+	FromAccountNumber string    `json:"from_account_number"`
+	ToAccountNumber   string    `json:"to_account_number"`
+	OutTransactionID  uint      `json:"out_transaction_id"`
+	InTransactionID   uint      `json:"in_transaction_id"`
+	Amount            Amount    `json:"amount"`
+	Reference         string    `json:"reference,omitempty"`
+	OccurredAt        time.Time `json:"occurred_at"`
+}
+
+func (e Transferred) Type() string        { return TypeTransferred }
+func (e Transferred) AggregateID() string { return e.FromAccountNumber }
+
+type HoldPlaced struct {
+	AccountNumber string    `json:"account_number"`
+	HoldID        uint      `json:"hold_id"`
+	Amount        Amount    `json:"amount"`
+	Reference     string    `json:"reference,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e HoldPlaced) Type() string        { return TypeHoldPlaced }
+func (e HoldPlaced) AggregateID() string { return e.AccountNumber }
+
+type HoldCaptured struct {
+	AccountNumber string    `json:"account_number"`
+	HoldID        uint      `json:"hold_id"`
+	TransactionID uint      `json:"transaction_id"`
+	Amount        Amount    `json:"amount"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e HoldCaptured) Type() string        { return TypeHoldCaptured }
+func (e HoldCaptured) AggregateID() string { return e.AccountNumber }
+
+// HoldReleased is emitted when a hold is released explicitly or expires;
+// Status tells which.
+type HoldReleased struct {
+	AccountNumber string    `json:"account_number"`
+	HoldID        uint      `json:"hold_id"`
+	Amount        Amount    `json:"amount"`
+	Status        string    `json:"status"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e HoldReleased) Type() string        { return TypeHoldReleased }
+func (e HoldReleased) AggregateID() string { return e.AccountNumber }
+
+// TransactionReversed is emitted for every account a reversal touches.
+type TransactionReversed struct {
+	AccountNumber         string    `json:"account_number"`
+	TransactionID         uint      `json:"transaction_id"`
+	ReversalTransactionID uint      `json:"reversal_transaction_id"`
+	Amount                Amount    `json:"amount"`
+	ReasonCode            string    `json:"reason_code"`
+	Actor                 string    `json:"actor"`
+	OccurredAt            time.Time `json:"occurred_at"`
+}
+
+func (e TransactionReversed) Type() string        { return TypeReversed }
+func (e TransactionReversed) AggregateID() string { return e.AccountNumber }
+
+// InterestPosted is emitted when a month's interest is paid into an account.
+// Amount is the gross interest; Tax is the part of it withheld.
+type InterestPosted struct {
+	AccountNumber string    `json:"account_number"`
+	TransactionID uint      `json:"transaction_id"`
+	Period        string    `json:"period"`
+	Amount        Amount    `json:"amount"`
+	Tax           Amount    `json:"tax"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e InterestPosted) Type() string        { return TypeInterestPosted }
+func (e InterestPosted) AggregateID() string { return e.AccountNumber }
+
+// Overdrawn warns that a debit took the balance of an account below zero.
+// It is emitted each time the balance crosses zero, not for further debits
+// while it stays negative.
+type Overdrawn struct {
+	AccountNumber  string    `json:"account_number"`
+	TransactionID  uint      `json:"transaction_id"`
+	Balance        Amount    `json:"balance"`
+	OverdraftLimit Amount    `json:"overdraft_limit"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+func (e Overdrawn) Type() string        { return TypeOverdrawn }
+func (e Overdrawn) AggregateID() string { return e.AccountNumber }
+
+// OverdraftSet records a change to the arranged overdraft of an account.
+type OverdraftSet struct {
+	AccountNumber string    `json:"account_number"`
+	Limit         Amount    `json:"limit"`
+	RateBP        int64     `json:"rate_bp"`
+	Actor         string    `json:"actor"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e OverdraftSet) Type() string        { return TypeOverdraftSet }
+func (e OverdraftSet) AggregateID() string { return e.AccountNumber }
+
+type OverdraftInterestCharged struct {
+	AccountNumber string    `json:"account_number"`
+	TransactionID uint      `json:"transaction_id"`
+	Period        string    `json:"period"`
+	Amount        Amount    `json:"amount"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e OverdraftInterestCharged) Type() string        { return TypeOverdraftCharged }
+func (e OverdraftInterestCharged) AggregateID() string { return e.AccountNumber }
+
+// FeeCharged is emitted for every fee debited from an account. Operation fees
+// point at the transaction that incurred them through
+// TriggerTransactionID; monthly fees carry the Period instead.
+type FeeCharged struct {
+	AccountNumber        string    `json:"account_number"`
+	TransactionID        uint      `json:"transaction_id"`
+	TriggerTransactionID uint      `json:"trigger_transaction_id,omitempty"`
+	Rule                 string    `json:"rule"`
+	Period               string    `json:"period,omitempty"`
+	Amount               Amount    `json:"amount"`
+	OccurredAt           time.Time `json:"occurred_at"`
+}
+
+func (e FeeCharged) Type() string        { return TypeFeeCharged }
+func (e FeeCharged) AggregateID() string { return e.AccountNumber }
+
+// WithdrawalLimitSet records a new withdrawal limit policy or override. An
+// account limit carries the AccountNumber; a customer limit has none and
+// belongs to the customer.
+type WithdrawalLimitSet struct {
+	LimitID       uint       `json:"limit_id"`
+	AccountNumber string     `json:"account_number,omitempty"`
+	CustomerID    uint       `json:"customer_id"`
+	Currency      string     `json:"currency"`
+	MaxSingle     int64      `json:"max_single"`
+	MaxDaily      int64      `json:"max_daily"`
+	MaxMonthly    int64      `json:"max_monthly"`
+	MaxPerHour    int64      `json:"max_per_hour"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Actor         string     `json:"actor"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+}
+
+func (e WithdrawalLimitSet) Type() string { return TypeLimitSet }
+func (e WithdrawalLimitSet) AggregateID() string {
+	if e.AccountNumber != "" {
+		return e.AccountNumber
+	}
+	return fmt.Sprintf("customer:%d", e.CustomerID)
+}
+
+type StandingOrderCreated struct {
+	AccountNumber   string    `json:"account_number"`
+	StandingOrderID uint      `json:"standing_order_id"`
+	ToAccountNumber string    `json:"to_account_number"`
+	Amount          Amount    `json:"amount"`
+	Frequency       string    `json:"frequency"`
+	FirstDueDate    string    `json:"first_due_date"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+func (e StandingOrderCreated) Type() string        { return TypeStandingCreated }
+func (e StandingOrderCreated) AggregateID() string { return e.AccountNumber }
+
+type StandingOrderCancelled struct {
+	AccountNumber   string    `json:"account_number"`
+	StandingOrderID uint      `json:"standing_order_id"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+func (e StandingOrderCancelled) Type() string        { return TypeStandingCanceled }
+func (e StandingOrderCancelled) AggregateID() string { return e.AccountNumber }
+
+// StandingOrderFailed is emitted when an occurrence of a standing order is
+// given up, after its last retry or on a failure that retrying cannot fix.
+// Successful executions publish Transferred.
+type StandingOrderFailed struct {
+	AccountNumber   string    `json:"account_number"`
+	StandingOrderID uint      `json:"standing_order_id"`
+	DueDate         string    `json:"due_date"`
+	Attempts        int       `json:"attempts"`
+	Reason          string    `json:"reason"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+func (e StandingOrderFailed) Type() string        { return TypeStandingFailed }
+func (e StandingOrderFailed) AggregateID() string { return e.AccountNumber }
+
+// PayoutBatchCompleted is emitted when every line of a payout batch was
+// executed. Each line that succeeded publishes Transferred or Withdrawn.
+type PayoutBatchCompleted struct {
+	AccountNumber string    `json:"account_number"`
+	BatchID       uint      `json:"batch_id"`
+	Succeeded     int       `json:"succeeded"`
+	Failed        int       `json:"failed"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e PayoutBatchCompleted) Type() string        { return TypePayoutCompleted }
+func (e PayoutBatchCompleted) AggregateID() string { return e.AccountNumber }
+
+// StatusChanged is emitted for every account status change, including the
+// ones the dormancy job makes.
+type StatusChanged struct {
+	AccountNumber string    `json:"account_number"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Reason        string    `json:"reason"`
+	Actor         string    `json:"actor"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e StatusChanged) Type() string        { return TypeStatusChanged }
+func (e StatusChanged) AggregateID() string { return e.AccountNumber }
+
+// ChainAnchored publishes the daily anchor of the transaction hash chains so
+// that it can be kept outside of this service's database.
+type ChainAnchored struct {
+	Day          string    `json:"day"`
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash"`
+	Accounts     int       `json:"accounts"`
+	Transactions int64     `json:"transactions"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+func (e ChainAnchored) Type() string        { return TypeChainAnchored }
+func (e ChainAnchored) AggregateID() string { return "ledger" }
+
+// NewOutboxMessage serializes an event for the outbox table.
+func NewOutboxMessage(event Event) (*entity.OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.OutboxMessage{
+		EventType:   event.Type(),
+		AggregateID: event.AggregateID(),
+		Payload:     string(payload),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is an event as handed to a publisher. ID is the outbox ID; it is
+// stable across redeliveries so that consumers can discard duplicates.
+type Message struct {
+	ID          uint
+	Type        string
+	AggregateID string
+	Payload     []byte
+	OccurredAt  time.Time
+}
+
+// Publisher delivers messages to a message broker. Publish must return only
+// once the broker has accepted the message; an error means it may be sent
+// again later.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+	Close() error
+}
+
+// MemoryPublisher keeps published messages in memory. It is meant for tests
+// and for running the service without a broker.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, message Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the messages published so far.
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
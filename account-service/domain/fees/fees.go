@@ -0,0 +1,225 @@
+// Package fees holds the fee rules of the bank and works out the fees an
+// operation or a month of account keeping costs.
+package fees
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// Events a rule can charge for.
+const (
+	EventWithdraw = "withdraw"
+	EventDeposit  = "deposit"
+	EventTransfer = "transfer"
+	EventMonthly  = "monthly"
+)
+
+// Config is the list of fee rules. Every rule that matches an event charges
+// its own fee.
+//
+//	{
+//	  "rules": [
+//	    {"name": "withdrawal", "event": "withdraw", "products": ["checking"], "currency": "USD",
+//	     "flat": 50, "percent_bp": 100, "min": 100, "max": 1000, "waive_min_balance": 500000},
+//	    {"name": "maintenance", "event": "monthly", "products": ["checking"], "currency": "USD",
+//	     "flat": 500, "waive_min_balance": 150000}
+//	  ]
+//	}
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule charges Flat plus PercentBP basis points of the amount of the
+// operation, bounded by Min and, when it is set, Max. All amounts are minor
+// units of Currency. The fee is waived when the balance is at least
+// WaiveMinBalance, if that is set: the balance before the operation, or at
+// the end of the month for monthly fees.
+type Rule struct {
+	Name            string   `json:"name"`
+	Event           string   `json:"event"`
+	Products        []string `json:"products"`
+	Currency        string   `json:"currency"`
+	Flat            int64    `json:"flat"`
+	PercentBP       int64    `json:"percent_bp"`
+	Min             int64    `json:"min"`
+	Max             int64    `json:"max"`
+	WaiveMinBalance *int64   `json:"waive_min_balance"`
+}
+
+// Fee is a fee charged by a rule.
+type Fee struct {
+	Rule   string
+	Event  string
+	Amount int64
+}
+
+var ErrInvalidConfig = errors.New("invalid fee configuration")
+
+// Load reads and validates a configuration file.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads and validates a configuration.
+func Parse(r io.Reader) (*Config, error) {
+	var config Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks every rule.
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for i, rule := range c.Rules {
+		invalid := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: rule %d (%q): %s", ErrInvalidConfig, i+1, rule.Name, fmt.Sprintf(format, args...))
+		}
+		if rule.Name == "" {
+			return invalid("name is required")
+		}
+		if names[rule.Name] {
+			return invalid("name is used twice")
+		}
+		names[rule.Name] = true
+
+		switch rule.Event {
+		case EventWithdraw, EventDeposit, EventTransfer:
+		case EventMonthly:
+			if rule.PercentBP != 0 {
+				return invalid("monthly fees cannot be a percentage")
+			}
+		default:
+			return invalid("unknown event %q", rule.Event)
+		}
+		if len(rule.Products) == 0 {
+			return invalid("products are required")
+		}
+		for _, product := range rule.Products {
+			if !entity.ValidProductType(product) {
+				return invalid("unknown product %q", product)
+			}
+		}
+		if _, err := entity.CurrencyExponent(rule.Currency); err != nil {
+			return invalid("unknown currency %q", rule.Currency)
+		}
+		if rule.Flat < 0 || rule.Min < 0 || rule.Max < 0 {
+			return invalid("amounts must not be negative")
+		}
+		if rule.PercentBP < 0 || rule.PercentBP > 10000 {
+			return invalid("percent_bp must be between 0 and 10000")
+		}
+		if rule.Flat == 0 && rule.PercentBP == 0 && rule.Min == 0 {
+			return invalid("the rule charges nothing")
+		}
+		if rule.Max > 0 && rule.Max < rule.Min {
+			return invalid("max is below min")
+		}
+	}
+	return nil
+}
+
+// Fees returns the fees the rules charge for an event on an account of the
+// given product and currency. amount is the amount of the operation and
+// balance the ledger balance the waivers are checked against.
+func (c *Config) Fees(event, productType, currency string, amount, balance int64) []Fee {
+	if c == nil {
+		return nil
+	}
+	var fees []Fee
+	for _, rule := range c.Rules {
+		if rule.Event != event || rule.Currency != currency || !contains(rule.Products, productType) {
+			continue
+		}
+		if rule.WaiveMinBalance != nil && balance >= *rule.WaiveMinBalance {
+			continue
+		}
+		if fee := rule.Fee(amount); fee > 0 {
+			fees = append(fees, Fee{Rule: rule.Name, Event: event, Amount: fee})
+		}
+	}
+	return fees
+}
+
+// MonthlyProductTypes lists the product types some monthly rule applies to.
+func (c *Config) MonthlyProductTypes() []string {
+	if c == nil {
+		return nil
+	}
+	var types []string
+	for _, rule := range c.Rules {
+		if rule.Event != EventMonthly {
+			continue
+		}
+		for _, product := range rule.Products {
+			if !contains(types, product) {
+				types = append(types, product)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// MonthlyRules names the monthly rules that apply to accounts of a product
+// and currency, whether or not they would be waived.
+func (c *Config) MonthlyRules(productType, currency string) []string {
+	if c == nil {
+		return nil
+	}
+	var names []string
+	for _, rule := range c.Rules {
+		if rule.Event == EventMonthly && rule.Currency == currency && contains(rule.Products, productType) {
+			names = append(names, rule.Name)
+		}
+	}
+	return names
+}
+
+// Fee works out the fee on an amount, rounding the percentage half up.
+func (r *Rule) Fee(amount int64) int64 {
+	fee := r.Flat + (amount*r.PercentBP+5000)/10000
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee
+}
+
+// Total sums fees.
+func Total(fees []Fee) int64 {
+	var total int64
+	for _, fee := range fees {
+		total += fee.Amount
+	}
+	return total
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
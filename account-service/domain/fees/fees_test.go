@@ -0,0 +1,73 @@
+package fees
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const config = `{
+  "rules": [
+    {"name": "atm", "event": "withdraw", "products": ["checking"], "currency": "USD",
+     "flat": 50, "percent_bp": 100, "min": 100, "max": 1000, "waive_min_balance": 500000},
+    {"name": "wire", "event": "transfer", "products": ["checking", "savings"], "currency": "USD", "flat": 250},
+    {"name": "maintenance", "event": "monthly", "products": ["checking"], "currency": "USD", "flat": 500}
+  ]
+}`
+
+func TestFees(t *testing.T) {
+	c, err := Parse(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		event, product  string
+		amount, balance int64
+		want            int64
+	}{
+		{EventWithdraw, "checking", 1000, 0, 100},      // 50 + 10 is raised to the minimum
+		{EventWithdraw, "checking", 20050, 0, 251},     // 50 + 200.5 rounded half up
+		{EventWithdraw, "checking", 1000000, 0, 1000},  // capped at the maximum
+		{EventWithdraw, "checking", 1000, 500000, 0},   // waived
+		{EventWithdraw, "savings", 1000, 0, 0},         // no rule for the product
+		{EventDeposit, "checking", 1000, 0, 0},         // no rule for the event
+		{EventTransfer, "savings", 1000, 1000000, 250}, // flat, never waived
+	} {
+		if got := Total(c.Fees(tc.event, tc.product, "USD", tc.amount, tc.balance)); got != tc.want {
+			t.Errorf("Fees(%s, %s, %d, %d) = %d, want %d", tc.event, tc.product, tc.amount, tc.balance, got, tc.want)
+		}
+	}
+	if got := c.Fees(EventWithdraw, "checking", "EUR", 1000, 0); len(got) != 0 {
+		t.Errorf("Expected no fees in another currency, got %v", got)
+	}
+	if got := c.MonthlyProductTypes(); len(got) != 1 || got[0] != "checking" {
+		t.Errorf("Expected monthly fees on checking accounts, got %v", got)
+	}
+
+	var none *Config
+	if got := none.Fees(EventWithdraw, "checking", "USD", 1000, 0); len(got) != 0 {
+		t.Errorf("Expected no fees without a configuration, got %v", got)
+	}
+}
+
+func TestParseRejectsInvalidConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`{"rules": [{"event": "withdraw", "products": ["checking"], "currency": "USD", "flat": 1}]}`,
+		`{"rules": [{"name": "a", "event": "atm", "products": ["checking"], "currency": "USD", "flat": 1}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["gold"], "currency": "USD", "flat": 1}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "XXX", "flat": 1}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD", "flat": -1}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD", "percent_bp": 10001}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD"}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD", "min": 10, "max": 5}]}`,
+		`{"rules": [{"name": "a", "event": "monthly", "products": ["checking"], "currency": "USD", "percent_bp": 10}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD", "flat": 1},
+		           {"name": "a", "event": "deposit", "products": ["checking"], "currency": "USD", "flat": 1}]}`,
+		`{"rules": [{"name": "a", "event": "withdraw", "products": ["checking"], "currency": "USD", "fee": 1}]}`,
+	} {
+		if _, err := Parse(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Expected %s to be rejected, got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package interest holds the interest rate schedules of the account products
+// and the arithmetic of daily accrual.
+package interest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+// MicrosPerUnit is the precision of accrued interest: amounts are accrued in
+// millionths of a minor unit and only rounded when they are posted.
+const MicrosPerUnit = 1_000_000
+
+// DaysPerYear is the day count basis: actual days over a 365 day year.
+const DaysPerYear = 365
+
+// Config is the interest configuration of the bank, keyed by product type.
+// Products without an entry earn no interest.
+//
+//	{
+//	  "products": {
+//	    "savings": {
+//	      "withholding_tax_bp": 2500,
+//	      "schedules": [
+//	        {"effective_from": "2026-01-01", "tiers": [{"from_balance": 0, "rate_bp": 100}, {"from_balance": 1000000, "rate_bp": 200}]}
+//	      ]
+//	    }
+//	  }
+//	}
+type Config struct {
+	Products map[string]*Product `json:"products"`
+}
+
+// Product holds the rate schedules of one product type, and the share of the
+// interest withheld as tax, in basis points.
+type Product struct {
+	WithholdingTaxBP int64      `json:"withholding_tax_bp"`
+	Schedules        []Schedule `json:"schedules"`
+}
+
+// Schedule is a set of rates that applies from EffectiveFrom, a date, until
+// the next schedule takes over.
+type Schedule struct {
+	EffectiveFrom string `json:"effective_from"`
+	Tiers         []Tier `json:"tiers"`
+
+	from time.Time
+}
+
+// Tier is a balance band. The part of a balance from FromBalance up to the
+// next tier's FromBalance, in minor units, earns RateBP basis points a year.
+type Tier struct {
+	FromBalance int64 `json:"from_balance"`
+	RateBP      int64 `json:"rate_bp"`
+}
+
+var ErrInvalidConfig = errors.New("invalid interest configuration")
+
+// Load reads and validates a configuration file.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads and validates a configuration.
+func Parse(r io.Reader) (*Config, error) {
+	var config Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the configuration and prepares it for use. Schedules are
+// sorted by effective date and their tiers by balance.
+func (c *Config) Validate() error {
+	invalid := func(product, format string, args ...interface{}) error {
+		return fmt.Errorf("%w: product %q: %s", ErrInvalidConfig, product, fmt.Sprintf(format, args...))
+	}
+	for name, product := range c.Products {
+		if product == nil {
+			return invalid(name, "no settings")
+		}
+		if product.WithholdingTaxBP < 0 || product.WithholdingTaxBP > 10000 {
+			return invalid(name, "withholding_tax_bp must be between 0 and 10000")
+		}
+		if len(product.Schedules) == 0 {
+			return invalid(name, "no schedules")
+		}
+		seen := make(map[string]bool)
+		for i := range product.Schedules {
+			schedule := &product.Schedules[i]
+			from, err := time.Parse(time.DateOnly, schedule.EffectiveFrom)
+			if err != nil {
+				return invalid(name, "effective_from %q is not a YYYY-MM-DD date", schedule.EffectiveFrom)
+			}
+			if seen[schedule.EffectiveFrom] {
+				return invalid(name, "two schedules are effective from %s", schedule.EffectiveFrom)
+			}
+			seen[schedule.EffectiveFrom] = true
+			schedule.from = from
+
+			if len(schedule.Tiers) == 0 {
+				return invalid(name, "schedule from %s has no tiers", schedule.EffectiveFrom)
+			}
+			sort.Slice(schedule.Tiers, func(a, b int) bool { return schedule.Tiers[a].FromBalance < schedule.Tiers[b].FromBalance })
+			if schedule.Tiers[0].FromBalance != 0 {
+				return invalid(name, "schedule from %s must have a tier from balance 0", schedule.EffectiveFrom)
+			}
+			for j, tier := range schedule.Tiers {
+				if tier.RateBP < 0 {
+					return invalid(name, "schedule from %s has a negative rate", schedule.EffectiveFrom)
+				}
+				if j > 0 && tier.FromBalance == schedule.Tiers[j-1].FromBalance {
+					return invalid(name, "schedule from %s has two tiers from balance %d", schedule.EffectiveFrom, tier.FromBalance)
+				}
+			}
+		}
+		sort.Slice(product.Schedules, func(a, b int) bool { return product.Schedules[a].from.Before(product.Schedules[b].from) })
+	}
+	return nil
+}
+
+// Product returns the settings of a product type, or nil when it earns no
+// interest.
+func (c *Config) Product(productType string) *Product {
+	if c == nil {
+		return nil
+	}
+	return c.Products[productType]
+}
+
+// ProductTypes lists the product types that earn interest.
+func (c *Config) ProductTypes() []string {
+	if c == nil {
+		return nil
+	}
+	types := make([]string, 0, len(c.Products))
+	for name := range c.Products {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Start is the first day any schedule of the product applies.
+func (p *Product) Start() time.Time {
+	return p.Schedules[0].from
+}
+
+// ScheduleOn returns the schedule in effect on day, or nil before the first
+// one.
+func (p *Product) ScheduleOn(day time.Time) *Schedule {
+	var current *Schedule
+	for i := range p.Schedules {
+		if p.Schedules[i].from.After(day) {
+			break
+		}
+		current = &p.Schedules[i]
+	}
+	return current
+}
+
+// DailyAccrual returns the interest, in millionths of a minor unit, that a
+// balance held at the end of day earns for that day. Every tier's band of the
+// balance earns the tier's rate. Balances of zero or less earn nothing.
+func (p *Product) DailyAccrual(balance int64, day time.Time) int64 {
+	if p == nil {
+		return 0
+	}
+	schedule := p.ScheduleOn(day)
+	if schedule == nil || balance <= 0 {
+		return 0
+	}
+
+	// Sum of band * rate in basis points; big.Int keeps large balances exact.
+	total := new(big.Int)
+	for i, tier := range schedule.Tiers {
+		if balance <= tier.FromBalance {
+			break
+		}
+		upper := balance
+		if i+1 < len(schedule.Tiers) && schedule.Tiers[i+1].FromBalance < balance {
+			upper = schedule.Tiers[i+1].FromBalance
+		}
+		band := new(big.Int).Mul(big.NewInt(upper-tier.FromBalance), big.NewInt(tier.RateBP))
+		total.Add(total, band)
+	}
+	return perDay(total)
+}
+
+// DailyOverdraftCharge returns the interest, in millionths of a minor unit,
+// that a negative end of day balance is charged at an annual rate in basis
+// points. Balances of zero or more are charged nothing.
+func DailyOverdraftCharge(balance, rateBP int64) int64 {
+	if balance >= 0 || rateBP <= 0 {
+		return 0
+	}
+	return perDay(new(big.Int).Mul(big.NewInt(-balance), big.NewInt(rateBP)))
+}
+
+// perDay turns an amount times an annual rate in basis points into a day's
+// interest in micros: amount * rate / 10000 / 365 * 1e6.
+func perDay(amountTimesRate *big.Int) int64 {
+	amountTimesRate.Mul(amountTimesRate, big.NewInt(MicrosPerUnit))
+	amountTimesRate.Quo(amountTimesRate, big.NewInt(10000*DaysPerYear))
+	return amountTimesRate.Int64()
+}
+
+// Tax returns the part of posted interest that is withheld, rounded half up.
+func (p *Product) Tax(interest int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return (interest*p.WithholdingTaxBP + 5000) / 10000
+}
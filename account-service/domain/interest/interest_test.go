@@ -0,0 +1,72 @@
+package interest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const config = `{
+  "products": {
+    "savings": {
+      "withholding_tax_bp": 2500,
+      "schedules": [
+        {"effective_from": "2026-09-01", "tiers": [{"from_balance": 0, "rate_bp": 365}]},
+        {"effective_from": "2026-08-01", "tiers": [{"from_balance": 500000, "rate_bp": 730}, {"from_balance": 0, "rate_bp": 365}]}
+      ]
+    }
+  }
+}`
+
+func day(value string) time.Time {
+	t, _ := time.Parse(time.DateOnly, value)
+	return t
+}
+
+func TestDailyAccrual(t *testing.T) {
+	c, err := Parse(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savings := c.Product("savings")
+
+	for _, tc := range []struct {
+		balance int64
+		day     string
+		want    int64
+	}{
+		{1000000, "2026-07-31", 0},                   // before the first schedule
+		{1000000, "2026-08-15", 150 * MicrosPerUnit}, // 500000 at 3.65% and 500000 at 7.3%
+		{400000, "2026-08-15", 40 * MicrosPerUnit},   // first tier only
+		{1000000, "2026-09-01", 100 * MicrosPerUnit}, // the rate change applies from its day
+		{1, "2026-09-01", MicrosPerUnit / 10000},     // fractions of a minor unit are kept
+		{-1000000, "2026-09-01", 0},                  // negative balances earn nothing
+	} {
+		if got := savings.DailyAccrual(tc.balance, day(tc.day)); got != tc.want {
+			t.Errorf("DailyAccrual(%d, %s) = %d, want %d", tc.balance, tc.day, got, tc.want)
+		}
+	}
+
+	if got := savings.Tax(1801); got != 450 {
+		t.Errorf("Expected 25%% of 1801 to be withheld as 450, got %d", got)
+	}
+	if c.Product("checking") != nil {
+		t.Errorf("Expected checking accounts to earn no interest")
+	}
+}
+
+func TestParseRejectsInvalidConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`{"products": {"savings": {"schedules": []}}}`,
+		`{"products": {"savings": {"schedules": [{"effective_from": "2026-13-01", "tiers": [{"from_balance": 0, "rate_bp": 1}]}]}}}`,
+		`{"products": {"savings": {"schedules": [{"effective_from": "2026-01-01", "tiers": [{"from_balance": 100, "rate_bp": 1}]}]}}}`,
+		`{"products": {"savings": {"schedules": [{"effective_from": "2026-01-01", "tiers": [{"from_balance": 0, "rate_bp": -1}]}]}}}`,
+		`{"products": {"savings": {"withholding_tax_bp": 10001, "schedules": [{"effective_from": "2026-01-01", "tiers": [{"from_balance": 0, "rate_bp": 1}]}]}}}`,
+		`{"products": {"savings": {"rate": 1}}}`,
+	} {
+		if _, err := Parse(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Expected %s to be rejected, got %v", invalid, err)
+		}
+	}
+}
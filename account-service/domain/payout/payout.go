@@ -0,0 +1,79 @@
+// Package payout reads the payout files customers upload to pay many
+// recipients at once, such as a payroll run.
+package payout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Payout file formats.
+const (
+	FormatCSV     = "csv"
+	FormatPain001 = "pain001"
+)
+
+// Kinds of payout line. A transfer credits an account at this bank; a
+// withdrawal pays the amount out of the bank.
+const (
+	TypeTransfer   = "transfer"
+	TypeWithdrawal = "withdrawal"
+)
+
+// MaxLines bounds the lines of a payout file.
+const MaxLines = 10000
+
+var (
+	ErrUnknownFormat = errors.New("payout file format must be csv or pain001")
+	ErrInvalidFile   = errors.New("invalid payout file")
+	ErrEmptyFile     = errors.New("payout file has no lines")
+	ErrTooManyLines  = fmt.Errorf("payout file has more than %d lines", MaxLines)
+)
+
+// Line is one payment of a payout file. Amount is positive, in minor units
+// of Currency. FromAccountNumber is the account the file says to debit, if
+// it names one. EndToEndID is the payer's own reference of the payment.
+type Line struct {
+	Number            int
+	Type              string
+	FromAccountNumber string
+	ToAccountNumber   string
+	Name              string
+	Amount            int64
+	Currency          string
+	Reference         string
+	EndToEndID        string
+}
+
+// LineError is a line of a payout file that cannot be paid as it stands.
+type LineError struct {
+	Line    int
+	Message string
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Read reads the lines of a payout file in the given format. Lines that
+// cannot be read are left out and reported as line errors, so that every
+// problem of a file is found at once; the error is for files that cannot be
+// read at all.
+func Read(r io.Reader, format string) ([]Line, []LineError, error) {
+	var lines []Line
+	var problems []LineError
+	var err error
+	switch format {
+	case FormatCSV:
+		lines, problems, err = ReadCSV(r)
+	case FormatPain001:
+		lines, problems, err = ReadPain001(r)
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+	if err == nil && len(lines)+len(problems) == 0 {
+		err = ErrEmptyFile
+	}
+	return lines, problems, err
+}
@@ -0,0 +1,81 @@
+package payout
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	lines, problems, err := Read(strings.NewReader("\ufeffName;To_Account_Number;Amount;Currency;Reference;Type\n"+
+		"Jane Roe;000000000294;2000,00;usd;Salary September;\n"+
+		"John Doe;;150,5;USD;;withdrawal\n"+
+		"Nobody;000000000391;-1;USD;;\n"+
+		"Nobody;;1;USD;;transfer\n"+
+		"Nobody;000000000391;1;USD;;cheque\n"+
+		"Nobody;000000000391;1.001;USD;;\n"), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Number: 2, Type: TypeTransfer, ToAccountNumber: "000000000294", Name: "Jane Roe", Amount: 200000, Currency: "USD", Reference: "Salary September"},
+		{Number: 3, Type: TypeWithdrawal, Name: "John Doe", Amount: 15050, Currency: "USD"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Unexpected lines %+v", lines)
+	}
+	var numbers []int
+	for _, problem := range problems {
+		numbers = append(numbers, problem.Line)
+	}
+	if !reflect.DeepEqual(numbers, []int{4, 5, 6, 7}) {
+		t.Errorf("Expected lines 4 to 7 to be reported, got %v", problems)
+	}
+
+	for _, file := range []string{
+		"to_account_number,amount\n000000000294,1.00\n", // no currency column
+		"amount,currency\n\"1.00,USD\n",                 // unterminated quote
+	} {
+		if _, _, err := Read(strings.NewReader(file), FormatCSV); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Expected %q to be rejected, got %v", file, err)
+		}
+	}
+	if _, _, err := Read(strings.NewReader("amount,currency\n"), FormatCSV); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("Expected an empty file to be rejected, got %v", err)
+	}
+	if _, _, err := Read(strings.NewReader(""), "xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected an unknown format to be rejected, got %v", err)
+	}
+}
+
+func TestReadPain001(t *testing.T) {
+	content, err := os.ReadFile("testdata/payroll.pain001.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, problems, err := Read(strings.NewReader(string(content)), FormatPain001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Number: 1, Type: TypeTransfer, FromAccountNumber: "000000000197", ToAccountNumber: "000000000294", Name: "Jane Roe", Amount: 200000, Currency: "USD", Reference: "Salary September", EndToEndID: "SAL-0001"},
+		{Number: 2, Type: TypeTransfer, FromAccountNumber: "000000000197", ToAccountNumber: "DE89370400440532013000", Name: "John Doe", Amount: 225050, Currency: "USD", Reference: "SAL-0002", EndToEndID: "SAL-0002"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Unexpected lines %+v", lines)
+	}
+	if len(problems) != 1 || problems[0].Line != 3 {
+		t.Errorf("Expected the zero amount on line 3 to be reported, got %v", problems)
+	}
+
+	for _, tampered := range []string{
+		strings.Replace(string(content), "<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>4</NbOfTxs>", 1),
+		strings.Replace(string(content), "<CtrlSum>4250.50</CtrlSum>", "<CtrlSum>4250.05</CtrlSum>", 1),
+	} {
+		if _, _, err := Read(strings.NewReader(tampered), FormatPain001); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Expected a group header that disagrees with the transfers to be rejected, got %v", err)
+		}
+	}
+}
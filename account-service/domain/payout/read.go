@@ -0,0 +1,251 @@
+package payout
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// ReadCSV reads a payout file with a header row. The columns are found by
+// name, in any order:
+//
+//	amount             decimal in major units; required
+//	currency           ISO 4217 code; required
+//	type               transfer (the default) or withdrawal
+//	to_account_number  the account a transfer credits
+//	name               the recipient
+//	reference          the payment reference
+//	end_to_end_id      the payer's reference of the payment
+//
+// Fields are separated by commas, or by semicolons when the header is; with
+// semicolons, amounts may use a decimal comma. Line numbers count the
+// header as line 1.
+func ReadCSV(r io.Reader) ([]Line, []LineError, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	header = strings.TrimPrefix(header, "\ufeff")
+	comma := ','
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		comma = ';'
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), buffered))
+	reader.Comma = comma
+	reader.TrimLeadingSpace = true
+	names, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	columns := make(map[string]int)
+	for i, name := range names {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"amount", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: the header has no %s column", ErrInvalidFile, required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var lines []Line
+	var problems []LineError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, problems, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(lines)+len(problems) == MaxLines {
+			return nil, nil, ErrTooManyLines
+		}
+		number, _ := reader.FieldPos(0)
+		line := Line{
+			Number:          number,
+			Type:            strings.ToLower(field(record, "type")),
+			ToAccountNumber: field(record, "to_account_number"),
+			Name:            field(record, "name"),
+			Currency:        strings.ToUpper(field(record, "currency")),
+			Reference:       field(record, "reference"),
+			EndToEndID:      field(record, "end_to_end_id"),
+		}
+		if line.Type == "" {
+			line.Type = TypeTransfer
+		}
+		amount := field(record, "amount")
+		if comma == ';' && !strings.Contains(amount, ".") {
+			amount = strings.Replace(amount, ",", ".", 1)
+		}
+		if line.Amount, err = positiveAmount(amount, line.Currency); err != nil {
+			problems = append(problems, LineError{Line: number, Message: err.Error()})
+			continue
+		}
+		if err := line.check(); err != nil {
+			problems = append(problems, LineError{Line: number, Message: err.Error()})
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+// check validates the kind of a line against the account it names.
+func (l *Line) check() error {
+	switch l.Type {
+	case TypeTransfer:
+		if l.ToAccountNumber == "" {
+			return errors.New("a transfer needs a to_account_number")
+		}
+	case TypeWithdrawal:
+		if l.ToAccountNumber != "" {
+			return errors.New("a withdrawal takes no to_account_number")
+		}
+	default:
+		return fmt.Errorf("type must be %s or %s", TypeTransfer, TypeWithdrawal)
+	}
+	return nil
+}
+
+// positiveAmount reads a decimal amount in major units into minor units and
+// makes sure it is positive.
+func positiveAmount(value, currency string) (int64, error) {
+	units, err := entity.ParseDecimal(value, currency)
+	if err != nil {
+		return 0, err
+	}
+	if units <= 0 {
+		return 0, entity.ErrNonPositive
+	}
+	return units, nil
+}
+
+// The types below cover the part of the pain.001 schema payouts need.
+// Element names are matched without their namespace, so that every version
+// of the schema reads the same.
+type painDocument struct {
+	Header   painGroupHeader   `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Payments []painPaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type painGroupHeader struct {
+	NumberOfTxs string `xml:"NbOfTxs"`
+	ControlSum  string `xml:"CtrlSum"`
+}
+
+type painPaymentInfo struct {
+	DebtorAccount painAccount       `xml:"DbtrAcct"`
+	Transactions  []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+type painAccount struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+func (a painAccount) number() string {
+	if a.Other != "" {
+		return strings.TrimSpace(a.Other)
+	}
+	return strings.TrimSpace(a.IBAN)
+}
+
+type painTransaction struct {
+	EndToEndID      string      `xml:"PmtId>EndToEndId"`
+	Amount          painAmount  `xml:"Amt>InstdAmt"`
+	CreditorName    string      `xml:"Cdtr>Nm"`
+	CreditorAccount painAccount `xml:"CdtrAcct"`
+	Remittance      []string    `xml:"RmtInf>Ustrd"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// ReadPain001 reads an ISO 20022 pain.001 customer credit transfer
+// initiation. Every credit transfer is a transfer line to the creditor
+// account; the debtor account of its payment information is the line's
+// FromAccountNumber. The reference is the unstructured remittance
+// information or, failing that, the end-to-end ID. The number of
+// transactions and the control sum of the group header, when given, must
+// agree with the transfers. Line numbers count the transfers in document
+// order.
+func ReadPain001(r io.Reader) ([]Line, []LineError, error) {
+	var doc painDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var lines []Line
+	var problems []LineError
+	sum := new(big.Rat)
+	number := 0
+	for _, payment := range doc.Payments {
+		for _, tx := range payment.Transactions {
+			number++
+			if number > MaxLines {
+				return nil, nil, ErrTooManyLines
+			}
+			value := strings.TrimSpace(tx.Amount.Value)
+			if amount, ok := new(big.Rat).SetString(value); ok {
+				sum.Add(sum, amount)
+			}
+			line := Line{
+				Number:            number,
+				Type:              TypeTransfer,
+				FromAccountNumber: payment.DebtorAccount.number(),
+				ToAccountNumber:   tx.CreditorAccount.number(),
+				Name:              strings.TrimSpace(tx.CreditorName),
+				Currency:          strings.ToUpper(strings.TrimSpace(tx.Amount.Currency)),
+				Reference:         strings.TrimSpace(strings.Join(tx.Remittance, " ")),
+			}
+			if id := strings.TrimSpace(tx.EndToEndID); id != "NOTPROVIDED" {
+				line.EndToEndID = id
+			}
+			if line.Reference == "" {
+				line.Reference = line.EndToEndID
+			}
+			var err error
+			if line.Amount, err = positiveAmount(value, line.Currency); err != nil {
+				problems = append(problems, LineError{Line: number, Message: err.Error()})
+				continue
+			}
+			if line.ToAccountNumber == "" {
+				problems = append(problems, LineError{Line: number, Message: "a transfer needs a creditor account"})
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if value := strings.TrimSpace(doc.Header.NumberOfTxs); value != "" {
+		if n, err := strconv.Atoi(value); err != nil || n != number {
+			return nil, nil, fmt.Errorf("%w: NbOfTxs is %s but the file has %d transfers", ErrInvalidFile, value, number)
+		}
+	}
+	if value := strings.TrimSpace(doc.Header.ControlSum); value != "" {
+		if control, ok := new(big.Rat).SetString(value); !ok || control.Cmp(sum) != 0 {
+			return nil, nil, fmt.Errorf("%w: CtrlSum is %s but the transfers add up to %s", ErrInvalidFile, value, sum.FloatString(2))
+		}
+	}
+	return lines, problems, nil
+}
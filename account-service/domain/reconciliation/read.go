@@ -0,0 +1,321 @@
+package reconciliation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// Settlement file formats.
+const (
+	FormatCSV     = "csv"
+	FormatCAMT054 = "camt054"
+)
+
+var (
+	ErrUnknownFormat = errors.New("settlement file format must be csv or camt054")
+	ErrInvalidFile   = errors.New("invalid settlement file")
+	ErrEmptyFile     = errors.New("settlement file has no lines")
+)
+
+// Read reads the lines of a settlement file in the given format.
+func Read(r io.Reader, format string) ([]Line, error) {
+	var lines []Line
+	var err error
+	switch format {
+	case FormatCSV:
+		lines, err = ReadCSV(r)
+	case FormatCAMT054:
+		lines, err = ReadCAMT054(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err == nil && len(lines) == 0 {
+		err = ErrEmptyFile
+	}
+	return lines, err
+}
+
+// ReadCSV reads a settlement file with a header row. The columns are found
+// by name, in any order:
+//
+//	date       YYYY-MM-DD or an RFC 3339 time; required
+//	amount     decimal in major units, negative for debits; required
+//	currency   ISO 4217 code; required
+//	direction  CRDT or DBIT (also C/D, CR/DR, credit/debit), when amounts
+//	           are unsigned
+//	reference  the payment reference
+//	id         the bank's reference of the line
+//
+// Fields are separated by commas, or by semicolons when the header is; with
+// semicolons, amounts may use a decimal comma. Line numbers count the
+// header as line 1.
+func ReadCSV(r io.Reader) ([]Line, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	header = strings.TrimPrefix(header, "\ufeff")
+	comma := ','
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		comma = ';'
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), buffered))
+	reader.Comma = comma
+	reader.TrimLeadingSpace = true
+	names, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	columns := make(map[string]int)
+	for i, name := range names {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"date", "amount", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: the header has no %s column", ErrInvalidFile, required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var lines []Line
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		number, _ := reader.FieldPos(0)
+		line := Line{
+			Number:     number,
+			ExternalID: field(record, "id"),
+			Currency:   strings.ToUpper(field(record, "currency")),
+			Reference:  field(record, "reference"),
+		}
+		if line.Date, err = parseDate(field(record, "date")); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, number, err)
+		}
+		amount := field(record, "amount")
+		if comma == ';' && !strings.Contains(amount, ".") {
+			amount = strings.Replace(amount, ",", ".", 1)
+		}
+		if line.Amount, err = entity.ParseDecimal(amount, line.Currency); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, number, err)
+		}
+		if direction := field(record, "direction"); direction != "" {
+			if line.Amount < 0 {
+				return nil, fmt.Errorf("%w: line %d: amount is signed and has a direction", ErrInvalidFile, number)
+			}
+			debit, err := parseDirection(direction)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, number, err)
+			}
+			if debit {
+				line.Amount = -line.Amount
+			}
+		}
+		lines = append(lines, line)
+	}
+}
+
+// The types below cover the part of the camt.054 schema matching needs.
+// Element names are matched without their namespace, so that every version
+// of the schema the bank may send reads the same.
+type camtNotificationDocument struct {
+	Notifications []camtNotification `xml:"BkToCstmrDbtCdtNtfctn>Ntfctn"`
+}
+
+type camtNotification struct {
+	Entries []camtNotificationEntry `xml:"Ntry"`
+}
+
+type camtNotificationEntry struct {
+	Reference   string          `xml:"NtryRef"`
+	Amount      camtAmount      `xml:"Amt"`
+	Indicator   string          `xml:"CdtDbtInd"`
+	Status      camtStatus      `xml:"Sts"`
+	BookingDate camtDate        `xml:"BookgDt"`
+	ServicerRef string          `xml:"AcctSvcrRef"`
+	Details     []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus is plain text before version 8 of the schema and a code
+// element after.
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTxDetails struct {
+	ServicerRef  string      `xml:"Refs>AcctSvcrRef"`
+	EndToEndID   string      `xml:"Refs>EndToEndId"`
+	Amount       *camtAmount `xml:"Amt"`
+	LegacyAmount *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Indicator    string      `xml:"CdtDbtInd"`
+	Remittance   []string    `xml:"RmtInf>Ustrd"`
+}
+
+// ReadCAMT054 reads an ISO 20022 camt.054 debit/credit notification. Every
+// booked entry is a line, except batch bookings whose transactions carry
+// their own amounts: each of those is a line. The reference is the
+// end-to-end ID or, failing that, the unstructured remittance information.
+// Line numbers count entries and their transactions in document order.
+func ReadCAMT054(r io.Reader) ([]Line, error) {
+	var doc camtNotificationDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var lines []Line
+	number := 0
+	for _, notification := range doc.Notifications {
+		for _, entry := range notification.Entries {
+			if status := strings.TrimSpace(entry.Status.Code + entry.Status.Text); status != "" && status != "BOOK" {
+				continue
+			}
+			dateValue := entry.BookingDate.Date
+			if dateValue == "" {
+				dateValue = entry.BookingDate.DateTime
+			}
+			date, err := parseDate(dateValue)
+			if err != nil {
+				return nil, fmt.Errorf("%w: entry %s: %v", ErrInvalidFile, entry.ServicerRef, err)
+			}
+			externalID := entry.ServicerRef
+			if externalID == "" {
+				externalID = entry.Reference
+			}
+
+			if batch(entry.Details) {
+				for i, details := range entry.Details {
+					number++
+					amount := details.Amount
+					if amount == nil {
+						amount = details.LegacyAmount
+					}
+					indicator := details.Indicator
+					if indicator == "" {
+						indicator = entry.Indicator
+					}
+					line, err := camtLine(number, *amount, indicator, date)
+					if err != nil {
+						return nil, fmt.Errorf("%w: entry %s: %v", ErrInvalidFile, externalID, err)
+					}
+					line.ExternalID = details.ServicerRef
+					if line.ExternalID == "" && externalID != "" {
+						line.ExternalID = externalID + "/" + strconv.Itoa(i+1)
+					}
+					line.Reference = details.reference()
+					lines = append(lines, line)
+				}
+				continue
+			}
+
+			number++
+			line, err := camtLine(number, entry.Amount, entry.Indicator, date)
+			if err != nil {
+				return nil, fmt.Errorf("%w: entry %s: %v", ErrInvalidFile, externalID, err)
+			}
+			line.ExternalID = externalID
+			if len(entry.Details) == 1 {
+				line.Reference = entry.Details[0].reference()
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// batch reports whether an entry books several transactions that each carry
+// an amount.
+func batch(details []camtTxDetails) bool {
+	if len(details) < 2 {
+		return false
+	}
+	for _, d := range details {
+		if d.Amount == nil && d.LegacyAmount == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *camtTxDetails) reference() string {
+	if id := strings.TrimSpace(d.EndToEndID); id != "" && id != "NOTPROVIDED" {
+		return id
+	}
+	return strings.TrimSpace(strings.Join(d.Remittance, " "))
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func camtLine(number int, amount camtAmount, indicator string, date time.Time) (Line, error) {
+	line := Line{Number: number, Currency: strings.ToUpper(strings.TrimSpace(amount.Currency)), Date: date}
+	units, err := entity.ParseDecimal(strings.TrimSpace(amount.Value), line.Currency)
+	if err != nil {
+		return Line{}, err
+	}
+	if units < 0 {
+		return Line{}, errors.New("amount must not be signed")
+	}
+	debit, err := parseDirection(indicator)
+	if err != nil {
+		return Line{}, err
+	}
+	line.Amount = units
+	if debit {
+		line.Amount = -units
+	}
+	return line, nil
+}
+
+// parseDate reads a date or an RFC 3339 time and returns its calendar day
+// as midnight UTC.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseDirection reports whether a credit/debit indicator is a debit.
+func parseDirection(value string) (bool, error) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "CRDT", "C", "CR", "CREDIT":
+		return false, nil
+	case "DBIT", "D", "DR", "DEBIT":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid direction %q", value)
+}
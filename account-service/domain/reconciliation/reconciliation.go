@@ -0,0 +1,208 @@
+// Package reconciliation reads the settlement files of the partner bank and
+// matches their lines against the transactions booked here.
+package reconciliation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Outcomes of matching a line.
+const (
+	StatusMatched   = "matched"
+	StatusUnmatched = "unmatched"
+	StatusAmbiguous = "ambiguous"
+)
+
+// Line is one movement reported by the bank. Amount is in minor units of
+// Currency and negative for debits. Date is the booking date at midnight
+// UTC. ExternalID is the bank's own reference of the line, when the file
+// carries one.
+type Line struct {
+	Number     int
+	ExternalID string
+	Amount     int64
+	Currency   string
+	Date       time.Time
+	Reference  string
+}
+
+// Candidate is a booked transaction a line may settle. Amount is signed like
+// the amount of a line: negative when money left the bank.
+type Candidate struct {
+	ID        uint
+	Amount    int64
+	Currency  string
+	Date      time.Time
+	Reference string
+}
+
+// Result is the outcome of matching a line. TransactionID is set for matched
+// lines; Candidates lists the transactions an ambiguous line fits.
+type Result struct {
+	Line          Line
+	Status        string
+	TransactionID uint
+	Candidates    []uint
+}
+
+// Config holds the tolerances of the matching. A line fits a transaction
+// when their amounts differ by at most AmountTolerance minor units and their
+// dates by at most DateToleranceDays days, which covers fees the bank takes
+// on the way and settlement lag.
+//
+//	{"amount_tolerance": 0, "date_tolerance_days": 2}
+type Config struct {
+	AmountTolerance   int64 `json:"amount_tolerance"`
+	DateToleranceDays int   `json:"date_tolerance_days"`
+}
+
+// DefaultConfig matches exact amounts settled up to two days apart.
+var DefaultConfig = Config{DateToleranceDays: 2}
+
+var ErrInvalidConfig = errors.New("invalid reconciliation configuration")
+
+// Load reads and validates a configuration file.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads and validates a configuration.
+func Parse(r io.Reader) (*Config, error) {
+	config := DefaultConfig
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if c.AmountTolerance < 0 {
+		return fmt.Errorf("%w: amount_tolerance must not be negative", ErrInvalidConfig)
+	}
+	if c.DateToleranceDays < 0 {
+		return fmt.Errorf("%w: date_tolerance_days must not be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Match pairs every line with at most one candidate, and every candidate
+// with at most one line. A candidate fits a line when the currency and the
+// direction are the same, the amount and the date are within the tolerances
+// and the references, when both have one, contain one another. Among the
+// candidates that fit, those with a matching reference are preferred over
+// those without, and exact amounts over close ones. A line is matched when
+// a single candidate is left; lines are resolved in rounds, so that taking a
+// candidate for one line can settle another that was ambiguous before.
+func (c *Config) Match(lines []Line, candidates []Candidate) []Result {
+	results := make([]Result, len(lines))
+	for i := range lines {
+		results[i] = Result{Line: lines[i], Status: StatusUnmatched}
+	}
+	taken := make(map[uint]bool)
+	open := make([]bool, len(lines))
+	for i := range open {
+		open[i] = true
+	}
+
+	for progress := true; progress; {
+		progress = false
+		for i := range lines {
+			if !open[i] {
+				continue
+			}
+			fits := c.best(lines[i], candidates, taken)
+			switch len(fits) {
+			case 0:
+				results[i].Status, results[i].Candidates = StatusUnmatched, nil
+				open[i] = false
+			case 1:
+				results[i].Status, results[i].TransactionID, results[i].Candidates = StatusMatched, fits[0], nil
+				taken[fits[0]] = true
+				open[i] = false
+				progress = true
+			default:
+				results[i].Status, results[i].Candidates = StatusAmbiguous, fits
+			}
+		}
+	}
+	return results
+}
+
+// best returns the IDs of the free candidates that fit a line best.
+func (c *Config) best(line Line, candidates []Candidate, taken map[uint]bool) []uint {
+	var fits []uint
+	bestRank := -1
+	for i := range candidates {
+		candidate := &candidates[i]
+		if taken[candidate.ID] || !c.fits(line, candidate) {
+			continue
+		}
+		rank := 0
+		if line.Reference == "" || candidate.Reference == "" {
+			rank += 2
+		}
+		if line.Amount != candidate.Amount {
+			rank++
+		}
+		switch {
+		case bestRank == -1 || rank < bestRank:
+			fits, bestRank = []uint{candidate.ID}, rank
+		case rank == bestRank:
+			fits = append(fits, candidate.ID)
+		}
+	}
+	return fits
+}
+
+func (c *Config) fits(line Line, candidate *Candidate) bool {
+	if line.Currency != candidate.Currency || (line.Amount < 0) != (candidate.Amount < 0) {
+		return false
+	}
+	if abs(line.Amount-candidate.Amount) > c.AmountTolerance {
+		return false
+	}
+	days := int(day(candidate.Date).Sub(line.Date).Hours() / 24)
+	if abs(int64(days)) > int64(c.DateToleranceDays) {
+		return false
+	}
+	if line.Reference == "" || candidate.Reference == "" {
+		return true
+	}
+	a, b := normalizeReference(line.Reference), normalizeReference(candidate.Reference)
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// normalizeReference drops case and spacing, which banks change freely.
+func normalizeReference(reference string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reference), ""))
+}
+
+// day returns midnight UTC of the day of t.
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
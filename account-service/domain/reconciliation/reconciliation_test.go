@@ -0,0 +1,136 @@
+package reconciliation
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse(time.DateOnly, value)
+	return t
+}
+
+func TestReadCSV(t *testing.T) {
+	lines, err := ReadCSV(strings.NewReader("\ufeffId;Date;Amount;Currency;Direction;Reference\n" +
+		"B-1;2026-09-13;500,00;usd;CRDT;Salary September\n" +
+		"B-2;2026-09-13T22:10:00Z;25,5;USD;D;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Number: 2, ExternalID: "B-1", Amount: 50000, Currency: "USD", Date: date("2026-09-13"), Reference: "Salary September"},
+		{Number: 3, ExternalID: "B-2", Amount: -2550, Currency: "USD", Date: date("2026-09-13")},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Unexpected lines %+v", lines)
+	}
+
+	for _, file := range []string{
+		"date,amount\n2026-09-13,1.00\n",               // no currency column
+		"date,amount,currency\n2026-09-13,1.005,USD\n", // more digits than the currency has
+		"date,amount,currency\n13.09.2026,1.00,USD\n",  // unknown date format
+		"date,amount,currency\n2026-09-13,1.00,XXX\n",  // unknown currency
+		"date,amount,currency,direction\n2026-09-13,-1,USD,DBIT\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(file)); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Expected %q to be rejected, got %v", file, err)
+		}
+	}
+	if _, err := Read(strings.NewReader("date,amount,currency\n"), FormatCSV); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("Expected an empty file to be rejected, got %v", err)
+	}
+}
+
+func TestReadCAMT054(t *testing.T) {
+	f, err := os.Open("testdata/settlement.camt054.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines, err := Read(f, FormatCAMT054)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Number: 1, ExternalID: "BANK-001", Amount: 50000, Currency: "USD", Date: date("2026-09-13"), Reference: "Salary September"},
+		{Number: 2, ExternalID: "BANK-002-A", Amount: -2550, Currency: "USD", Date: date("2026-09-13"), Reference: "E2E-1"},
+		{Number: 3, ExternalID: "BANK-002/2", Amount: -2000, Currency: "USD", Date: date("2026-09-13"), Reference: "E2E-2"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Unexpected lines %+v", lines)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	at := func(value string) time.Time { return date(value).Add(15 * time.Hour) }
+	candidates := []Candidate{
+		{ID: 1, Amount: 50000, Currency: "USD", Date: at("2026-09-12"), Reference: "salary  september"},
+		{ID: 2, Amount: 50000, Currency: "USD", Date: at("2026-09-13")},
+		{ID: 3, Amount: -2550, Currency: "USD", Date: at("2026-09-13")},
+		{ID: 4, Amount: -2550, Currency: "USD", Date: at("2026-09-14")},
+		{ID: 5, Amount: -2000, Currency: "USD", Date: at("2026-09-01")},
+		{ID: 6, Amount: 1000, Currency: "EUR", Date: at("2026-09-13")},
+		{ID: 7, Amount: 7500, Currency: "USD", Date: at("2026-09-13"), Reference: "INV-7"},
+		{ID: 8, Amount: 7490, Currency: "USD", Date: at("2026-09-13")},
+		{ID: 9, Amount: 12000, Currency: "USD", Date: at("2026-09-13")},
+		{ID: 10, Amount: 12000, Currency: "USD", Date: at("2026-09-14"), Reference: "RENT"},
+	}
+	lines := []Line{
+		{Number: 1, Amount: 50000, Currency: "USD", Date: date("2026-09-13"), Reference: "Salary September"},
+		{Number: 2, Amount: -2550, Currency: "USD", Date: date("2026-09-13")},
+		{Number: 3, Amount: -2000, Currency: "USD", Date: date("2026-09-13")},
+		{Number: 4, Amount: 1000, Currency: "USD", Date: date("2026-09-13")},
+		{Number: 5, Amount: 7490, Currency: "USD", Date: date("2026-09-13"), Reference: "INV-7"},
+		{Number: 6, Amount: 12000, Currency: "USD", Date: date("2026-09-13")},
+		{Number: 7, Amount: 12000, Currency: "USD", Date: date("2026-09-13"), Reference: "RENT"},
+		{Number: 8, Amount: 50000, Currency: "USD", Date: date("2026-09-13")},
+	}
+	config := Config{AmountTolerance: 10, DateToleranceDays: 1}
+
+	var got []string
+	for _, result := range config.Match(lines, candidates) {
+		got = append(got, strings.TrimSpace(result.Status+" "+ids(result)))
+	}
+	want := []string{
+		"matched 1",     // the reference wins over the same amount on the same day
+		"ambiguous 3 4", // two debits of the same amount within a day
+		"unmatched",     // twelve days apart
+		"unmatched",     // wrong currency
+		"matched 7",     // within the amount tolerance, and the reference agrees
+		"matched 9",     // 10 takes the line with its reference, which leaves 9
+		"matched 10",    // the reference picks 10 over 9
+		"matched 2",     // 1 is taken by the first line
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected results\n got %q\nwant %q", got, want)
+	}
+}
+
+func ids(result Result) string {
+	if result.Status == StatusMatched {
+		return strconv.FormatUint(uint64(result.TransactionID), 10)
+	}
+	var s []string
+	for _, id := range result.Candidates {
+		s = append(s, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := Parse(strings.NewReader(`{"amount_tolerance": 5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.AmountTolerance != 5 || config.DateToleranceDays != DefaultConfig.DateToleranceDays {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	if _, err := Parse(strings.NewReader(`{"date_tolerance_days": -1}`)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected a negative tolerance to be rejected, got %v", err)
+	}
+}
@@ -2,144 +2,691 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"math"
 	"time"
 
+	"github.com/m-dehghani/account-service/domain/aggregate"
 	repository "github.com/m-dehghani/account-service/domain/data"
 	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/fees"
+	"github.com/m-dehghani/account-service/domain/interest"
+	"github.com/m-dehghani/account-service/domain/reconciliation"
+	"github.com/m-dehghani/account-service/domain/statement"
 	pb "github.com/m-dehghani/account-service/proto"
 )
 
+var (
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrAccountNumberRequired = errors.New("account number is required")
+	ErrInsufficientFunds     = errors.New("insufficient funds")
+	ErrSameAccount           = errors.New("cannot transfer to the same account")
+)
+
 type AccountService struct {
-	repo repository.AccountRepository
+	repo     repository.AccountRepository
+	ledger   *Ledger
+	interest *interest.Config
+	fees     *fees.Config
+	// reconciliation holds the matching tolerances; nil means the defaults.
+	reconciliation *reconciliation.Config
+	// dormancyMonths is how long an account may go without customer
+	// activity before it is flagged dormant.
+	dormancyMonths int
 }
 
 func NewAccountService(repo repository.AccountRepository) *AccountService {
-	return &AccountService{repo: repo}
+	return &AccountService{repo: repo, ledger: NewLedger(repo), dormancyMonths: DefaultDormancyMonths}
 }
 
+// CreateAccount makes sure a newly registered customer has a default checking
+// account. Calling it again returns the existing one; further accounts are
+// opened with OpenAccount.
 func (s *AccountService) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
-	account := entity.Account{
-		CustomerID: uint(req.Customerid),
-		Balance:    0.0,
+	if existing, err := s.repo.GetAccountByCustomerID(ctx, uint(req.Customerid)); err == nil {
+		return &pb.CreateAccountResponse{Success: true, Message: "account already exists", AccountNumber: existing.Number}, nil
 	}
-	if err := s.repo.CreateAccount(ctx, &account); err != nil {
+
+	account, err := s.openAccount(ctx, uint(req.Customerid), entity.ProductChecking, req.Currency)
+	if err != nil {
 		return &pb.CreateAccountResponse{Success: false, Message: err.Error()}, nil
 	}
 
-	return &pb.CreateAccountResponse{Success: true, Message: "account created successfully"}, nil
+	return &pb.CreateAccountResponse{Success: true, Message: "account created successfully", AccountNumber: account.Number}, nil
+}
+
+// OpenAccount opens an additional account of the given product type.
+func (s *AccountService) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.OpenAccountResponse, error) {
+	account, err := s.openAccount(ctx, uint(req.Customerid), req.ProductType, req.Currency)
+	if err != nil {
+		return &pb.OpenAccountResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	return &pb.OpenAccountResponse{
+		Success: true,
+		Message: "account opened successfully",
+		Account: accountToProto(account, 0),
+	}, nil
+}
+
+func (s *AccountService) openAccount(ctx context.Context, customerID uint, productType, currency string) (*entity.Account, error) {
+	if !entity.ValidProductType(productType) {
+		return nil, entity.ErrUnknownProduct
+	}
+	currency = entity.NormalizeCurrency(currency)
+	if _, err := entity.CurrencyExponent(currency); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account := &entity.Account{
+		CustomerID:  customerID,
+		ProductType: productType,
+		Currency:    currency,
+		Status:      entity.AccountActive,
+		OpenedAt:    &now,
+	}
+	err := s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		if err := repo.CreateAccount(ctx, account); err != nil {
+			return err
+		}
+		if _, err := NewLedger(repo).CustomerAccount(ctx, account); err != nil {
+			return err
+		}
+		if err := appendEvents(ctx, repo, account, now, &aggregate.Opened{
+			Number:      account.Number,
+			CustomerID:  account.CustomerID,
+			ProductType: account.ProductType,
+			Currency:    account.Currency,
+			Status:      account.Status,
+			OpenedAt:    now,
+		}); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, events.AccountCreated{
+			AccountNumber: account.Number,
+			CustomerID:    account.CustomerID,
+			ProductType:   account.ProductType,
+			Currency:      account.Currency,
+			OccurredAt:    now,
+		})
+	})
+	return account, err
+}
+
+// ListAccounts returns every account of a customer with its balance.
+func (s *AccountService) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	accounts, err := s.repo.ListAccountsByCustomerID(ctx, uint(req.Customerid))
+	if err != nil {
+		return &pb.ListAccountsResponse{Message: "failed to list accounts"}, nil
+	}
+
+	var grpcAccounts []*pb.Account
+	for i := range accounts {
+		customerLedger, err := s.ledger.CustomerAccount(ctx, &accounts[i])
+		if err != nil {
+			return &pb.ListAccountsResponse{Message: "ledger account not found"}, nil
+		}
+		balance, err := s.ledger.Balance(ctx, customerLedger)
+		if err != nil {
+			return &pb.ListAccountsResponse{Message: "failed to read balance"}, nil
+		}
+		grpcAccounts = append(grpcAccounts, accountToProto(&accounts[i], balance))
+	}
+
+	return &pb.ListAccountsResponse{Accounts: grpcAccounts, Message: "accounts retrieved"}, nil
 }
 
 func (s *AccountService) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
-	tx := s.repo.Begin()
-	if tx.Error != nil {
-		return &pb.WithdrawResponse{Success: false, Message: "failed to start transaction"}, nil
+	var replayed pb.WithdrawResponse
+	if found, err := replayResponse(ctx, s.repo, req.Customerid, req, &replayed); err != nil {
+		return &pb.WithdrawResponse{Success: false, Message: failureMessage(err, "withdraw failed")}, nil
+	} else if found {
+		return &replayed, nil
+	}
+	amount, err := moneyFromProto(req.Amount)
+	if err != nil {
+		return &pb.WithdrawResponse{Success: false, Message: err.Error()}, nil
 	}
 
-	account, err := s.repo.GetAccountByCustomerID(ctx, uint(req.Customerid))
+	res := &pb.WithdrawResponse{Success: true, Message: "withdraw successful"}
+
+	err = s.inTx(ctx, func(repo repository.AccountRepository) error {
+		if err := rememberResponse(ctx, repo, req.Customerid, req, res); err != nil {
+			return err
+		}
+		_, err := s.withdraw(ctx, repo, req.Customerid, req.AccountNumber, amount, "")
+		return err
+	})
 	if err != nil {
-		tx.Rollback()
-		return &pb.WithdrawResponse{Success: false, Message: "account not found"}, nil
+		found, err := replayAfterFailure(ctx, s.repo, req.Customerid, req, &replayed, err)
+		if found {
+			return &replayed, nil
+		}
+		return &pb.WithdrawResponse{Success: false, Message: failureMessage(err, "withdraw failed"), LimitViolation: limitViolationToProto(err)}, nil
 	}
 
-	if account.Balance < req.Amount {
-		tx.Rollback()
-		return &pb.WithdrawResponse{Success: false, Message: "insufficient funds"}, nil
+	return res, nil
+}
+
+// withdraw books a withdrawal within a unit of work and returns its
+// transaction. The account must belong to customerID.
+func (s *AccountService) withdraw(ctx context.Context, repo repository.AccountRepository, customerID uint32, number string, amount entity.Money, reference string) (*entity.Transaction, error) {
+	ledger := NewLedger(repo)
+
+	account, err := ownedAccount(ctx, repo, customerID, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := account.CanDebit(); err != nil {
+		return nil, err
+	}
+	if account.Currency != amount.Currency {
+		return nil, entity.ErrCurrencyMismatch
+	}
+	if err := checkWithdrawalLimits(ctx, repo, account, amount.Units, time.Now()); err != nil {
+		return nil, err
 	}
 
-	account.Balance -= req.Amount
-	if err := s.repo.UpdateAccount(ctx, account); err != nil {
-		tx.Rollback()
-		return &pb.WithdrawResponse{Success: false, Message: "failed to update balance"}, nil
+	before, err := checkFunds(ctx, repo, account, amount.Units)
+	if err != nil {
+		return nil, err
+	}
+	charged := s.operationFees(fees.EventWithdraw, account, amount.Units, before)
+	debit := amount.Units + fees.Total(charged)
+	if len(charged) > 0 {
+		if _, err := checkFunds(ctx, repo, account, debit); err != nil {
+			return nil, err
+		}
+	}
+
+	customerLedger, err := ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	cash, err := ledger.SystemAccount(ctx, entity.LedgerCash, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := ledger.Move(ctx, "withdraw", customerLedger, cash, amount)
+	if err != nil {
+		return nil, err
 	}
 
 	transaction := entity.Transaction{
-		CustomerID: uint(req.Customerid),
-		Type:       "withdraw",
-		Amount:     req.Amount,
-		Date:       time.Now(),
+		CustomerID:     account.CustomerID,
+		AccountID:      account.ID,
+		JournalEntryID: journal.ID,
+		Type:           entity.TransactionWithdraw,
+		Amount:         amount.Units,
+		Currency:       amount.Currency,
+		Reference:      reference,
+		Date:           journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, account, &transaction); err != nil {
+		return nil, err
+	}
+	if err := chargeFees(ctx, repo, account, charged, &transaction); err != nil {
+		return nil, err
+	}
+	if err := noteOverdrawn(ctx, repo, account, before, debit, transaction.ID, journal.Date); err != nil {
+		return nil, err
 	}
-	if err := s.repo.CreateTransaction(ctx, &transaction); err != nil {
-		tx.Rollback()
-		return &pb.WithdrawResponse{Success: false, Message: "failed to record transaction"}, nil
+	if err := recordEvent(ctx, repo, events.Withdrawn{
+		AccountNumber: account.Number,
+		CustomerID:    account.CustomerID,
+		TransactionID: transaction.ID,
+		Amount:        events.NewAmount(amount),
+		OccurredAt:    journal.Date,
+	}); err != nil {
+		return nil, err
 	}
+	if err := repo.BumpAccountVersion(ctx, account); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return &pb.WithdrawResponse{Success: false, Message: "failed to commit transaction"}, nil
+func (s *AccountService) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.DepositResponse, error) {
+	var replayed pb.DepositResponse
+	if found, err := replayResponse(ctx, s.repo, req.Customerid, req, &replayed); err != nil {
+		return &pb.DepositResponse{Success: false, Message: failureMessage(err, "deposit failed")}, nil
+	} else if found {
+		return &replayed, nil
 	}
+	amount, err := moneyFromProto(req.Amount)
+	if err != nil {
+		return &pb.DepositResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	res := &pb.DepositResponse{Success: true, Message: "deposit successful"}
+
+	err = s.inTx(ctx, func(repo repository.AccountRepository) error {
+		if err := rememberResponse(ctx, repo, req.Customerid, req, res); err != nil {
+			return err
+		}
+		ledger := NewLedger(repo)
 
-	// Send event
-	event := "Withdraw successful for customer ID: " + string(rune(req.Customerid))
-	fmt.Println(event)
+		account, err := ownedAccount(ctx, repo, req.Customerid, req.AccountNumber)
+		if err != nil {
+			return err
+		}
+		if err := account.CanCredit(); err != nil {
+			return err
+		}
+		if account.Currency != amount.Currency {
+			return entity.ErrCurrencyMismatch
+		}
+		var charged []fees.Fee
+		if s.fees != nil {
+			before, _, err := balances(ctx, repo, account, time.Now())
+			if err != nil {
+				return err
+			}
+			charged = s.operationFees(fees.EventDeposit, account, amount.Units, before)
+		}
 
-	return &pb.WithdrawResponse{Success: true, Message: "withdraw successful"}, nil
+		customerLedger, err := ledger.CustomerAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		cash, err := ledger.SystemAccount(ctx, entity.LedgerCash, amount.Currency)
+		if err != nil {
+			return err
+		}
+		journal, err := ledger.Move(ctx, "deposit", cash, customerLedger, amount)
+		if err != nil {
+			return err
+		}
+
+		transaction := entity.Transaction{
+			CustomerID:     account.CustomerID,
+			AccountID:      account.ID,
+			JournalEntryID: journal.ID,
+			Type:           entity.TransactionDeposit,
+			Amount:         amount.Units,
+			Currency:       amount.Currency,
+			Date:           journal.Date,
+		}
+		if err := bookTransaction(ctx, repo, account, &transaction); err != nil {
+			return err
+		}
+		// Deposit fees come out of the deposit; they may not take the
+		// account further than its overdraft allows.
+		if len(charged) > 0 {
+			if _, err := checkFunds(ctx, repo, account, fees.Total(charged)); err != nil {
+				return err
+			}
+			if err := chargeFees(ctx, repo, account, charged, &transaction); err != nil {
+				return err
+			}
+		}
+		if err := recordEvent(ctx, repo, events.Deposited{
+			AccountNumber: account.Number,
+			CustomerID:    account.CustomerID,
+			TransactionID: transaction.ID,
+			Amount:        events.NewAmount(amount),
+			OccurredAt:    journal.Date,
+		}); err != nil {
+			return err
+		}
+		return repo.BumpAccountVersion(ctx, account)
+	})
+	if err != nil {
+		found, err := replayAfterFailure(ctx, s.repo, req.Customerid, req, &replayed, err)
+		if found {
+			return &replayed, nil
+		}
+		return &pb.DepositResponse{Success: false, Message: failureMessage(err, "deposit failed")}, nil
+	}
+
+	return res, nil
 }
 
-func (s *AccountService) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.DepositResponse, error) {
-	tx := s.repo.Begin()
-	if tx.Error != nil {
-		return &pb.DepositResponse{Success: false, Message: "failed to start transaction"}, nil
+// Transfer moves money between the accounts of two customers. The journal
+// entry and both history rows are written in one database transaction.
+func (s *AccountService) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	var replayed pb.TransferResponse
+	if found, err := replayResponse(ctx, s.repo, req.FromCustomerid, req, &replayed); err != nil {
+		return &pb.TransferResponse{Success: false, Message: failureMessage(err, "transfer failed")}, nil
+	} else if found {
+		return &replayed, nil
+	}
+	amount, err := moneyFromProto(req.Amount)
+	if err != nil {
+		return &pb.TransferResponse{Success: false, Message: err.Error()}, nil
+	}
+	if req.FromAccountNumber == req.ToAccountNumber {
+		return &pb.TransferResponse{Success: false, Message: ErrSameAccount.Error()}, nil
 	}
 
-	account, err := s.repo.GetAccountByCustomerID(ctx, uint(req.Customerid))
+	res := &pb.TransferResponse{Success: true, Message: "transfer successful"}
+	err = s.inTx(ctx, func(repo repository.AccountRepository) error {
+		if err := rememberResponse(ctx, repo, req.FromCustomerid, req, res); err != nil {
+			return err
+		}
+		_, err := s.transfer(ctx, repo, req.FromCustomerid, req.FromAccountNumber, req.ToAccountNumber, amount, req.Reference)
+		return err
+	})
 	if err != nil {
-		tx.Rollback()
-		return &pb.DepositResponse{Success: false, Message: "account not found"}, nil
+		found, err := replayAfterFailure(ctx, s.repo, req.FromCustomerid, req, &replayed, err)
+		if found {
+			return &replayed, nil
+		}
+		return &pb.TransferResponse{Success: false, Message: failureMessage(err, "transfer failed")}, nil
 	}
 
-	account.Balance += req.Amount
-	if err := s.repo.UpdateAccount(ctx, account); err != nil {
-		tx.Rollback()
-		return &pb.DepositResponse{Success: false, Message: "failed to update balance"}, nil
+	return res, nil
+}
+
+// transfer books a transfer within a unit of work and returns the outgoing
+// leg. The source account must belong to fromCustomerID.
+func (s *AccountService) transfer(ctx context.Context, repo repository.AccountRepository, fromCustomerID uint32, fromNumber, toNumber string, amount entity.Money, reference string) (*entity.Transaction, error) {
+	ledger := NewLedger(repo)
+
+	from, err := ownedAccount(ctx, repo, fromCustomerID, fromNumber)
+	if err != nil {
+		return nil, err
+	}
+	to, err := repo.GetAccountByNumber(ctx, toNumber)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	if err := from.CanDebit(); err != nil {
+		return nil, err
+	}
+	if err := to.CanCredit(); err != nil {
+		return nil, err
+	}
+	if from.Currency != amount.Currency || to.Currency != amount.Currency {
+		return nil, entity.ErrCurrencyMismatch
+	}
+	if err := checkWithdrawalLimits(ctx, repo, from, amount.Units, time.Now()); err != nil {
+		return nil, err
 	}
 
-	transaction := entity.Transaction{
-		CustomerID: uint(req.Customerid),
-		Type:       "deposit",
-		Amount:     req.Amount,
-		Date:       time.Now(),
+	fromLedger, err := ledger.CustomerAccount(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toLedger, err := ledger.CustomerAccount(ctx, to)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.repo.CreateTransaction(ctx, &transaction); err != nil {
-		tx.Rollback()
-		return &pb.DepositResponse{Success: false, Message: "failed to record transaction"}, nil
+
+	before, err := checkFunds(ctx, repo, from, amount.Units)
+	if err != nil {
+		return nil, err
+	}
+	charged := s.operationFees(fees.EventTransfer, from, amount.Units, before)
+	debit := amount.Units + fees.Total(charged)
+	if len(charged) > 0 {
+		if _, err := checkFunds(ctx, repo, from, debit); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return &pb.DepositResponse{Success: false, Message: "failed to commit transaction"}, nil
+	journal, err := ledger.Move(ctx, "transfer", fromLedger, toLedger, amount)
+	if err != nil {
+		return nil, err
 	}
 
-	// Send event
-	event := "Deposit successful for customer ID: " + fmt.Sprint(req.Customerid)
-	fmt.Println(event)
+	out := entity.Transaction{
+		CustomerID:     from.CustomerID,
+		AccountID:      from.ID,
+		JournalEntryID: journal.ID,
+		Type:           entity.TransactionTransferOut,
+		Amount:         amount.Units,
+		Currency:       amount.Currency,
+		Reference:      reference,
+		Date:           journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, from, &out); err != nil {
+		return nil, err
+	}
+	in := entity.Transaction{
+		CustomerID:          to.CustomerID,
+		AccountID:           to.ID,
+		JournalEntryID:      journal.ID,
+		LinkedTransactionID: &out.ID,
+		Type:                entity.TransactionTransferIn,
+		Amount:              amount.Units,
+		Currency:            amount.Currency,
+		Reference:           reference,
+		Date:                journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, to, &in); err != nil {
+		return nil, err
+	}
+	if err := linkTransaction(ctx, repo, from, &out, in.ID); err != nil {
+		return nil, err
+	}
+	if err := chargeFees(ctx, repo, from, charged, &out); err != nil {
+		return nil, err
+	}
+	if err := noteOverdrawn(ctx, repo, from, before, debit, out.ID, journal.Date); err != nil {
+		return nil, err
+	}
+	if err := recordEvent(ctx, repo, events.Transferred{
+		FromAccountNumber: from.Number,
+		ToAccountNumber:   to.Number,
+		OutTransactionID:  out.ID,
+		InTransactionID:   in.ID,
+		Amount:            events.NewAmount(amount),
+		Reference:         reference,
+		OccurredAt:        journal.Date,
+	}); err != nil {
+		return nil, err
+	}
 
-	return &pb.DepositResponse{Success: true, Message: "deposit successful"}, nil
+	// Bump in ID order so that opposite transfers cannot deadlock.
+	first, second := from, to
+	if second.ID < first.ID {
+		first, second = second, first
+	}
+	if err := repo.BumpAccountVersion(ctx, first); err != nil {
+		return nil, err
+	}
+	if err := repo.BumpAccountVersion(ctx, second); err != nil {
+		return nil, err
+	}
+	return &out, nil
 }
 
 func (s *AccountService) BalanceInquiry(ctx context.Context, req *pb.BalanceInquiryRequest) (*pb.BalanceInquiryResponse, error) {
-	account, err := s.repo.GetAccountByCustomerID(ctx, uint(req.Customerid))
+	account, err := ownedAccount(ctx, s.repo, req.Customerid, req.AccountNumber)
 	if err != nil {
-		return &pb.BalanceInquiryResponse{Balance: 0, Message: "account not found"}, nil
+		return &pb.BalanceInquiryResponse{Message: err.Error()}, nil
 	}
 
-	return &pb.BalanceInquiryResponse{Balance: account.Balance, Message: "balance inquiry successful"}, nil
+	balance, available, err := balances(ctx, s.repo, account, time.Now())
+	if err != nil {
+		return &pb.BalanceInquiryResponse{Message: "failed to read balance"}, nil
+	}
+
+	return &pb.BalanceInquiryResponse{
+		Balance:           moneyToProto(entity.Money{Units: balance, Currency: account.Currency}),
+		AvailableBalance:  moneyToProto(entity.Money{Units: available, Currency: account.Currency}),
+		OverdraftLimit:    moneyToProto(entity.Money{Units: account.OverdraftLimit, Currency: account.Currency}),
+		OverdraftHeadroom: moneyToProto(entity.Money{Units: overdraftHeadroom(account, available), Currency: account.Currency}),
+		Message:           "balance inquiry successful",
+	}, nil
+}
+
+// MigrateLegacyBalances moves the balances that accounts stored before the
+// ledger existed into opening journal entries against the suspense account,
+// then drops the old column. Those balances were floats in the default
+// currency. The entries and the drop are one unit of work, so a migration
+// that is interrupted is rolled back and starts over on the next run rather
+// than posting some opening balances twice.
+func (s *AccountService) MigrateLegacyBalances(ctx context.Context) error {
+	return s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		balances, err := repo.LegacyBalances(ctx)
+		if err != nil {
+			return err
+		}
+		if len(balances) == 0 {
+			return repo.DropLegacyBalances(ctx)
+		}
+
+		ledger := NewLedger(repo)
+		suspense, err := ledger.SystemAccount(ctx, entity.LedgerSuspense, entity.DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		exponent, err := entity.CurrencyExponent(entity.DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		factor := math.Pow10(exponent)
+		for accountID, balance := range balances {
+			account := &entity.Account{ID: accountID, Currency: entity.DefaultCurrency}
+			customerLedger, err := ledger.CustomerAccount(ctx, account)
+			if err != nil {
+				return err
+			}
+			opening := entity.Money{Units: int64(math.Round(balance * factor)), Currency: account.Currency}
+			if _, err := ledger.Move(ctx, openingBalanceDescription, suspense, customerLedger, opening); err != nil {
+				return err
+			}
+		}
+		return repo.DropLegacyBalances(ctx)
+	})
 }
 
-func (s *AccountService) TransactionHistory(ctx context.Context, req *pb.TransactionHistoryRequest) (*pb.TransactionHistoryResponse, error) {
-	transactions, err := s.repo.GetTransactionsByCustomerID(ctx, uint(req.Customerid))
+// maxTxAttempts bounds how often a balance change is retried after losing a
+// race for an account to a concurrent one.
+const maxTxAttempts = 3
+
+// inTx runs fn as one unit of work. Every read and write of fn goes through
+// the repository it is given, so the balance checks and the postings they
+// guard see the same state. A run that fails with
+// repository.ErrConcurrentUpdate has been rolled back and is retried.
+func (s *AccountService) inTx(ctx context.Context, fn func(repo repository.AccountRepository) error) error {
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = s.repo.WithTx(ctx, fn)
+		if !errors.Is(err, repository.ErrConcurrentUpdate) {
+			return err
+		}
+	}
+	return err
+}
+
+// recordEvent adds an event to the outbox. Called with the repository of a
+// unit of work, the event is stored if and only if the work commits.
+func recordEvent(ctx context.Context, repo repository.AccountRepository, event events.Event) error {
+	message, err := events.NewOutboxMessage(event)
 	if err != nil {
-		return &pb.TransactionHistoryResponse{Transactions: nil, Message: "no transactions found"}, nil
+		return err
 	}
+	return repo.CreateOutboxMessage(ctx, message)
+}
 
-	var grpcTransactions []*pb.Transaction
-	for _, t := range transactions {
-		grpcTransactions = append(grpcTransactions, &pb.Transaction{
-			Id:         uint32(t.ID),
-			Customerid: uint32(t.CustomerID),
-			Type:       t.Type,
-			Amount:     t.Amount,
-			Date:       t.Date.Format(time.RFC3339),
-		})
+// ownedAccount resolves an account number and makes sure the account belongs
+// to the given customer. Accounts of other customers are reported as not
+// found so that numbers cannot be probed.
+func ownedAccount(ctx context.Context, repo repository.AccountRepository, customerID uint32, number string) (*entity.Account, error) {
+	if number == "" {
+		return nil, ErrAccountNumberRequired
 	}
+	account, err := repo.GetAccountByNumber(ctx, number)
+	if err != nil || account.CustomerID != uint(customerID) {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
 
-	return &pb.TransactionHistoryResponse{Transactions: grpcTransactions, Message: "transaction history retrieved"}, nil
+func accountToProto(account *entity.Account, balance int64) *pb.Account {
+	return &pb.Account{
+		Number:      account.Number,
+		Customerid:  uint32(account.CustomerID),
+		ProductType: account.ProductType,
+		Currency:    account.Currency,
+		Balance:     moneyToProto(entity.Money{Units: balance, Currency: account.Currency}),
+		Status:      account.Status,
+	}
+}
+
+func moneyFromProto(m *pb.Money) (entity.Money, error) {
+	if m == nil {
+		return entity.Money{}, entity.ErrNonPositive
+	}
+	money, err := entity.NewMoney(m.Units, entity.NormalizeCurrency(m.Currency))
+	if err != nil {
+		return entity.Money{}, err
+	}
+	if money.Units <= 0 {
+		return entity.Money{}, entity.ErrNonPositive
+	}
+	return money, nil
+}
+
+func moneyToProto(m entity.Money) *pb.Money {
+	return &pb.Money{Units: m.Units, Currency: m.Currency}
+}
+
+// failureMessage exposes the domain errors callers can act on and hides
+// everything else behind a generic message.
+func failureMessage(err error, fallback string) string {
+	for _, known := range []error{
+		ErrAccountNotFound,
+		ErrAccountNumberRequired,
+		ErrInsufficientFunds,
+		ErrNegativeOverdraft,
+		ErrLimitExceeded,
+		ErrNegativeLimit,
+		ErrLimitScopeRequired,
+		ErrInvalidExpiry,
+		ErrStandingOrderNotFound,
+		ErrStartInPast,
+		ErrEndBeforeStart,
+		entity.ErrUnknownFrequency,
+		entity.ErrInvalidDayOfMonth,
+		entity.ErrStandingOrderStopped,
+		ErrSameAccount,
+		ErrRequestIDReused,
+		ErrRequestIDTooLong,
+		ErrReasonRequired,
+		ErrBalanceNotZero,
+		ErrHoldsOutstanding,
+		ErrInterestUnposted,
+		entity.ErrUnknownStatus,
+		entity.ErrInvalidTransition,
+		entity.ErrAccountFrozen,
+		entity.ErrAccountDormant,
+		entity.ErrAccountClosed,
+		entity.ErrCurrencyMismatch,
+		entity.ErrNonPositive,
+		entity.ErrUnknownCurrency,
+		repository.ErrConcurrentUpdate,
+		ErrHoldNotFound,
+		entity.ErrHoldNotActive,
+		entity.ErrCaptureTooLarge,
+		ErrTransactionNotFound,
+		ErrAlreadyReversed,
+		ErrReverseReversal,
+		ErrActorRequired,
+		ErrNotReversible,
+		ErrInterestReversal,
+		entity.ErrUnknownReason,
+		ErrInvalidCursor,
+		ErrInvalidDate,
+		ErrInvalidLimit,
+		statement.ErrInvalidPeriod,
+		statement.ErrUnknownFormat,
+	} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return fallback
+}
+
+func linkedID(id *uint) uint32 {
+	if id == nil {
+		return 0
+	}
+	return uint32(*id)
 }
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+// VerifyTransactionChain recomputes the hash chain of one account, or of
+// every account, and reports where each stops matching: a missing sequence
+// number means transactions were deleted, a hash that no longer matches
+// means one was edited. Checking every account also recomputes the
+// published daily anchors, which catches transactions removed from the end
+// of a chain and chains rewritten as a whole.
+func (s *AccountService) VerifyTransactionChain(ctx context.Context, req *pb.VerifyTransactionChainRequest) (*pb.VerifyTransactionChainResponse, error) {
+	res := &pb.VerifyTransactionChainResponse{Success: true, Message: "transaction chain verified"}
+
+	if req.AccountNumber != "" {
+		account, err := s.repo.GetAccountByNumber(ctx, req.AccountNumber)
+		if err != nil {
+			return &pb.VerifyTransactionChainResponse{Success: false, Message: ErrAccountNotFound.Error()}, nil
+		}
+		if err := s.verifyChain(ctx, account, res); err != nil {
+			return &pb.VerifyTransactionChainResponse{Success: false, Message: "verifying transaction chain failed"}, nil
+		}
+		res.Intact = len(res.Breaks) == 0
+		return res, nil
+	}
+
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListAccounts(ctx, afterID, interestBatchSize)
+		if err != nil {
+			return &pb.VerifyTransactionChainResponse{Success: false, Message: "verifying transaction chain failed"}, nil
+		}
+		if len(accounts) == 0 {
+			break
+		}
+		for i := range accounts {
+			afterID = accounts[i].ID
+			if err := s.verifyChain(ctx, &accounts[i], res); err != nil {
+				return &pb.VerifyTransactionChainResponse{Success: false, Message: "verifying transaction chain failed"}, nil
+			}
+		}
+	}
+	if err := s.verifyAnchors(ctx, res); err != nil {
+		return &pb.VerifyTransactionChainResponse{Success: false, Message: "verifying chain anchors failed"}, nil
+	}
+	res.Intact = len(res.Breaks) == 0 && len(res.AnchorMismatches) == 0
+	return res, nil
+}
+
+// verifyChain walks the chain of an account and adds its first break, if
+// any, to res.
+func (s *AccountService) verifyChain(ctx context.Context, account *entity.Account, res *pb.VerifyTransactionChainResponse) error {
+	res.Accounts++
+	var sequence uint
+	var prevHash string
+	for {
+		batch, err := s.repo.ListChain(ctx, account.ID, sequence, streamBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			t := &batch[i]
+			res.Transactions++
+			reason := ""
+			switch {
+			case t.Sequence != sequence+1:
+				res.Breaks = append(res.Breaks, &pb.ChainBreak{
+					AccountNumber: account.Number,
+					Sequence:      uint32(sequence + 1),
+					Reason:        fmt.Sprintf("transactions %d to %d are missing", sequence+1, t.Sequence-1),
+				})
+				return nil
+			case t.PrevHash != prevHash:
+				reason = "transaction does not link to the previous one"
+			case t.Hash != t.ComputeHash():
+				reason = "transaction was modified"
+			}
+			if reason != "" {
+				res.Breaks = append(res.Breaks, &pb.ChainBreak{
+					AccountNumber: account.Number,
+					Sequence:      uint32(t.Sequence),
+					TransactionId: uint32(t.ID),
+					Reason:        reason,
+				})
+				return nil
+			}
+			sequence, prevHash = t.Sequence, t.Hash
+		}
+		if len(batch) < streamBatchSize {
+			return nil
+		}
+	}
+}
+
+// verifyAnchors recomputes every stored anchor from the transactions and
+// adds the ones that no longer match to res.
+func (s *AccountService) verifyAnchors(ctx context.Context, res *pb.VerifyTransactionChainResponse) error {
+	anchors, err := s.repo.ListChainAnchors(ctx)
+	if err != nil {
+		return err
+	}
+	prevHash := ""
+	for _, anchor := range anchors {
+		res.Anchors++
+		heads, _, err := s.repo.ChainHeads(ctx, anchor.Day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		computed := entity.AnchorHash(prevHash, heads)
+		if anchor.PrevHash != prevHash || anchor.Hash != computed {
+			res.AnchorMismatches = append(res.AnchorMismatches, &pb.AnchorMismatch{
+				Day:          anchor.Day.UTC().Format(time.DateOnly),
+				AnchorHash:   anchor.Hash,
+				ComputedHash: computed,
+			})
+		}
+		// Later anchors were chained to the published hash, not to the
+		// recomputed one.
+		prevHash = anchor.Hash
+	}
+	return nil
+}
+
+// AnchorTransactionChains anchors every finished day since the last anchor,
+// or only yesterday when there is none yet, and returns how many anchors it
+// made. Each anchor is published as an event.
+func (s *AccountService) AnchorTransactionChains(ctx context.Context, now time.Time) (int, error) {
+	today := startOfDay(now)
+	day := today.AddDate(0, 0, -1)
+	prevHash := ""
+	last, found, err := s.repo.LatestChainAnchor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		day = last.Day.AddDate(0, 0, 1)
+		prevHash = last.Hash
+	}
+
+	anchored := 0
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return anchored, err
+		}
+		anchor := &entity.ChainAnchor{Day: day, PrevHash: prevHash}
+		err := s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+			heads, count, err := repo.ChainHeads(ctx, day.AddDate(0, 0, 1))
+			if err != nil {
+				return err
+			}
+			anchor.Hash = entity.AnchorHash(prevHash, heads)
+			anchor.Accounts, anchor.Transactions = len(heads), count
+			if err := repo.CreateChainAnchor(ctx, anchor); err != nil {
+				return err
+			}
+			return recordEvent(ctx, repo, events.ChainAnchored{
+				Day:          day.Format(time.DateOnly),
+				PrevHash:     anchor.PrevHash,
+				Hash:         anchor.Hash,
+				Accounts:     anchor.Accounts,
+				Transactions: anchor.Transactions,
+				OccurredAt:   now,
+			})
+		})
+		if err != nil {
+			return anchored, err
+		}
+		prevHash = anchor.Hash
+		anchored++
+	}
+	return anchored, nil
+}
+
+// RunChainAnchors anchors the transaction chains every interval until ctx is
+// cancelled.
+func (s *AccountService) RunChainAnchors(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.AnchorTransactionChains(ctx, time.Now()); err != nil {
+			log.Printf("chain anchors: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
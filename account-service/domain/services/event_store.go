@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/aggregate"
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+var ErrNoProjection = errors.New("account has no balance projection")
+
+// appendEvents adds events to the stream of an account and brings its
+// projections up to date within the same unit of work: the accounts row,
+// which account also reflects afterwards, the balance and the history.
+func appendEvents(ctx context.Context, repo repository.AccountRepository, account *entity.Account, at time.Time, events ...aggregate.Event) error {
+	balance, _, err := repo.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	state := aggregate.Load(account, balance)
+	// The projection may lag behind the stream, e.g. while it is rebuilt.
+	version, err := repo.LastAccountEventVersion(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if version != state.Version {
+		return repository.ErrConcurrentUpdate
+	}
+
+	for _, event := range events {
+		stored, err := aggregate.Encode(account.ID, state.Version+1, event, at)
+		if err != nil {
+			return err
+		}
+		if err := repo.AppendAccountEvent(ctx, stored); err != nil {
+			return err
+		}
+		if err := state.Apply(stored.Version, event); err != nil {
+			return err
+		}
+		if err := projectHistory(ctx, repo, state, event); err != nil {
+			return err
+		}
+	}
+	return saveProjections(ctx, repo, account, state)
+}
+
+// bookTransaction creates a transaction and records it in the stream of its
+// account.
+func bookTransaction(ctx context.Context, repo repository.AccountRepository, account *entity.Account, transaction *entity.Transaction) error {
+	if err := repo.CreateTransaction(ctx, transaction); err != nil {
+		return err
+	}
+	booked := aggregate.NewBooked(transaction)
+	return appendEvents(ctx, repo, account, transaction.Date, &booked)
+}
+
+// linkTransaction points a booked transaction of account at its counterpart.
+func linkTransaction(ctx context.Context, repo repository.AccountRepository, account *entity.Account, transaction *entity.Transaction, linkedID uint) error {
+	transaction.LinkedTransactionID = &linkedID
+	if err := repo.UpdateTransaction(ctx, transaction); err != nil {
+		return err
+	}
+	return appendEvents(ctx, repo, account, time.Now(), &aggregate.Linked{
+		TransactionID:       transaction.ID,
+		LinkedTransactionID: linkedID,
+	})
+}
+
+// markReversed records that a transaction of account was reversed.
+func markReversed(ctx context.Context, repo repository.AccountRepository, account *entity.Account, transaction *entity.Transaction, reversal *entity.Transaction) error {
+	transaction.ReversedByTransactionID = &reversal.ID
+	if err := repo.UpdateTransaction(ctx, transaction); err != nil {
+		return err
+	}
+	return appendEvents(ctx, repo, account, reversal.Date, &aggregate.Reversed{
+		TransactionID:         transaction.ID,
+		ReversalTransactionID: reversal.ID,
+	})
+}
+
+// projectHistory updates the history projection for an event that state
+// has just applied.
+func projectHistory(ctx context.Context, repo repository.AccountRepository, state *aggregate.Account, event aggregate.Event) error {
+	switch e := event.(type) {
+	case *aggregate.Booked:
+		return repo.CreateHistoryEntry(ctx, &entity.HistoryEntry{
+			TransactionID:         e.TransactionID,
+			AccountID:             state.ID,
+			CustomerID:            e.CustomerID,
+			JournalEntryID:        e.JournalEntryID,
+			LinkedTransactionID:   optionalID(e.LinkedTransactionID),
+			ReversesTransactionID: optionalID(e.ReversesTransactionID),
+			Type:                  e.TransactionType,
+			Amount:                e.Amount,
+			Currency:              e.Currency,
+			Reference:             e.Reference,
+			ReasonCode:            e.ReasonCode,
+			Actor:                 e.Actor,
+			Date:                  e.Date,
+			BalanceAfter:          state.Balance,
+		})
+	case *aggregate.Linked:
+		return repo.LinkHistoryEntry(ctx, e.TransactionID, e.LinkedTransactionID)
+	case *aggregate.Reversed:
+		return repo.ReverseHistoryEntry(ctx, e.TransactionID, e.ReversalTransactionID)
+	}
+	return nil
+}
+
+// saveProjections writes the state of an account to its row and its balance
+// projection.
+func saveProjections(ctx context.Context, repo repository.AccountRepository, account *entity.Account, state *aggregate.Account) error {
+	state.ApplyTo(account)
+	if err := repo.SaveAccountState(ctx, account); err != nil {
+		return err
+	}
+	return repo.SaveAccountBalance(ctx, &entity.AccountBalance{
+		AccountID: account.ID,
+		Balance:   state.Balance,
+		Currency:  account.Currency,
+		Version:   state.Version,
+	})
+}
+
+// projectedBalance returns the balance of an account from its projection.
+func projectedBalance(ctx context.Context, repo repository.AccountRepository, account *entity.Account) (int64, error) {
+	balance, found, err := repo.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrNoProjection
+	}
+	return balance.Balance, nil
+}
+
+// ImportAccountStreams starts the event stream of every account that
+// predates the event store: it is opened with the account's current status
+// and overdraft and the balance it had before its first transaction, and its
+// transactions are booked in the order they were made, which leaves it with
+// the balance the ledger has. It returns how many streams it started.
+func (s *AccountService) ImportAccountStreams(ctx context.Context) (int, error) {
+	imported := 0
+	for {
+		// Imported accounts drop out of the list, so every page starts over.
+		accounts, err := s.repo.ListAccountsWithoutEvents(ctx, 0, interestBatchSize)
+		if err != nil || len(accounts) == 0 {
+			return imported, err
+		}
+		for i := range accounts {
+			account := &accounts[i]
+			if err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+				return importAccountStream(ctx, repo, account)
+			}); err != nil {
+				return imported, err
+			}
+			imported++
+		}
+	}
+}
+
+func importAccountStream(ctx context.Context, repo repository.AccountRepository, account *entity.Account) error {
+	now := time.Now()
+	opened := &aggregate.Opened{
+		Number:          account.Number,
+		CustomerID:      account.CustomerID,
+		ProductType:     account.ProductType,
+		Currency:        account.Currency,
+		Status:          account.Status,
+		OverdraftLimit:  account.OverdraftLimit,
+		OverdraftRateBP: account.OverdraftRateBP,
+		OpenedAt:        now,
+	}
+	if account.OpenedAt != nil {
+		opened.OpenedAt = *account.OpenedAt
+	}
+	var booked, reversed []aggregate.Event
+	// net is what the replayed transactions change the balance by.
+	var net int64
+	filter := repository.TransactionFilter{AccountID: account.ID, Limit: streamBatchSize}
+	for {
+		batch, err := repo.ListTransactions(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			event := aggregate.NewBooked(&batch[i])
+			booked = append(booked, &event)
+			if entity.IsCredit(batch[i].Type) {
+				net += batch[i].Amount
+			} else {
+				net -= batch[i].Amount
+			}
+			if batch[i].ReversedByTransactionID != nil {
+				reversed = append(reversed, &aggregate.Reversed{
+					TransactionID:         batch[i].ID,
+					ReversalTransactionID: *batch[i].ReversedByTransactionID,
+				})
+			}
+		}
+		if len(batch) < filter.Limit {
+			break
+		}
+		filter.AfterID = batch[len(batch)-1].ID
+	}
+
+	ledger := NewLedger(repo)
+	customerLedger, err := ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return err
+	}
+	balance, err := ledger.Balance(ctx, customerLedger)
+	if err != nil {
+		return err
+	}
+	events := []aggregate.Event{opened, &aggregate.Imported{Balance: balance - net}}
+	events = append(events, booked...)
+	events = append(events, reversed...)
+	return appendEvents(ctx, repo, account, now, events...)
+}
+
+// RebuildProjections throws away the balance and history projections of
+// every account and builds them again, together with the event-derived
+// columns of the accounts row, by replaying the account's stream. It returns
+// how many accounts it rebuilt.
+func (s *AccountService) RebuildProjections(ctx context.Context) (int, error) {
+	rebuilt := 0
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListAccounts(ctx, afterID, interestBatchSize)
+		if err != nil || len(accounts) == 0 {
+			return rebuilt, err
+		}
+		for i := range accounts {
+			account := &accounts[i]
+			afterID = account.ID
+			if err := ctx.Err(); err != nil {
+				return rebuilt, err
+			}
+			if err := s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+				return rebuildAccount(ctx, repo, account)
+			}); err != nil {
+				return rebuilt, err
+			}
+			rebuilt++
+		}
+	}
+}
+
+func rebuildAccount(ctx context.Context, repo repository.AccountRepository, account *entity.Account) error {
+	if err := repo.DeleteProjections(ctx, account.ID); err != nil {
+		return err
+	}
+	state := &aggregate.Account{ID: account.ID}
+	for {
+		stream, err := repo.ListAccountEvents(ctx, account.ID, state.Version, streamBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range stream {
+			event, err := aggregate.Decode(&stream[i])
+			if err != nil {
+				return err
+			}
+			if err := state.Apply(stream[i].Version, event); err != nil {
+				return err
+			}
+			if err := projectHistory(ctx, repo, state, event); err != nil {
+				return err
+			}
+		}
+		if len(stream) < streamBatchSize {
+			break
+		}
+	}
+	if state.Version == 0 {
+		// Not imported yet; ImportAccountStreams will project it.
+		return nil
+	}
+	return saveProjections(ctx, repo, account, state)
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
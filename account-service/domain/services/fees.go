@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/fees"
+)
+
+// feeTransactionTypes maps the event a fee is charged for to the type of the
+// fee transaction.
+var feeTransactionTypes = map[string]string{
+	fees.EventWithdraw: entity.TransactionWithdrawalFee,
+	fees.EventDeposit:  entity.TransactionDepositFee,
+	fees.EventTransfer: entity.TransactionTransferFee,
+	fees.EventMonthly:  entity.TransactionMaintenanceFee,
+}
+
+// SetFeeConfig sets the fee rules. Without them nothing is charged.
+func (s *AccountService) SetFeeConfig(config *fees.Config) {
+	s.fees = config
+}
+
+// operationFees works out the fees an operation on an account incurs, given
+// the balance before it.
+func (s *AccountService) operationFees(event string, account *entity.Account, amount, balance int64) []fees.Fee {
+	return s.fees.Fees(event, account.ProductType, account.Currency, amount, balance)
+}
+
+// chargeFees debits the fees an operation incurred. Every fee is a journal
+// entry of its own that credits the fee income account, so it can be
+// refunded by reversing it without touching the operation.
+func chargeFees(ctx context.Context, repo repository.AccountRepository, account *entity.Account, charged []fees.Fee, trigger *entity.Transaction) error {
+	for _, fee := range charged {
+		if _, err := chargeFee(ctx, repo, account, fee, &trigger.ID, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chargeFee debits one fee and returns the ID of the fee transaction. The
+// transaction points at the one that incurred the fee, if any, and carries
+// the rule name as its reference.
+func chargeFee(ctx context.Context, repo repository.AccountRepository, account *entity.Account, fee fees.Fee, trigger *uint, period string) (uint, error) {
+	amount := entity.Money{Units: fee.Amount, Currency: account.Currency}
+	ledger := NewLedger(repo)
+	customerLedger, err := ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	income, err := ledger.SystemAccount(ctx, entity.LedgerFees, account.Currency)
+	if err != nil {
+		return 0, err
+	}
+	journal, err := ledger.Move(ctx, "fee "+fee.Rule, customerLedger, income, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := entity.Transaction{
+		CustomerID:          account.CustomerID,
+		AccountID:           account.ID,
+		JournalEntryID:      journal.ID,
+		LinkedTransactionID: trigger,
+		Type:                feeTransactionTypes[fee.Event],
+		Amount:              fee.Amount,
+		Currency:            account.Currency,
+		Reference:           fee.Rule,
+		Date:                journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, account, &transaction); err != nil {
+		return 0, err
+	}
+	event := events.FeeCharged{
+		AccountNumber: account.Number,
+		TransactionID: transaction.ID,
+		Rule:          fee.Rule,
+		Period:        period,
+		Amount:        events.NewAmount(amount),
+		OccurredAt:    journal.Date,
+	}
+	if trigger != nil {
+		event.TriggerTransactionID = *trigger
+	}
+	return transaction.ID, recordEvent(ctx, repo, event)
+}
+
+// ChargeMaintenanceFees applies the monthly fee rules to every account of
+// their products for the month before the month of now, in UTC, and returns
+// how many fees it charged. A rule is applied once per account and month,
+// however often this runs; it is waived when the balance at the end of the
+// month reaches the rule's minimum. Accounts opened after the month are
+// skipped. Like overdraft interest, the fee may take the balance past the
+// overdraft limit.
+func (s *AccountService) ChargeMaintenanceFees(ctx context.Context, now time.Time) (int, error) {
+	day := startOfDay(now)
+	monthEnd := day.AddDate(0, 0, 1-day.Day())
+	period := monthEnd.AddDate(0, -1, 0).Format("2006-01")
+
+	productTypes := s.fees.MonthlyProductTypes()
+	if len(productTypes) == 0 {
+		return 0, nil
+	}
+	charged := 0
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListAccountsByProduct(ctx, productTypes, afterID, interestBatchSize)
+		if err != nil {
+			return charged, err
+		}
+		for i := range accounts {
+			if err := ctx.Err(); err != nil {
+				return charged, err
+			}
+			n, err := s.chargeMonthlyFees(ctx, accounts[i].ID, period, monthEnd)
+			charged += n
+			if err != nil {
+				return charged, err
+			}
+		}
+		if len(accounts) < interestBatchSize {
+			return charged, nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (s *AccountService) chargeMonthlyFees(ctx context.Context, accountID uint, period string, monthEnd time.Time) (int, error) {
+	charged := 0
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		charged = 0
+		account, err := repo.GetAccountByID(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		ledger := NewLedger(repo)
+		customerLedger, err := ledger.CustomerAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		first, ok, err := repo.FirstPostingDate(ctx, customerLedger.ID)
+		if err != nil || !ok || !first.Before(monthEnd) {
+			return err
+		}
+		balance, err := ledger.BalanceBefore(ctx, customerLedger, monthEnd)
+		if err != nil {
+			return err
+		}
+
+		due := s.fees.Fees(fees.EventMonthly, account.ProductType, account.Currency, 0, balance)
+		for _, rule := range s.fees.MonthlyRules(account.ProductType, account.Currency) {
+			done, err := repo.HasFeeCharge(ctx, account.ID, rule, period)
+			if err != nil {
+				return err
+			}
+			if done {
+				continue
+			}
+			record := &entity.FeeCharge{AccountID: account.ID, Rule: rule, Period: period, Waived: true}
+			for _, fee := range due {
+				if fee.Rule != rule {
+					continue
+				}
+				id, err := chargeFee(ctx, repo, account, fee, nil, period)
+				if err != nil {
+					return err
+				}
+				record.TransactionID, record.Waived = &id, false
+				charged++
+			}
+			if err := repo.CreateFeeCharge(ctx, record); err != nil {
+				return err
+			}
+		}
+		if charged == 0 {
+			return nil
+		}
+		return repo.BumpAccountVersion(ctx, account)
+	})
+	return charged, err
+}
+
+// RunFees charges the monthly fees every interval until ctx is cancelled.
+func (s *AccountService) RunFees(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ChargeMaintenanceFees(ctx, time.Now()); err != nil {
+			log.Printf("maintenance fees: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+// Page sizes of TransactionHistory, and of the batches StreamTransactions
+// reads.
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 500
+	streamBatchSize     = 500
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidDate   = errors.New("dates must be RFC 3339 times or YYYY-MM-DD")
+	ErrInvalidLimit  = errors.New("limit must not be negative")
+)
+
+// TransactionHistory returns a page of the transactions of one account, or
+// of all the customer's accounts when no account number is given. It reads
+// the history projection.
+func (s *AccountService) TransactionHistory(ctx context.Context, req *pb.TransactionHistoryRequest) (*pb.TransactionHistoryResponse, error) {
+	filter, numbers, err := s.historyFilter(ctx, req)
+	if err != nil {
+		return &pb.TransactionHistoryResponse{Transactions: nil, Message: failureMessage(err, "no transactions found")}, nil
+	}
+
+	limit := filter.Limit
+	// One more than asked for tells whether there is another page.
+	filter.Limit++
+	transactions, err := s.repo.ListHistory(ctx, filter)
+	if err != nil {
+		return &pb.TransactionHistoryResponse{Transactions: nil, Message: "no transactions found"}, nil
+	}
+
+	var nextCursor string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		nextCursor = encodeCursor(transactions[limit-1].TransactionID)
+	}
+
+	var grpcTransactions []*pb.Transaction
+	for i := range transactions {
+		grpcTransactions = append(grpcTransactions, historyToProto(&transactions[i], numbers))
+	}
+
+	return &pb.TransactionHistoryResponse{
+		Transactions: grpcTransactions,
+		Message:      "transaction history retrieved",
+		NextCursor:   nextCursor,
+	}, nil
+}
+
+// StreamTransactions sends every transaction matching the request to send,
+// oldest first. Only one batch is held in memory at a time. It stops with
+// the context's error once ctx is done, e.g. because the client went away,
+// and with send's error when sending fails.
+func (s *AccountService) StreamTransactions(ctx context.Context, req *pb.StreamTransactionsRequest, send func(*pb.Transaction) error) error {
+	filter, numbers, err := s.historyFilter(ctx, &pb.TransactionHistoryRequest{
+		Customerid:    req.Customerid,
+		AccountNumber: req.AccountNumber,
+		From:          req.From,
+		To:            req.To,
+		Type:          req.Type,
+	})
+	if err != nil {
+		return err
+	}
+	filter.Limit = streamBatchSize
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		transactions, err := s.repo.ListHistory(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for i := range transactions {
+			if err := send(historyToProto(&transactions[i], numbers)); err != nil {
+				return err
+			}
+		}
+		if len(transactions) < filter.Limit {
+			return nil
+		}
+		filter.AfterID = transactions[len(transactions)-1].TransactionID
+	}
+}
+
+// IsInvalidRequest reports whether err was caused by the request rather
+// than by the service, for callers that must map errors to status codes. A
+// concurrent update is not: the same request may succeed when retried.
+func IsInvalidRequest(err error) bool {
+	return failureMessage(err, "") != "" && !errors.Is(err, repository.ErrConcurrentUpdate)
+}
+
+// historyFilter validates a history request and turns it into a repository
+// filter. It also returns the numbers of the customer's accounts by ID.
+func (s *AccountService) historyFilter(ctx context.Context, req *pb.TransactionHistoryRequest) (repository.TransactionFilter, map[uint]string, error) {
+	filter := repository.TransactionFilter{CustomerID: uint(req.Customerid)}
+
+	accounts, err := s.repo.ListAccountsByCustomerID(ctx, uint(req.Customerid))
+	if err != nil {
+		return filter, nil, err
+	}
+	numbers := make(map[uint]string, len(accounts))
+	for _, account := range accounts {
+		numbers[account.ID] = account.Number
+	}
+
+	if req.AccountNumber != "" {
+		account, err := ownedAccount(ctx, s.repo, req.Customerid, req.AccountNumber)
+		if err != nil {
+			return filter, nil, err
+		}
+		filter.AccountID = account.ID
+	}
+
+	switch {
+	case req.Limit < 0:
+		return filter, nil, ErrInvalidLimit
+	case req.Limit == 0:
+		filter.Limit = DefaultHistoryLimit
+	case req.Limit > MaxHistoryLimit:
+		filter.Limit = MaxHistoryLimit
+	default:
+		filter.Limit = int(req.Limit)
+	}
+
+	if req.Cursor != "" {
+		if filter.AfterID, err = decodeCursor(req.Cursor); err != nil {
+			return filter, nil, err
+		}
+	}
+	if filter.From, err = parseHistoryTime(req.From, false); err != nil {
+		return filter, nil, err
+	}
+	if filter.To, err = parseHistoryTime(req.To, true); err != nil {
+		return filter, nil, err
+	}
+	for _, t := range strings.Split(req.Type, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	return filter, numbers, nil
+}
+
+// parseHistoryTime accepts an RFC 3339 time or a date. A date used as the
+// end of a range includes the whole day.
+func parseHistoryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// Cursors are opaque to clients; they carry the ID of the last transaction
+// of a page.
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return uint(id), nil
+}
+
+func historyToProto(t *entity.HistoryEntry, numbers map[uint]string) *pb.Transaction {
+	return &pb.Transaction{
+		Id:                      uint32(t.TransactionID),
+		Customerid:              uint32(t.CustomerID),
+		Type:                    t.Type,
+		Amount:                  moneyToProto(t.Money()),
+		Date:                    t.Date.Format(time.RFC3339),
+		LinkedTransactionId:     linkedID(t.LinkedTransactionID),
+		Reference:               t.Reference,
+		AccountNumber:           numbers[t.AccountID],
+		ReversesTransactionId:   linkedID(t.ReversesTransactionID),
+		ReversedByTransactionId: linkedID(t.ReversedByTransactionID),
+		ReasonCode:              t.ReasonCode,
+		Actor:                   t.Actor,
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+// DefaultHoldLifetime applies when a hold is placed without an expiry.
+const DefaultHoldLifetime = 7 * 24 * time.Hour
+
+var ErrHoldNotFound = errors.New("hold not found")
+
+// PlaceHold reserves funds on an account. The amount must be available and
+// within the withdrawal limits, and stays reserved until the hold is
+// captured, released or expires. Until then the hold counts against the
+// limits; once captured, the capture does.
+func (s *AccountService) PlaceHold(ctx context.Context, req *pb.PlaceHoldRequest) (*pb.PlaceHoldResponse, error) {
+	amount, err := moneyFromProto(req.Amount)
+	if err != nil {
+		return &pb.PlaceHoldResponse{Success: false, Message: err.Error()}, nil
+	}
+	lifetime := DefaultHoldLifetime
+	if req.ExpiresInSeconds > 0 {
+		lifetime = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	var hold entity.Hold
+	err = s.inTx(ctx, func(repo repository.AccountRepository) error {
+		account, err := ownedAccount(ctx, repo, req.Customerid, req.AccountNumber)
+		if err != nil {
+			return err
+		}
+		if err := account.CanDebit(); err != nil {
+			return err
+		}
+		if account.Currency != amount.Currency {
+			return entity.ErrCurrencyMismatch
+		}
+
+		now := time.Now()
+		if err := checkWithdrawalLimits(ctx, repo, account, amount.Units, now); err != nil {
+			return err
+		}
+		if _, err := checkFunds(ctx, repo, account, amount.Units); err != nil {
+			return err
+		}
+
+		hold = entity.Hold{
+			AccountID: account.ID,
+			Amount:    amount.Units,
+			Currency:  amount.Currency,
+			Reference: req.Reference,
+			Status:    entity.HoldActive,
+			ExpiresAt: now.Add(lifetime),
+		}
+		if err := repo.CreateHold(ctx, &hold); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, repo, events.HoldPlaced{
+			AccountNumber: account.Number,
+			HoldID:        hold.ID,
+			Amount:        events.NewAmount(amount),
+			Reference:     hold.Reference,
+			ExpiresAt:     hold.ExpiresAt,
+			OccurredAt:    now,
+		}); err != nil {
+			return err
+		}
+		return repo.BumpAccountVersion(ctx, account)
+	})
+	if err != nil {
+		return &pb.PlaceHoldResponse{Success: false, Message: failureMessage(err, "placing hold failed")}, nil
+	}
+
+	return &pb.PlaceHoldResponse{
+		Success:   true,
+		Message:   "hold placed",
+		HoldId:    uint32(hold.ID),
+		ExpiresAt: hold.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// CaptureHold settles a hold: the captured amount leaves the account like a
+// withdrawal and the rest of the hold, if any, is released.
+func (s *AccountService) CaptureHold(ctx context.Context, req *pb.CaptureHoldRequest) (*pb.CaptureHoldResponse, error) {
+	var transaction entity.Transaction
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		ledger := NewLedger(repo)
+
+		hold, account, err := ownedHold(ctx, repo, req.Customerid, req.HoldId)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !hold.ActiveAt(now) {
+			return entity.ErrHoldNotActive
+		}
+		if err := account.CanDebit(); err != nil {
+			return err
+		}
+
+		amount := hold.Money()
+		if req.Amount != nil {
+			captured, err := moneyFromProto(req.Amount)
+			if err != nil {
+				return err
+			}
+			if captured.Currency != hold.Currency {
+				return entity.ErrCurrencyMismatch
+			}
+			if captured.Units > hold.Amount {
+				return entity.ErrCaptureTooLarge
+			}
+			amount = captured
+		}
+
+		customerLedger, err := ledger.CustomerAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		cash, err := ledger.SystemAccount(ctx, entity.LedgerCash, amount.Currency)
+		if err != nil {
+			return err
+		}
+		before, err := ledger.Balance(ctx, customerLedger)
+		if err != nil {
+			return err
+		}
+		journal, err := ledger.Move(ctx, "hold capture", customerLedger, cash, amount)
+		if err != nil {
+			return err
+		}
+
+		transaction = entity.Transaction{
+			CustomerID:     account.CustomerID,
+			AccountID:      account.ID,
+			JournalEntryID: journal.ID,
+			Type:           entity.TransactionHoldCapture,
+			Amount:         amount.Units,
+			Currency:       amount.Currency,
+			Reference:      hold.Reference,
+			Date:           journal.Date,
+		}
+		if err := bookTransaction(ctx, repo, account, &transaction); err != nil {
+			return err
+		}
+
+		// The hold may have been placed in the overdraft.
+		if err := noteOverdrawn(ctx, repo, account, before, amount.Units, transaction.ID, journal.Date); err != nil {
+			return err
+		}
+
+		hold.Status = entity.HoldCaptured
+		hold.TransactionID = &transaction.ID
+		if err := repo.UpdateHold(ctx, hold); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, repo, events.HoldCaptured{
+			AccountNumber: account.Number,
+			HoldID:        hold.ID,
+			TransactionID: transaction.ID,
+			Amount:        events.NewAmount(amount),
+			OccurredAt:    journal.Date,
+		}); err != nil {
+			return err
+		}
+		return repo.BumpAccountVersion(ctx, account)
+	})
+	if err != nil {
+		return &pb.CaptureHoldResponse{Success: false, Message: failureMessage(err, "capturing hold failed")}, nil
+	}
+
+	return &pb.CaptureHoldResponse{Success: true, Message: "hold captured", TransactionId: uint32(transaction.ID)}, nil
+}
+
+// ReleaseHold gives the reserved funds back without moving money.
+func (s *AccountService) ReleaseHold(ctx context.Context, req *pb.ReleaseHoldRequest) (*pb.ReleaseHoldResponse, error) {
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		hold, account, err := ownedHold(ctx, repo, req.Customerid, req.HoldId)
+		if err != nil {
+			return err
+		}
+		if !hold.ActiveAt(time.Now()) {
+			return entity.ErrHoldNotActive
+		}
+		return endHold(ctx, repo, hold, account, entity.HoldReleased)
+	})
+	if err != nil {
+		return &pb.ReleaseHoldResponse{Success: false, Message: failureMessage(err, "releasing hold failed")}, nil
+	}
+
+	return &pb.ReleaseHoldResponse{Success: true, Message: "hold released"}, nil
+}
+
+// ExpireHolds marks the active holds whose expiry has passed by now as
+// expired and returns how many there were. Expired holds stop counting
+// against the available balance as soon as their expiry passes; this only
+// records the fact.
+func (s *AccountService) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		holds, err := s.repo.GetExpiredHolds(ctx, now, 100)
+		if err != nil {
+			return expired, err
+		}
+		if len(holds) == 0 {
+			return expired, nil
+		}
+		for i := range holds {
+			var ended bool
+			err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+				ended = false
+				account, err := repo.GetAccountByID(ctx, holds[i].AccountID)
+				if err != nil {
+					return err
+				}
+				// The hold may have been captured or released since it was listed.
+				hold, err := repo.GetHold(ctx, holds[i].ID)
+				if err != nil || hold.Status != entity.HoldActive {
+					return err
+				}
+				ended = true
+				return endHold(ctx, repo, hold, account, entity.HoldExpired)
+			})
+			if err != nil {
+				return expired, err
+			}
+			if ended {
+				expired++
+			}
+		}
+	}
+}
+
+// RunHoldExpiry expires stale holds every interval until ctx is cancelled.
+func (s *AccountService) RunHoldExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireHolds(ctx, time.Now()); err != nil {
+			log.Printf("hold expiry: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// endHold releases or expires a hold. Like every other change to a hold it
+// bumps the account version, so that it cannot race with a capture.
+func endHold(ctx context.Context, repo repository.AccountRepository, hold *entity.Hold, account *entity.Account, status string) error {
+	hold.Status = status
+	if err := repo.UpdateHold(ctx, hold); err != nil {
+		return err
+	}
+	if err := recordEvent(ctx, repo, events.HoldReleased{
+		AccountNumber: account.Number,
+		HoldID:        hold.ID,
+		Amount:        events.NewAmount(hold.Money()),
+		Status:        status,
+		OccurredAt:    time.Now(),
+	}); err != nil {
+		return err
+	}
+	return repo.BumpAccountVersion(ctx, account)
+}
+
+// ownedHold loads a hold and the account it is placed on, making sure the
+// account belongs to the customer. The hold is read again after the account
+// so that any change to it committed after the account version was read
+// makes the caller's version bump fail.
+func ownedHold(ctx context.Context, repo repository.AccountRepository, customerID uint32, holdID uint32) (*entity.Hold, *entity.Account, error) {
+	hold, err := repo.GetHold(ctx, uint(holdID))
+	if err != nil {
+		return nil, nil, ErrHoldNotFound
+	}
+	account, err := repo.GetAccountByID(ctx, hold.AccountID)
+	if err != nil || account.CustomerID != uint(customerID) {
+		return nil, nil, ErrHoldNotFound
+	}
+	hold, err = repo.GetHold(ctx, uint(holdID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return hold, account, nil
+}
+
+// balances returns the balance of an account, from its projection, and the
+// part of it that is not reserved by holds active at now.
+func balances(ctx context.Context, repo repository.AccountRepository, account *entity.Account, now time.Time) (int64, int64, error) {
+	balance, err := projectedBalance(ctx, repo, account)
+	if err != nil {
+		return 0, 0, err
+	}
+	held, err := repo.SumActiveHolds(ctx, account.ID, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	return balance, balance - held, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrRequestIDReused  = errors.New("request_id was already used for a different request")
+	ErrRequestIDTooLong = errors.New("request_id is too long")
+)
+
+// idempotentRequest is a request that may carry a request ID.
+type idempotentRequest interface {
+	proto.Message
+	GetRequestId() string
+}
+
+// replayResponse looks up the request ID of req among those of the customer
+// making it. When a request was already answered under it, res is filled
+// with that answer and replayResponse returns true; it fails with
+// ErrRequestIDReused when that request was a different one.
+func replayResponse(ctx context.Context, repo repository.AccountRepository, customerID uint32, req idempotentRequest, res proto.Message) (bool, error) {
+	requestID := req.GetRequestId()
+	if requestID == "" {
+		return false, nil
+	}
+	if len(requestID) > entity.MaxRequestIDLength {
+		return false, ErrRequestIDTooLong
+	}
+	key, found, err := repo.GetIdempotencyKey(ctx, uint(customerID), requestID)
+	if err != nil || !found {
+		return false, err
+	}
+	operation, hash, err := fingerprint(req)
+	if err != nil {
+		return false, err
+	}
+	if key.Operation != operation || key.RequestHash != hash {
+		return false, ErrRequestIDReused
+	}
+	return true, proto.Unmarshal(key.Response, res)
+}
+
+// rememberResponse stores res, the answer req gets if it succeeds, under its
+// request ID and the customer making it. It is the first write of the unit
+// of work that books req: the answer is kept if and only if the booking
+// commits, and a concurrent request with the same ID waits for that and then
+// fails on the unique index instead of booking too.
+func rememberResponse(ctx context.Context, repo repository.AccountRepository, customerID uint32, req idempotentRequest, res proto.Message) error {
+	if req.GetRequestId() == "" {
+		return nil
+	}
+	operation, hash, err := fingerprint(req)
+	if err != nil {
+		return err
+	}
+	response, err := proto.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return repo.CreateIdempotencyKey(ctx, &entity.IdempotencyKey{
+		CustomerID:  uint(customerID),
+		RequestID:   req.GetRequestId(),
+		Operation:   operation,
+		RequestHash: hash,
+		Response:    response,
+	})
+}
+
+// fingerprint names the kind of request and hashes everything in it but the
+// request ID.
+func fingerprint(req idempotentRequest) (string, string, error) {
+	clone := proto.Clone(req)
+	message := clone.ProtoReflect()
+	message.Clear(message.Descriptor().Fields().ByName("request_id"))
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(clone)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(message.Descriptor().FullName()), hex.EncodeToString(sum[:]), nil
+}
+
+// replayAfterFailure checks, once booking req failed, whether a concurrent
+// request with the same request ID was booked first, and answers like
+// replayResponse if so. Otherwise it returns err.
+func replayAfterFailure(ctx context.Context, repo repository.AccountRepository, customerID uint32, req idempotentRequest, res proto.Message, err error) (bool, error) {
+	if req.GetRequestId() == "" {
+		return false, err
+	}
+	found, replayErr := replayResponse(ctx, repo, customerID, req, res)
+	if found || errors.Is(replayErr, ErrRequestIDReused) {
+		return found, replayErr
+	}
+	return false, err
+}
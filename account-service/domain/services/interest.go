@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/interest"
+)
+
+const interestBatchSize = 100
+
+// SetInterestConfig sets the rate schedules interest is accrued with. Without
+// one no account earns interest; overdraft interest is set per account and
+// charged regardless.
+func (s *AccountService) SetInterestConfig(config *interest.Config) {
+	s.interest = config
+}
+
+// AccrueInterest records the interest every eligible account earned, and the
+// overdraft interest it was charged, on each day before the day of now, in
+// UTC, and returns how many account days it accrued. Each account continues
+// from the day after its last accrual, so days missed while the service was
+// down are caught up with the balance and the rates of the day, not today's.
+func (s *AccountService) AccrueInterest(ctx context.Context, now time.Time) (int, error) {
+	today := startOfDay(now)
+	accrued := 0
+	err := s.eachInterestAccount(ctx, func(account *entity.Account, product *interest.Product) error {
+		n, err := s.accrueAccount(ctx, account, product, today)
+		accrued += n
+		return err
+	})
+	return accrued, err
+}
+
+func (s *AccountService) accrueAccount(ctx context.Context, account *entity.Account, product *interest.Product, today time.Time) (int, error) {
+	customerLedger, err := s.ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	last, ok, err := s.repo.LastInterestAccrualDate(ctx, account.ID)
+	if err != nil {
+		return 0, err
+	}
+	day := last.UTC().AddDate(0, 0, 1)
+	if !ok {
+		first, ok, err := s.repo.FirstPostingDate(ctx, customerLedger.ID)
+		if err != nil || !ok {
+			return 0, err
+		}
+		day = startOfDay(first)
+		if product != nil && account.OverdraftRateBP == 0 && day.Before(product.Start()) {
+			day = product.Start()
+		}
+	}
+
+	accrued := 0
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return accrued, err
+		}
+		// The balance at the end of the day earns the day's interest.
+		balance, err := s.ledger.BalanceBefore(ctx, customerLedger, day.AddDate(0, 0, 1))
+		if err != nil {
+			return accrued, err
+		}
+		err = s.repo.CreateInterestAccrual(ctx, &entity.InterestAccrual{
+			AccountID:       account.ID,
+			Date:            day,
+			Balance:         balance,
+			Micros:          product.DailyAccrual(balance, day),
+			OverdraftMicros: interest.DailyOverdraftCharge(balance, account.OverdraftRateBP),
+			Currency:        account.Currency,
+		})
+		if err != nil {
+			return accrued, err
+		}
+		accrued++
+	}
+	return accrued, nil
+}
+
+// PostInterest pays the interest accrued in months before the month of now
+// into the accounts, charges the overdraft interest, and returns how many
+// months it posted something for. Each month is posted separately. Accrued
+// amounts are truncated to minor units; what is left over is carried into
+// the next month. Withholding tax, if the product has any, is deducted as a
+// separate transaction.
+func (s *AccountService) PostInterest(ctx context.Context, now time.Time) (int, error) {
+	day := startOfDay(now)
+	monthStart := day.AddDate(0, 0, 1-day.Day())
+	posted := 0
+	err := s.eachInterestAccount(ctx, func(account *entity.Account, product *interest.Product) error {
+		return s.inTx(ctx, func(repo repository.AccountRepository) error {
+			account, err := repo.GetAccountByID(ctx, account.ID)
+			if err != nil {
+				return err
+			}
+			accruals, err := repo.GetUnpostedInterestAccruals(ctx, account.ID, monthStart)
+			if err != nil || len(accruals) == 0 {
+				return err
+			}
+			var carried interestRemainder
+			if last, ok, err := repo.LastPostedInterestAccrual(ctx, account.ID); err != nil {
+				return err
+			} else if ok {
+				carried = interestRemainder{micros: last.RemainderMicros, overdraftMicros: last.OverdraftRemainderMicros}
+			}
+
+			n := 0
+			for len(accruals) > 0 {
+				month := accruals[0].Date.UTC().Format("2006-01")
+				end := 1
+				for end < len(accruals) && accruals[end].Date.UTC().Format("2006-01") == month {
+					end++
+				}
+				ok, err := postMonthInterest(ctx, repo, account, product, month, accruals[:end], &carried, now)
+				if err != nil {
+					return err
+				}
+				if ok {
+					n++
+				}
+				accruals = accruals[end:]
+			}
+			if err := repo.BumpAccountVersion(ctx, account); err != nil {
+				return err
+			}
+			posted += n
+			return nil
+		})
+	})
+	return posted, err
+}
+
+// interestRemainder is accrued interest and overdraft interest, in micros,
+// that came to less than one minor unit when its month was posted.
+type interestRemainder struct {
+	micros, overdraftMicros int64
+}
+
+// hasUnpostedInterest reports whether the accruals of an account not posted
+// yet, with the remainder carried from the last posting, come to at least
+// one minor unit of interest or overdraft interest.
+func hasUnpostedInterest(ctx context.Context, repo repository.AccountRepository, accountID uint, now time.Time) (bool, error) {
+	accruals, err := repo.GetUnpostedInterestAccruals(ctx, accountID, now)
+	if err != nil || len(accruals) == 0 {
+		return false, err
+	}
+	var micros, overdraftMicros int64
+	if last, ok, err := repo.LastPostedInterestAccrual(ctx, accountID); err != nil {
+		return false, err
+	} else if ok {
+		micros, overdraftMicros = last.RemainderMicros, last.OverdraftRemainderMicros
+	}
+	for _, accrual := range accruals {
+		micros += accrual.Micros
+		overdraftMicros += accrual.OverdraftMicros
+	}
+	return micros >= interest.MicrosPerUnit || overdraftMicros >= interest.MicrosPerUnit, nil
+}
+
+// postMonthInterest posts the accruals of one month, plus the remainder
+// carried from the month before, and reports whether they came to at least
+// one minor unit. It replaces the remainder with what this month leaves over.
+func postMonthInterest(ctx context.Context, repo repository.AccountRepository, account *entity.Account, product *interest.Product, month string, accruals []entity.InterestAccrual, carried *interestRemainder, now time.Time) (bool, error) {
+	ids := make([]uint, len(accruals))
+	micros, overdraftMicros := carried.micros, carried.overdraftMicros
+	for i, accrual := range accruals {
+		ids[i] = accrual.ID
+		micros += accrual.Micros
+		overdraftMicros += accrual.OverdraftMicros
+	}
+	gross := micros / interest.MicrosPerUnit
+	charge := overdraftMicros / interest.MicrosPerUnit
+	*carried = interestRemainder{micros: micros % interest.MicrosPerUnit, overdraftMicros: overdraftMicros % interest.MicrosPerUnit}
+
+	var transactionID *uint
+	if gross > 0 {
+		id, err := creditInterest(ctx, repo, account, product, month, gross)
+		if err != nil {
+			return false, err
+		}
+		transactionID = &id
+	}
+	if charge > 0 {
+		id, err := chargeOverdraftInterest(ctx, repo, account, month, charge)
+		if err != nil {
+			return false, err
+		}
+		if transactionID == nil {
+			transactionID = &id
+		}
+	}
+	if err := repo.MarkInterestAccrualsPosted(ctx, ids, now, transactionID); err != nil {
+		return false, err
+	}
+	if err := repo.SetInterestRemainder(ctx, ids[len(ids)-1], carried.micros, carried.overdraftMicros); err != nil {
+		return false, err
+	}
+	return transactionID != nil, nil
+}
+
+// creditInterest pays a month's interest into an account, less withholding
+// tax, and returns the ID of the interest transaction.
+func creditInterest(ctx context.Context, repo repository.AccountRepository, account *entity.Account, product *interest.Product, month string, gross int64) (uint, error) {
+	tax := product.Tax(gross)
+
+	ledger := NewLedger(repo)
+	customerLedger, err := ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	expense, err := ledger.SystemAccount(ctx, entity.LedgerInterestExpense, account.Currency)
+	if err != nil {
+		return 0, err
+	}
+	// The customer is credited the interest net of tax; the tax is owed to
+	// the tax authority until it is paid over.
+	postings := []entity.Posting{{LedgerAccountID: expense.ID, Amount: gross, Currency: account.Currency}}
+	if net := gross - tax; net > 0 {
+		postings = append(postings, entity.Posting{LedgerAccountID: customerLedger.ID, Amount: -net, Currency: account.Currency})
+	}
+	if tax > 0 {
+		taxLedger, err := ledger.SystemAccount(ctx, entity.LedgerWithholdingTax, account.Currency)
+		if err != nil {
+			return 0, err
+		}
+		postings = append(postings, entity.Posting{LedgerAccountID: taxLedger.ID, Amount: -tax, Currency: account.Currency})
+	}
+	journal, err := ledger.Post(ctx, "interest "+month, postings...)
+	if err != nil {
+		return 0, err
+	}
+
+	credit := entity.Transaction{
+		CustomerID:     account.CustomerID,
+		AccountID:      account.ID,
+		JournalEntryID: journal.ID,
+		Type:           entity.TransactionInterest,
+		Amount:         gross,
+		Currency:       account.Currency,
+		Reference:      "interest " + month,
+		Date:           journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, account, &credit); err != nil {
+		return 0, err
+	}
+	if tax > 0 {
+		debit := entity.Transaction{
+			CustomerID:          account.CustomerID,
+			AccountID:           account.ID,
+			JournalEntryID:      journal.ID,
+			LinkedTransactionID: &credit.ID,
+			Type:                entity.TransactionWithholdingTax,
+			Amount:              tax,
+			Currency:            account.Currency,
+			Reference:           "withholding tax on interest " + month,
+			Date:                journal.Date,
+		}
+		if err := bookTransaction(ctx, repo, account, &debit); err != nil {
+			return 0, err
+		}
+		if err := linkTransaction(ctx, repo, account, &credit, debit.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return credit.ID, recordEvent(ctx, repo, events.InterestPosted{
+		AccountNumber: account.Number,
+		TransactionID: credit.ID,
+		Period:        month,
+		Amount:        events.NewAmount(entity.Money{Units: gross, Currency: account.Currency}),
+		Tax:           events.NewAmount(entity.Money{Units: tax, Currency: account.Currency}),
+		OccurredAt:    journal.Date,
+	})
+}
+
+// chargeOverdraftInterest debits a month's overdraft interest from an account
+// and returns the ID of the charge. The charge may take the balance past the
+// overdraft limit.
+func chargeOverdraftInterest(ctx context.Context, repo repository.AccountRepository, account *entity.Account, month string, charge int64) (uint, error) {
+	amount := entity.Money{Units: charge, Currency: account.Currency}
+	ledger := NewLedger(repo)
+	customerLedger, err := ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	income, err := ledger.SystemAccount(ctx, entity.LedgerInterestIncome, account.Currency)
+	if err != nil {
+		return 0, err
+	}
+	journal, err := ledger.Move(ctx, "overdraft interest "+month, customerLedger, income, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := entity.Transaction{
+		CustomerID:     account.CustomerID,
+		AccountID:      account.ID,
+		JournalEntryID: journal.ID,
+		Type:           entity.TransactionOverdraftInterest,
+		Amount:         charge,
+		Currency:       account.Currency,
+		Reference:      "overdraft interest " + month,
+		Date:           journal.Date,
+	}
+	if err := bookTransaction(ctx, repo, account, &transaction); err != nil {
+		return 0, err
+	}
+	return transaction.ID, recordEvent(ctx, repo, events.OverdraftInterestCharged{
+		AccountNumber: account.Number,
+		TransactionID: transaction.ID,
+		Period:        month,
+		Amount:        events.NewAmount(amount),
+		OccurredAt:    journal.Date,
+	})
+}
+
+// RunInterest accrues and posts interest every interval until ctx is
+// cancelled.
+func (s *AccountService) RunInterest(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if _, err := s.AccrueInterest(ctx, now); err != nil {
+			log.Printf("interest accrual: %v", err)
+		} else if _, err := s.PostInterest(ctx, now); err != nil {
+			log.Printf("interest posting: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// eachInterestAccount calls fn for every account of a product that earns
+// interest or with an overdraft rate. The product is nil for accounts that
+// only pay overdraft interest.
+func (s *AccountService) eachInterestAccount(ctx context.Context, fn func(*entity.Account, *interest.Product) error) error {
+	productTypes := s.interest.ProductTypes()
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListInterestAccounts(ctx, productTypes, afterID, interestBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range accounts {
+			if err := fn(&accounts[i], s.interest.Product(accounts[i].ProductType)); err != nil {
+				return err
+			}
+		}
+		if len(accounts) < interestBatchSize {
+			return nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
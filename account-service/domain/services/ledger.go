@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+// systemAccountTypes lists the internal ledger accounts and their types. They
+// are created on first use.
+var systemAccountTypes = map[string]string{
+	entity.LedgerCash:            entity.LedgerTypeAsset,
+	entity.LedgerFees:            entity.LedgerTypeIncome,
+	entity.LedgerSuspense:        entity.LedgerTypeLiability,
+	entity.LedgerInterestExpense: entity.LedgerTypeExpense,
+	entity.LedgerWithholdingTax:  entity.LedgerTypeLiability,
+	entity.LedgerInterestIncome:  entity.LedgerTypeIncome,
+}
+
+// Ledger records money movements as balanced journal entries. Balances are
+// never stored; they are derived from the postings of a ledger account.
+type Ledger struct {
+	repo repository.AccountRepository
+}
+
+func NewLedger(repo repository.AccountRepository) *Ledger {
+	return &Ledger{repo: repo}
+}
+
+func customerLedgerCode(accountID uint) string {
+	return fmt.Sprintf("customer:%d", accountID)
+}
+
+// SystemAccount returns one of the bank's internal ledger accounts. There is
+// one per currency.
+func (l *Ledger) SystemAccount(ctx context.Context, code, currency string) (*entity.LedgerAccount, error) {
+	accountType, ok := systemAccountTypes[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown system account %q", code)
+	}
+	return l.findOrCreate(ctx, &entity.LedgerAccount{Code: code, Type: accountType, Currency: currency})
+}
+
+// CustomerAccount returns the ledger account that backs a customer account.
+func (l *Ledger) CustomerAccount(ctx context.Context, account *entity.Account) (*entity.LedgerAccount, error) {
+	return l.findOrCreate(ctx, &entity.LedgerAccount{
+		Code:      customerLedgerCode(account.ID),
+		Type:      entity.LedgerTypeLiability,
+		Currency:  account.Currency,
+		AccountID: &account.ID,
+	})
+}
+
+func (l *Ledger) findOrCreate(ctx context.Context, ledgerAccount *entity.LedgerAccount) (*entity.LedgerAccount, error) {
+	existing, err := l.repo.GetLedgerAccountByCode(ctx, ledgerAccount.Code, ledgerAccount.Currency)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := l.repo.CreateLedgerAccount(ctx, ledgerAccount); err != nil {
+		return nil, err
+	}
+	return ledgerAccount, nil
+}
+
+// Post records a journal entry made of the given postings. The entry is
+// rejected unless the postings sum to zero.
+func (l *Ledger) Post(ctx context.Context, description string, postings ...entity.Posting) (*entity.JournalEntry, error) {
+	journal := &entity.JournalEntry{
+		Description: description,
+		Date:        time.Now(),
+		Postings:    postings,
+	}
+	if err := journal.Validate(); err != nil {
+		return nil, err
+	}
+	if err := l.repo.CreateJournalEntry(ctx, journal); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// Move debits one ledger account and credits another with the same amount.
+// Both accounts must be kept in the currency of the amount.
+func (l *Ledger) Move(ctx context.Context, description string, debit, credit *entity.LedgerAccount, amount entity.Money) (*entity.JournalEntry, error) {
+	if debit.Currency != amount.Currency || credit.Currency != amount.Currency {
+		return nil, entity.ErrCurrencyMismatch
+	}
+	return l.Post(ctx, description,
+		entity.Posting{LedgerAccountID: debit.ID, Amount: amount.Units, Currency: amount.Currency},
+		entity.Posting{LedgerAccountID: credit.ID, Amount: -amount.Units, Currency: amount.Currency},
+	)
+}
+
+// Reverse posts a journal entry that undoes another one: every posting of
+// the original is repeated with the opposite sign.
+func (l *Ledger) Reverse(ctx context.Context, description string, journalEntryID uint) (*entity.JournalEntry, error) {
+	original, err := l.repo.GetPostingsByJournalEntryID(ctx, journalEntryID)
+	if err != nil {
+		return nil, err
+	}
+	postings := make([]entity.Posting, 0, len(original))
+	for _, posting := range original {
+		postings = append(postings, entity.Posting{
+			LedgerAccountID: posting.LedgerAccountID,
+			Amount:          -posting.Amount,
+			Currency:        posting.Currency,
+		})
+	}
+	return l.Post(ctx, description, postings...)
+}
+
+// Balance derives the balance of a ledger account from its postings.
+func (l *Ledger) Balance(ctx context.Context, ledgerAccount *entity.LedgerAccount) (int64, error) {
+	sum, err := l.repo.SumPostings(ctx, ledgerAccount.ID)
+	if err != nil {
+		return 0, err
+	}
+	return ledgerAccount.Balance(sum), nil
+}
+
+// BalanceBefore derives the balance of a ledger account from the postings of
+// journal entries dated before the given time.
+func (l *Ledger) BalanceBefore(ctx context.Context, ledgerAccount *entity.LedgerAccount, before time.Time) (int64, error) {
+	sum, err := l.repo.SumPostingsBefore(ctx, ledgerAccount.ID, before)
+	if err != nil {
+		return 0, err
+	}
+	return ledgerAccount.Balance(sum), nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/aggregate"
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+// DefaultDormancyMonths applies unless SetDormancyMonths says otherwise.
+const DefaultDormancyMonths = 12
+
+// dormancyActor is recorded as the actor of the changes the dormancy job
+// makes.
+const dormancyActor = "dormancy-job"
+
+// activityTypes are the transactions a customer makes. Interest, fees and
+// incoming transfers do not keep an account from becoming dormant.
+var activityTypes = []string{
+	entity.TransactionDeposit,
+	entity.TransactionWithdraw,
+	entity.TransactionTransferOut,
+	entity.TransactionHoldCapture,
+}
+
+var (
+	ErrReasonRequired   = errors.New("reason is required")
+	ErrBalanceNotZero   = errors.New("account balance must be zero to close it")
+	ErrHoldsOutstanding = errors.New("account has active holds")
+	ErrInterestUnposted = errors.New("account has interest that is not posted yet")
+)
+
+// SetDormancyMonths sets how many months without customer activity make an
+// account dormant.
+func (s *AccountService) SetDormancyMonths(months int) {
+	s.dormancyMonths = months
+}
+
+// ChangeAccountStatus moves an account to another status. Every change is
+// recorded with its reason and actor. Closing needs a zero balance and no
+// active holds, and cancels the standing orders paying out of the account.
+func (s *AccountService) ChangeAccountStatus(ctx context.Context, req *pb.ChangeAccountStatusRequest) (*pb.ChangeAccountStatusResponse, error) {
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return &pb.ChangeAccountStatusResponse{Success: false, Message: ErrActorRequired.Error()}, nil
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return &pb.ChangeAccountStatusResponse{Success: false, Message: ErrReasonRequired.Error()}, nil
+	}
+	if !entity.ValidAccountStatus(req.Status) {
+		return &pb.ChangeAccountStatusResponse{Success: false, Message: entity.ErrUnknownStatus.Error()}, nil
+	}
+
+	var account *entity.Account
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		var err error
+		account, err = repo.GetAccountByNumber(ctx, req.AccountNumber)
+		if err != nil {
+			return ErrAccountNotFound
+		}
+		return changeStatus(ctx, repo, account, req.Status, reason, actor, time.Now())
+	})
+	if err != nil {
+		return &pb.ChangeAccountStatusResponse{Success: false, Message: failureMessage(err, "changing account status failed")}, nil
+	}
+
+	balance, _, err := balances(ctx, s.repo, account, time.Now())
+	if err != nil {
+		return &pb.ChangeAccountStatusResponse{Success: false, Message: "failed to read balance"}, nil
+	}
+	return &pb.ChangeAccountStatusResponse{
+		Success: true,
+		Message: "account status changed",
+		Account: accountToProto(account, balance),
+	}, nil
+}
+
+// AccountStatusHistory returns the status changes of an account, oldest
+// first.
+func (s *AccountService) AccountStatusHistory(ctx context.Context, req *pb.AccountStatusHistoryRequest) (*pb.AccountStatusHistoryResponse, error) {
+	account, err := s.repo.GetAccountByNumber(ctx, req.AccountNumber)
+	if err != nil {
+		return &pb.AccountStatusHistoryResponse{Success: false, Message: ErrAccountNotFound.Error()}, nil
+	}
+	changes, err := s.repo.ListAccountStatusChanges(ctx, account.ID)
+	if err != nil {
+		return &pb.AccountStatusHistoryResponse{Success: false, Message: "failed to read status history"}, nil
+	}
+
+	res := &pb.AccountStatusHistoryResponse{Success: true, Message: "status history retrieved", Status: account.Status}
+	for _, change := range changes {
+		res.Changes = append(res.Changes, &pb.AccountStatusChange{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			Reason:     change.Reason,
+			Actor:      change.Actor,
+			ChangedAt:  change.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return res, nil
+}
+
+// FlagDormantAccounts marks dormant the active accounts that have seen no
+// customer activity for the dormancy period, and returns how many it marked.
+func (s *AccountService) FlagDormantAccounts(ctx context.Context, now time.Time) (int, error) {
+	if s.dormancyMonths <= 0 {
+		return 0, nil
+	}
+	since := now.AddDate(0, -s.dormancyMonths, 0)
+	reason := "no customer activity since " + since.UTC().Format(time.DateOnly)
+
+	flagged := 0
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListIdleAccounts(ctx, since, activityTypes, afterID, interestBatchSize)
+		if err != nil || len(accounts) == 0 {
+			return flagged, err
+		}
+		for _, idle := range accounts {
+			afterID = idle.ID
+			if err := ctx.Err(); err != nil {
+				return flagged, err
+			}
+			changed := false
+			err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+				account, err := repo.GetAccountByID(ctx, idle.ID)
+				if err != nil {
+					return err
+				}
+				// The status may have been changed since the account was
+				// listed.
+				if account.Status != entity.AccountActive {
+					return nil
+				}
+				changed = true
+				return changeStatus(ctx, repo, account, entity.AccountDormant, reason, dormancyActor, now)
+			})
+			if err != nil {
+				return flagged, err
+			}
+			if changed {
+				flagged++
+			}
+		}
+	}
+}
+
+// RunDormancy flags dormant accounts every interval until ctx is cancelled.
+func (s *AccountService) RunDormancy(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.FlagDormantAccounts(ctx, time.Now()); err != nil {
+			log.Printf("dormancy: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// changeStatus moves an account to status within a unit of work and records
+// the change. The version bump makes operations that read the old status
+// conflict with the change.
+func changeStatus(ctx context.Context, repo repository.AccountRepository, account *entity.Account, status, reason, actor string, now time.Time) error {
+	if !account.CanTransition(status) {
+		return entity.ErrInvalidTransition
+	}
+	if status == entity.AccountClosed {
+		if err := prepareClose(ctx, repo, account, now); err != nil {
+			return err
+		}
+	}
+
+	from := account.Status
+	if err := appendEvents(ctx, repo, account, now, &aggregate.StatusChanged{
+		From:   from,
+		To:     status,
+		Reason: reason,
+		Actor:  actor,
+	}); err != nil {
+		return err
+	}
+	if err := repo.CreateAccountStatusChange(ctx, &entity.AccountStatusChange{
+		AccountID:  account.ID,
+		FromStatus: from,
+		ToStatus:   status,
+		Reason:     reason,
+		Actor:      actor,
+		CreatedAt:  now,
+	}); err != nil {
+		return err
+	}
+	if err := recordEvent(ctx, repo, events.StatusChanged{
+		AccountNumber: account.Number,
+		From:          from,
+		To:            status,
+		Reason:        reason,
+		Actor:         actor,
+		OccurredAt:    now,
+	}); err != nil {
+		return err
+	}
+	return repo.BumpAccountVersion(ctx, account)
+}
+
+// prepareClose makes sure an account can be closed and cancels its active
+// standing orders. Interest accrued but not posted yet would be lost once
+// the account is closed, so it keeps the account open until it is posted.
+func prepareClose(ctx context.Context, repo repository.AccountRepository, account *entity.Account, now time.Time) error {
+	unposted, err := hasUnpostedInterest(ctx, repo, account.ID, now)
+	if err != nil {
+		return err
+	}
+	if unposted {
+		return ErrInterestUnposted
+	}
+	balance, available, err := balances(ctx, repo, account, now)
+	if err != nil {
+		return err
+	}
+	if balance != 0 {
+		return ErrBalanceNotZero
+	}
+	if available != balance {
+		return ErrHoldsOutstanding
+	}
+
+	orders, err := repo.ListStandingOrders(ctx, account.CustomerID, account.ID)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		order := &orders[i]
+		if order.Status != entity.StandingOrderActive {
+			continue
+		}
+		order.Status = entity.StandingOrderCancelled
+		if err := repo.UpdateStandingOrder(ctx, order); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, repo, events.StandingOrderCancelled{
+			AccountNumber:   account.Number,
+			StandingOrderID: order.ID,
+			OccurredAt:      now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+var (
+	ErrLimitExceeded      = errors.New("withdrawal limit exceeded")
+	ErrNegativeLimit      = errors.New("withdrawal limits must not be negative")
+	ErrLimitScopeRequired = errors.New("account number or customer id is required")
+	ErrInvalidExpiry      = errors.New("expires_at must be a future RFC 3339 time")
+)
+
+// LimitExceededError tells which limit a withdrawal would break. It wraps
+// ErrLimitExceeded.
+type LimitExceededError struct {
+	Reason   string
+	Scope    string
+	Limit    int64
+	Used     int64
+	Currency string
+	ResetsAt time.Time
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrLimitExceeded, e.Scope, e.Reason)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// SetWithdrawalLimit sets the withdrawal limits of an account or of a
+// customer, either as the standing policy or as an override until
+// expires_at.
+func (s *AccountService) SetWithdrawalLimit(ctx context.Context, req *pb.SetWithdrawalLimitRequest) (*pb.SetWithdrawalLimitResponse, error) {
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return &pb.SetWithdrawalLimitResponse{Success: false, Message: ErrActorRequired.Error()}, nil
+	}
+	if req.MaxSingle < 0 || req.MaxDaily < 0 || req.MaxMonthly < 0 || req.MaxPerHour < 0 {
+		return &pb.SetWithdrawalLimitResponse{Success: false, Message: ErrNegativeLimit.Error()}, nil
+	}
+	now := time.Now()
+	limit := &entity.WithdrawalLimit{
+		MaxSingle:  req.MaxSingle,
+		MaxDaily:   req.MaxDaily,
+		MaxMonthly: req.MaxMonthly,
+		MaxPerHour: req.MaxPerHour,
+		Actor:      actor,
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !expiresAt.After(now) {
+			return &pb.SetWithdrawalLimitResponse{Success: false, Message: ErrInvalidExpiry.Error()}, nil
+		}
+		limit.ExpiresAt = &expiresAt
+	}
+
+	err := s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		event := events.WithdrawalLimitSet{Actor: actor, OccurredAt: now}
+		switch {
+		case req.AccountNumber != "":
+			account, err := repo.GetAccountByNumber(ctx, req.AccountNumber)
+			if err != nil {
+				return ErrAccountNotFound
+			}
+			if req.Currency != "" && entity.NormalizeCurrency(req.Currency) != account.Currency {
+				return entity.ErrCurrencyMismatch
+			}
+			limit.AccountID = &account.ID
+			limit.CustomerID = account.CustomerID
+			limit.Currency = account.Currency
+			event.AccountNumber = account.Number
+		case req.Customerid != 0:
+			limit.CustomerID = uint(req.Customerid)
+			limit.Currency = entity.NormalizeCurrency(req.Currency)
+			if _, err := entity.CurrencyExponent(limit.Currency); err != nil {
+				return err
+			}
+		default:
+			return ErrLimitScopeRequired
+		}
+		if err := repo.CreateWithdrawalLimit(ctx, limit); err != nil {
+			return err
+		}
+
+		event.LimitID = limit.ID
+		event.CustomerID = limit.CustomerID
+		event.Currency = limit.Currency
+		event.MaxSingle = limit.MaxSingle
+		event.MaxDaily = limit.MaxDaily
+		event.MaxMonthly = limit.MaxMonthly
+		event.MaxPerHour = limit.MaxPerHour
+		event.ExpiresAt = limit.ExpiresAt
+		return recordEvent(ctx, repo, event)
+	})
+	if err != nil {
+		return &pb.SetWithdrawalLimitResponse{Success: false, Message: failureMessage(err, "setting withdrawal limit failed")}, nil
+	}
+
+	return &pb.SetWithdrawalLimitResponse{Success: true, Message: "withdrawal limit set", LimitId: uint32(limit.ID)}, nil
+}
+
+// checkWithdrawalLimits makes sure withdrawing amount from an account at now
+// keeps within the limits of the account and of its customer; outgoing
+// transfers and holds are checked like withdrawals. Each scope is governed by
+// its newest active override or, without one, its newest policy.
+// The version bump of the withdrawal serialises the withdrawals of one
+// account; for a customer limit, which withdrawals from any of the customer's
+// accounts count against, the customer's accounts are locked first.
+func checkWithdrawalLimits(ctx context.Context, repo repository.AccountRepository, account *entity.Account, amount int64, now time.Time) error {
+	limits, err := repo.GetWithdrawalLimits(ctx, account, now)
+	if err != nil {
+		return err
+	}
+	effective := make(map[string]*entity.WithdrawalLimit)
+	for i := range limits {
+		limit := &limits[i]
+		current := effective[limit.Scope()]
+		if current == nil || (limit.IsOverride() && !current.IsOverride()) {
+			effective[limit.Scope()] = limit
+		}
+	}
+
+	for _, scope := range []string{entity.LimitScopeAccount, entity.LimitScopeCustomer} {
+		limit := effective[scope]
+		if limit == nil {
+			continue
+		}
+		filter := repository.WithdrawalFilter{Currency: account.Currency, Now: now}
+		if scope == entity.LimitScopeAccount {
+			filter.AccountID = account.ID
+		} else {
+			if err := repo.LockCustomerAccounts(ctx, account.CustomerID); err != nil {
+				return err
+			}
+			filter.CustomerID = account.CustomerID
+		}
+		violation := &LimitExceededError{Scope: scope, Currency: account.Currency}
+
+		if limit.MaxSingle > 0 && amount > limit.MaxSingle {
+			violation.Reason, violation.Limit, violation.Used = entity.LimitSingleAmount, limit.MaxSingle, amount
+			return violation
+		}
+		if limit.MaxPerHour > 0 {
+			filter.Since = now.Add(-time.Hour)
+			usage, err := repo.SumWithdrawals(ctx, filter)
+			if err != nil {
+				return err
+			}
+			if usage.Count >= limit.MaxPerHour {
+				violation.Reason, violation.Limit, violation.Used = entity.LimitHourlyCount, limit.MaxPerHour, usage.Count
+				violation.Currency = ""
+				violation.ResetsAt = usage.First.Add(time.Hour)
+				return violation
+			}
+		}
+		day := startOfDay(now)
+		month := day.AddDate(0, 0, 1-day.Day())
+		for _, window := range []struct {
+			reason      string
+			max         int64
+			start, ends time.Time
+		}{
+			{entity.LimitDailyTotal, limit.MaxDaily, day, day.AddDate(0, 0, 1)},
+			{entity.LimitMonthlyTotal, limit.MaxMonthly, month, month.AddDate(0, 1, 0)},
+		} {
+			if window.max == 0 {
+				continue
+			}
+			filter.Since = window.start
+			usage, err := repo.SumWithdrawals(ctx, filter)
+			if err != nil {
+				return err
+			}
+			if usage.Total+amount > window.max {
+				violation.Reason, violation.Limit, violation.Used = window.reason, window.max, usage.Total
+				violation.ResetsAt = window.ends
+				return violation
+			}
+		}
+	}
+	return nil
+}
+
+// limitViolationToProto describes the limit err broke, or returns nil when
+// err is not a limit violation.
+func limitViolationToProto(err error) *pb.LimitViolation {
+	var violation *LimitExceededError
+	if !errors.As(err, &violation) {
+		return nil
+	}
+	res := &pb.LimitViolation{
+		Reason:   violation.Reason,
+		Scope:    violation.Scope,
+		Limit:    violation.Limit,
+		Used:     violation.Used,
+		Currency: violation.Currency,
+	}
+	if !violation.ResetsAt.IsZero() {
+		res.ResetsAt = violation.ResetsAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/events"
+)
+
+// OutboxRelay publishes the events written to the outbox. Messages are sent
+// in outbox order and at least once: a message whose publication failed, or
+// whose publication could not be recorded, is sent again on the next run.
+type OutboxRelay struct {
+	repo      repository.AccountRepository
+	publisher events.Publisher
+	batchSize int
+}
+
+func NewOutboxRelay(repo repository.AccountRepository, publisher events.Publisher) *OutboxRelay {
+	return &OutboxRelay{repo: repo, publisher: publisher, batchSize: 100}
+}
+
+// PublishPending publishes up to one batch of pending messages and returns
+// how many were published. It stops at the first failure so that later
+// events never overtake earlier ones.
+func (r *OutboxRelay) PublishPending(ctx context.Context) (int, error) {
+	messages, err := r.repo.GetPendingOutboxMessages(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, message := range messages {
+		err := r.publisher.Publish(ctx, events.Message{
+			ID:          message.ID,
+			Type:        message.EventType,
+			AggregateID: message.AggregateID,
+			Payload:     []byte(message.Payload),
+			OccurredAt:  message.CreatedAt,
+		})
+		if err != nil {
+			if markErr := r.repo.MarkOutboxMessageFailed(ctx, message.ID, err.Error()); markErr != nil {
+				log.Printf("outbox: record failure of message %d: %v", message.ID, markErr)
+			}
+			return i, err
+		}
+		if err := r.repo.MarkOutboxMessagePublished(ctx, message.ID, time.Now()); err != nil {
+			return i, err
+		}
+	}
+	return len(messages), nil
+}
+
+// Run publishes pending messages every interval until ctx is cancelled. A
+// full batch is followed immediately by the next one.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := r.PublishPending(ctx)
+		if err != nil {
+			log.Printf("outbox: %v", err)
+		}
+		if err == nil && published == r.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
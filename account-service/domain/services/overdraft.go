@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/aggregate"
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+var ErrNegativeOverdraft = errors.New("overdraft limit and rate must not be negative")
+
+// SetOverdraft arranges, changes or removes the overdraft of an account. A
+// limit below the current overdraft is allowed; the account then cannot be
+// debited until it is back within the limit.
+func (s *AccountService) SetOverdraft(ctx context.Context, req *pb.SetOverdraftRequest) (*pb.SetOverdraftResponse, error) {
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return &pb.SetOverdraftResponse{Success: false, Message: ErrActorRequired.Error()}, nil
+	}
+	if req.Limit == nil || req.Limit.Units < 0 || req.RateBp < 0 {
+		return &pb.SetOverdraftResponse{Success: false, Message: ErrNegativeOverdraft.Error()}, nil
+	}
+	currency := entity.NormalizeCurrency(req.Limit.Currency)
+	limit, err := entity.NewMoney(req.Limit.Units, currency)
+	if err != nil {
+		return &pb.SetOverdraftResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	err = s.inTx(ctx, func(repo repository.AccountRepository) error {
+		account, err := repo.GetAccountByNumber(ctx, req.AccountNumber)
+		if err != nil {
+			return ErrAccountNotFound
+		}
+		if account.Status == entity.AccountClosed {
+			return entity.ErrAccountClosed
+		}
+		if account.Currency != limit.Currency {
+			return entity.ErrCurrencyMismatch
+		}
+		if err := appendEvents(ctx, repo, account, time.Now(), &aggregate.OverdraftChanged{
+			Limit:  limit.Units,
+			RateBP: req.RateBp,
+			Actor:  actor,
+		}); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, repo, events.OverdraftSet{
+			AccountNumber: account.Number,
+			Limit:         events.NewAmount(limit),
+			RateBP:        req.RateBp,
+			Actor:         actor,
+			OccurredAt:    time.Now(),
+		}); err != nil {
+			return err
+		}
+		return repo.BumpAccountVersion(ctx, account)
+	})
+	if err != nil {
+		return &pb.SetOverdraftResponse{Success: false, Message: failureMessage(err, "setting overdraft failed")}, nil
+	}
+
+	return &pb.SetOverdraftResponse{Success: true, Message: "overdraft set"}, nil
+}
+
+// checkFunds makes sure an account can be debited amount: the available
+// balance plus the overdraft limit must cover it. It returns the ledger
+// balance before the debit.
+func checkFunds(ctx context.Context, repo repository.AccountRepository, account *entity.Account, amount int64) (int64, error) {
+	balance, available, err := balances(ctx, repo, account, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if available+account.OverdraftLimit < amount {
+		return 0, ErrInsufficientFunds
+	}
+	return balance, nil
+}
+
+// noteOverdrawn records an Overdrawn warning when a debit took the balance
+// of an account from zero or more to below zero.
+func noteOverdrawn(ctx context.Context, repo repository.AccountRepository, account *entity.Account, before, debit int64, transactionID uint, at time.Time) error {
+	after := before - debit
+	if before < 0 || after >= 0 {
+		return nil
+	}
+	return recordEvent(ctx, repo, events.Overdrawn{
+		AccountNumber:  account.Number,
+		TransactionID:  transactionID,
+		Balance:        events.NewAmount(entity.Money{Units: after, Currency: account.Currency}),
+		OverdraftLimit: events.NewAmount(entity.Money{Units: account.OverdraftLimit, Currency: account.Currency}),
+		OccurredAt:     at,
+	})
+}
+
+// overdraftHeadroom is how much of the overdraft is still unused, given the
+// available balance.
+func overdraftHeadroom(account *entity.Account, available int64) int64 {
+	if available >= 0 {
+		return account.OverdraftLimit
+	}
+	if headroom := account.OverdraftLimit + available; headroom > 0 {
+		return headroom
+	}
+	return 0
+}
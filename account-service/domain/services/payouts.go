@@ -0,0 +1,427 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/payout"
+	pb "github.com/m-dehghani/account-service/proto"
+	"gorm.io/gorm"
+)
+
+const (
+	// PayoutLineMaxAttempts is how often a line is tried before an internal
+	// error fails it.
+	PayoutLineMaxAttempts = 3
+
+	payoutBatchLimit = 10
+	payoutLineLimit  = 100
+)
+
+var (
+	ErrPayoutBatchNotFound = errors.New("payout batch not found")
+	ErrInvalidPayoutLines  = errors.New("payout file has invalid lines")
+)
+
+// SubmitPayoutBatch accepts a payout file to be paid from one of the
+// customer's accounts. Every line is validated before anything is stored:
+// a file with an invalid line is rejected as a whole, with the problem of
+// each such line. An accepted batch is executed in the background by
+// ExecutePayoutBatches.
+func (s *AccountService) SubmitPayoutBatch(ctx context.Context, req *pb.SubmitPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	var replayed pb.PayoutBatchResponse
+	if found, err := replayResponse(ctx, s.repo, req.Customerid, req, &replayed); err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: failureMessage(err, "submitting the payout batch failed")}, nil
+	} else if found {
+		return &replayed, nil
+	}
+	lines, problems, err := payout.Read(bytes.NewReader(req.Content), req.Format)
+	if err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	accountNumber := req.AccountNumber
+	if accountNumber == "" && len(lines) > 0 {
+		accountNumber = lines[0].FromAccountNumber
+	}
+	account, err := ownedAccount(ctx, s.repo, req.Customerid, accountNumber)
+	if err == nil {
+		err = account.CanDebit()
+	}
+	if err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: failureMessage(err, "submitting the payout batch failed")}, nil
+	}
+	problems, err = s.validatePayoutLines(ctx, account, lines, problems)
+	if err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: "submitting the payout batch failed"}, nil
+	}
+	if len(problems) > 0 {
+		res := &pb.PayoutBatchResponse{Success: false, Message: ErrInvalidPayoutLines.Error()}
+		for _, problem := range problems {
+			res.Errors = append(res.Errors, &pb.PayoutLineError{Line: int32(problem.Line), Message: problem.Message})
+		}
+		return res, nil
+	}
+
+	batch := &entity.PayoutBatch{
+		CustomerID: account.CustomerID,
+		AccountID:  account.ID,
+		Format:     req.Format,
+		Source:     req.Source,
+		Status:     entity.PayoutBatchPending,
+		Lines:      len(lines),
+		Currency:   account.Currency,
+	}
+	items := make([]entity.PayoutLine, len(lines))
+	for i, line := range lines {
+		batch.Total += line.Amount
+		items[i] = entity.PayoutLine{
+			Line:            line.Number,
+			Type:            line.Type,
+			ToAccountNumber: line.ToAccountNumber,
+			Name:            line.Name,
+			Amount:          line.Amount,
+			Currency:        line.Currency,
+			Reference:       line.Reference,
+			EndToEndID:      line.EndToEndID,
+			Status:          entity.PayoutLinePending,
+		}
+	}
+
+	var res *pb.PayoutBatchResponse
+	err = s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		if err := repo.CreatePayoutBatch(ctx, batch, items); err != nil {
+			return err
+		}
+		res = payoutBatchToProto(batch, account.Number, map[string]int{entity.PayoutLinePending: len(items)})
+		res.Message = "payout batch accepted"
+		// The response names the batch, so it is remembered last; a
+		// concurrent upload with the same request ID still fails on the
+		// unique index and rolls its batch back.
+		return rememberResponse(ctx, repo, req.Customerid, req, res)
+	})
+	if err != nil {
+		found, err := replayAfterFailure(ctx, s.repo, req.Customerid, req, &replayed, err)
+		if found {
+			return &replayed, nil
+		}
+		return &pb.PayoutBatchResponse{Success: false, Message: failureMessage(err, "submitting the payout batch failed")}, nil
+	}
+	return res, nil
+}
+
+// validatePayoutLines checks every line against the account it is paid
+// from and the account it pays into, and adds what is wrong to problems,
+// ordered by line.
+func (s *AccountService) validatePayoutLines(ctx context.Context, from *entity.Account, lines []payout.Line, problems []payout.LineError) ([]payout.LineError, error) {
+	recipients := make(map[string]*entity.Account)
+	for _, line := range lines {
+		problem := func(err error) {
+			problems = append(problems, payout.LineError{Line: line.Number, Message: err.Error()})
+		}
+		if line.FromAccountNumber != "" && line.FromAccountNumber != from.Number {
+			problem(fmt.Errorf("debtor account %s is not the account the batch is paid from", line.FromAccountNumber))
+			continue
+		}
+		if line.Currency != from.Currency {
+			problem(entity.ErrCurrencyMismatch)
+			continue
+		}
+		if line.Type != payout.TypeTransfer {
+			continue
+		}
+		if line.ToAccountNumber == from.Number {
+			problem(ErrSameAccount)
+			continue
+		}
+		to, ok := recipients[line.ToAccountNumber]
+		if !ok {
+			account, err := s.repo.GetAccountByNumber(ctx, line.ToAccountNumber)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				to = account
+			}
+			recipients[line.ToAccountNumber] = to
+		}
+		switch {
+		case to == nil:
+			problem(ErrAccountNotFound)
+		case to.Currency != line.Currency:
+			problem(entity.ErrCurrencyMismatch)
+		default:
+			if err := to.CanCredit(); err != nil {
+				problem(err)
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// GetPayoutBatch returns the status of a payout batch of the customer with
+// the outcome of every line.
+func (s *AccountService) GetPayoutBatch(ctx context.Context, req *pb.GetPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	batch, err := s.repo.GetPayoutBatch(ctx, uint(req.BatchId))
+	if err != nil || batch.CustomerID != uint(req.Customerid) {
+		return &pb.PayoutBatchResponse{Success: false, Message: ErrPayoutBatchNotFound.Error()}, nil
+	}
+	account, err := s.repo.GetAccountByID(ctx, batch.AccountID)
+	if err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: "loading the payout batch failed"}, nil
+	}
+	lines, err := s.repo.ListPayoutLines(ctx, batch.ID, "", 0)
+	if err != nil {
+		return &pb.PayoutBatchResponse{Success: false, Message: "loading the payout batch failed"}, nil
+	}
+
+	counts := make(map[string]int)
+	for i := range lines {
+		counts[lines[i].Status]++
+	}
+	res := payoutBatchToProto(batch, account.Number, counts)
+	res.Message = "payout batch found"
+	for i := range lines {
+		res.Lines = append(res.Lines, payoutLineToProto(&lines[i]))
+	}
+	return res, nil
+}
+
+// ExecutePayoutBatches pays the pending lines of every open payout batch,
+// oldest batch first and each in file order, and returns how many lines it
+// executed. Each line is booked in a unit of work of its own that also
+// records its outcome, so a line is paid at most once however often this
+// runs. A line that fails for a reason the customer can act on, e.g. lack
+// of funds, fails on its own and the batch moves on. Any other error, e.g.
+// a database outage, leaves the line pending and its batch is skipped for
+// the rest of the run; after PayoutLineMaxAttempts such runs the line fails.
+// The errors of skipped batches are returned once every other batch ran.
+func (s *AccountService) ExecutePayoutBatches(ctx context.Context, now time.Time) (int, error) {
+	executed := 0
+	var skipped []uint
+	var errs []error
+	for {
+		batches, err := s.repo.ListOpenPayoutBatches(ctx, skipped, payoutBatchLimit)
+		if err != nil || len(batches) == 0 {
+			return executed, errors.Join(append(errs, err)...)
+		}
+		for _, batch := range batches {
+			n, err := s.executePayoutBatch(ctx, batch.ID, now)
+			executed += n
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return executed, ctxErr
+			}
+			if err != nil {
+				skipped = append(skipped, batch.ID)
+				errs = append(errs, fmt.Errorf("payout batch %d: %w", batch.ID, err))
+			}
+		}
+	}
+}
+
+func (s *AccountService) executePayoutBatch(ctx context.Context, id uint, now time.Time) (int, error) {
+	batch, err := s.repo.GetPayoutBatch(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if batch.Status == entity.PayoutBatchPending {
+		batch.Status = entity.PayoutBatchProcessing
+		if err := s.repo.UpdatePayoutBatch(ctx, batch); err != nil {
+			return 0, err
+		}
+	}
+
+	executed := 0
+	for {
+		lines, err := s.repo.ListPayoutLines(ctx, id, entity.PayoutLinePending, payoutLineLimit)
+		if err != nil {
+			return executed, err
+		}
+		if len(lines) == 0 {
+			return executed, s.completePayoutBatch(ctx, id, now)
+		}
+		for _, line := range lines {
+			if err := ctx.Err(); err != nil {
+				return executed, err
+			}
+			if err := s.executePayoutLine(ctx, batch, line.ID, now); err != nil {
+				return executed, err
+			}
+			executed++
+		}
+	}
+}
+
+func (s *AccountService) executePayoutLine(ctx context.Context, batch *entity.PayoutBatch, id uint, now time.Time) error {
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		line, err := repo.GetPayoutLine(ctx, id)
+		if err != nil {
+			return err
+		}
+		if line.Status != entity.PayoutLinePending {
+			return nil
+		}
+		from, err := repo.GetAccountByID(ctx, batch.AccountID)
+		if err != nil {
+			return err
+		}
+		var booked *entity.Transaction
+		if line.Type == payout.TypeWithdrawal {
+			booked, err = s.withdraw(ctx, repo, uint32(batch.CustomerID), from.Number, line.Money(), line.Reference)
+		} else {
+			booked, err = s.transfer(ctx, repo, uint32(batch.CustomerID), from.Number, line.ToAccountNumber, line.Money(), line.Reference)
+		}
+		if err != nil {
+			return err
+		}
+		line.Status = entity.PayoutLineSucceeded
+		line.TransactionID = &booked.ID
+		line.ExecutedAt = &now
+		return repo.FinishPayoutLine(ctx, line)
+	})
+	// Errors the customer can act on fail the line, and so does any other
+	// error, e.g. a database outage, on the last attempt. Until then such
+	// errors, and concurrent updates, leave the line pending for the next
+	// run.
+	if err == nil || errors.Is(err, repository.ErrConcurrentUpdate) {
+		return err
+	}
+	if failureMessage(err, "") == "" {
+		log.Printf("payout line %d: %v", id, err)
+	}
+	return s.failPayoutLine(ctx, id, now, err)
+}
+
+// failPayoutLine records that a line could not be paid. A line that hit an
+// internal error before its last attempt stays pending with the attempt
+// counted, and cause is returned so that its batch waits for the next run;
+// any other line is failed.
+func (s *AccountService) failPayoutLine(ctx context.Context, id uint, now time.Time, cause error) error {
+	var retry bool
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		retry = false
+		line, err := repo.GetPayoutLine(ctx, id)
+		if err != nil {
+			return err
+		}
+		if line.Status != entity.PayoutLinePending {
+			return nil
+		}
+		if failureMessage(cause, "") == "" {
+			line.Attempts++
+			if line.Attempts < PayoutLineMaxAttempts {
+				retry = true
+				return repo.AddPayoutLineAttempt(ctx, id)
+			}
+		}
+		line.Status = entity.PayoutLineFailed
+		line.Message = failureMessage(cause, "payment failed")
+		line.ExecutedAt = &now
+		return repo.FinishPayoutLine(ctx, line)
+	})
+	if err == nil && retry {
+		return cause
+	}
+	return err
+}
+
+// completePayoutBatch marks a batch completed once none of its lines is
+// pending any more.
+func (s *AccountService) completePayoutBatch(ctx context.Context, id uint, now time.Time) error {
+	return s.inTx(ctx, func(repo repository.AccountRepository) error {
+		batch, err := repo.GetPayoutBatch(ctx, id)
+		if err != nil {
+			return err
+		}
+		if batch.Status == entity.PayoutBatchCompleted {
+			return nil
+		}
+		counts, err := repo.CountPayoutLines(ctx, id)
+		if err != nil || counts[entity.PayoutLinePending] > 0 {
+			return err
+		}
+		batch.Status = entity.PayoutBatchCompleted
+		batch.CompletedAt = &now
+		if err := repo.UpdatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+		account, err := repo.GetAccountByID(ctx, batch.AccountID)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, events.PayoutBatchCompleted{
+			AccountNumber: account.Number,
+			BatchID:       batch.ID,
+			Succeeded:     counts[entity.PayoutLineSucceeded],
+			Failed:        counts[entity.PayoutLineFailed],
+			OccurredAt:    now,
+		})
+	})
+}
+
+// RunPayoutBatches executes pending payout lines every interval until ctx
+// is cancelled.
+func (s *AccountService) RunPayoutBatches(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExecutePayoutBatches(ctx, time.Now()); err != nil {
+			log.Printf("payout batches: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func payoutBatchToProto(batch *entity.PayoutBatch, accountNumber string, counts map[string]int) *pb.PayoutBatchResponse {
+	res := &pb.PayoutBatchResponse{
+		Success:       true,
+		BatchId:       uint32(batch.ID),
+		AccountNumber: accountNumber,
+		Format:        batch.Format,
+		Source:        batch.Source,
+		Status:        batch.Status,
+		LineCount:     int32(batch.Lines),
+		Pending:       int32(counts[entity.PayoutLinePending]),
+		Succeeded:     int32(counts[entity.PayoutLineSucceeded]),
+		Failed:        int32(counts[entity.PayoutLineFailed]),
+		Total:         moneyToProto(batch.Money()),
+		CreatedAt:     batch.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if batch.CompletedAt != nil {
+		res.CompletedAt = batch.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
+
+func payoutLineToProto(line *entity.PayoutLine) *pb.PayoutLine {
+	res := &pb.PayoutLine{
+		Line:            int32(line.Line),
+		Type:            line.Type,
+		ToAccountNumber: line.ToAccountNumber,
+		Name:            line.Name,
+		Amount:          moneyToProto(line.Money()),
+		Reference:       line.Reference,
+		EndToEndId:      line.EndToEndID,
+		Status:          line.Status,
+		TransactionId:   linkedID(line.TransactionID),
+		Message:         line.Message,
+	}
+	if line.ExecutedAt != nil {
+		res.ExecutedAt = line.ExecutedAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/reconciliation"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+var ErrReconciliationNotFound = errors.New("reconciliation not found")
+
+// settledTypes are the transactions that move money through the partner
+// bank, and so show up in its settlement files.
+var settledTypes = []string{entity.TransactionDeposit, entity.TransactionWithdraw, entity.TransactionHoldCapture}
+
+// SetReconciliationConfig sets the tolerances settlement files are matched
+// with.
+func (s *AccountService) SetReconciliationConfig(config *reconciliation.Config) {
+	s.reconciliation = config
+}
+
+func (s *AccountService) reconciliationConfig() *reconciliation.Config {
+	if s.reconciliation == nil {
+		return &reconciliation.DefaultConfig
+	}
+	return s.reconciliation
+}
+
+// Reconcile matches the lines of a settlement file against the deposits,
+// withdrawals and card captures no earlier reconciliation matched, and
+// stores the outcome of every line. Lines whose bank reference an earlier
+// reconciliation matched are reported as duplicates and left alone, so
+// importing a file twice matches nothing twice; the unique transaction ID
+// of the stored lines makes a reconciliation running at the same time fail
+// rather than claim the same transaction.
+func (s *AccountService) Reconcile(ctx context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return &pb.ReconcileResponse{Success: false, Message: ErrActorRequired.Error()}, nil
+	}
+	lines, err := reconciliation.Read(bytes.NewReader(req.Content), req.Format)
+	if err != nil {
+		return &pb.ReconcileResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	run := &entity.Reconciliation{Format: req.Format, Source: req.Source, Actor: actor, Lines: len(lines)}
+	var items []entity.ReconciliationItem
+	err = s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		items, err = s.matchLines(ctx, repo, lines)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			switch item.Status {
+			case entity.ReconciliationMatched:
+				run.Matched++
+			case entity.ReconciliationUnmatched:
+				run.Unmatched++
+			case entity.ReconciliationAmbiguous:
+				run.Ambiguous++
+			case entity.ReconciliationDuplicate:
+				run.Duplicates++
+			}
+		}
+		if err := repo.CreateReconciliation(ctx, run); err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ReconciliationID = run.ID
+		}
+		return repo.CreateReconciliationItems(ctx, items)
+	})
+	if err != nil {
+		return &pb.ReconcileResponse{Success: false, Message: "reconciling the settlement file failed"}, nil
+	}
+
+	res := reconciliationToProto(run, items)
+	res.Message = "settlement file reconciled"
+	return res, nil
+}
+
+// matchLines works out the outcome of every line of a settlement file.
+func (s *AccountService) matchLines(ctx context.Context, repo repository.AccountRepository, lines []reconciliation.Line) ([]entity.ReconciliationItem, error) {
+	var externalIDs []string
+	for _, line := range lines {
+		if line.ExternalID != "" {
+			externalIDs = append(externalIDs, line.ExternalID)
+		}
+	}
+	matchedBefore, err := repo.MatchedExternalIDs(ctx, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	config := s.reconciliationConfig()
+	items := make([]entity.ReconciliationItem, len(lines))
+	var open []reconciliation.Line
+	var openItems []int
+	var from, to time.Time
+	for i, line := range lines {
+		items[i] = entity.ReconciliationItem{
+			Line:       line.Number,
+			ExternalID: line.ExternalID,
+			Amount:     line.Amount,
+			Currency:   line.Currency,
+			Date:       line.Date,
+			Reference:  line.Reference,
+		}
+		if id, ok := matchedBefore[line.ExternalID]; ok && line.ExternalID != "" {
+			items[i].Status = entity.ReconciliationDuplicate
+			items[i].Candidates = strconv.FormatUint(uint64(id), 10)
+			continue
+		}
+		open, openItems = append(open, line), append(openItems, i)
+		if from.IsZero() || line.Date.Before(from) {
+			from = line.Date
+		}
+		if line.Date.After(to) {
+			to = line.Date
+		}
+	}
+	if len(open) == 0 {
+		return items, nil
+	}
+
+	slack := config.DateToleranceDays
+	transactions, err := repo.ListUnreconciledTransactions(ctx, settledTypes, from.AddDate(0, 0, -slack), to.AddDate(0, 0, slack+1))
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]reconciliation.Candidate, len(transactions))
+	for i := range transactions {
+		t := &transactions[i]
+		candidates[i] = reconciliation.Candidate{ID: t.ID, Amount: signedAmount(t), Currency: t.Currency, Date: t.Date, Reference: t.Reference}
+	}
+
+	for i, result := range config.Match(open, candidates) {
+		item := &items[openItems[i]]
+		item.Status = result.Status
+		if result.Status == reconciliation.StatusMatched {
+			id := result.TransactionID
+			item.TransactionID = &id
+		}
+		ids := make([]string, len(result.Candidates))
+		for j, id := range result.Candidates {
+			ids[j] = strconv.FormatUint(uint64(id), 10)
+		}
+		item.Candidates = strings.Join(ids, ",")
+	}
+	return items, nil
+}
+
+// GetReconciliation returns the report of an earlier reconciliation.
+func (s *AccountService) GetReconciliation(ctx context.Context, req *pb.GetReconciliationRequest) (*pb.ReconcileResponse, error) {
+	run, err := s.repo.GetReconciliation(ctx, uint(req.ReconciliationId))
+	if err != nil {
+		return &pb.ReconcileResponse{Success: false, Message: ErrReconciliationNotFound.Error()}, nil
+	}
+	items, err := s.repo.ListReconciliationItems(ctx, run.ID)
+	if err != nil {
+		return &pb.ReconcileResponse{Success: false, Message: "loading the reconciliation failed"}, nil
+	}
+	res := reconciliationToProto(run, items)
+	res.Message = "reconciliation found"
+	return res, nil
+}
+
+func reconciliationToProto(run *entity.Reconciliation, items []entity.ReconciliationItem) *pb.ReconcileResponse {
+	res := &pb.ReconcileResponse{
+		Success:          true,
+		ReconciliationId: uint32(run.ID),
+		Format:           run.Format,
+		Source:           run.Source,
+		Actor:            run.Actor,
+		CreatedAt:        run.CreatedAt.Format(time.RFC3339),
+		Lines:            int32(run.Lines),
+		Matched:          int32(run.Matched),
+		Unmatched:        int32(run.Unmatched),
+		Ambiguous:        int32(run.Ambiguous),
+		Duplicates:       int32(run.Duplicates),
+	}
+	for i := range items {
+		item := &items[i]
+		var candidates []uint32
+		for _, id := range strings.Split(item.Candidates, ",") {
+			if n, err := strconv.ParseUint(id, 10, 32); err == nil {
+				candidates = append(candidates, uint32(n))
+			}
+		}
+		res.Items = append(res.Items, &pb.ReconciliationItem{
+			Line:          int32(item.Line),
+			ExternalId:    item.ExternalID,
+			Amount:        moneyToProto(item.Money()),
+			Date:          item.Date.Format(time.DateOnly),
+			Reference:     item.Reference,
+			Status:        item.Status,
+			TransactionId: linkedID(item.TransactionID),
+			Candidates:    candidates,
+		})
+	}
+	return res
+}
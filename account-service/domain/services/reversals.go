@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrAlreadyReversed     = errors.New("transaction has already been reversed")
+	ErrReverseReversal     = errors.New("a reversal cannot be reversed")
+	ErrActorRequired       = errors.New("actor is required")
+	ErrNotReversible       = errors.New("transaction predates the ledger and cannot be reversed")
+	ErrInterestReversal    = errors.New("interest postings cannot be reversed")
+)
+
+// ReverseTransaction undoes a transaction by posting the opposite of its
+// journal entry. Every account the entry touched gets a compensating history
+// row linked to its original row, so a transfer is always reversed as a
+// whole. A debit to a customer may not exceed the available balance plus the
+// overdraft limit, and no leg may be on a closed account. Frozen and dormant
+// accounts can be corrected. Interest postings are refused: the accruals they
+// posted would still count as paid.
+func (s *AccountService) ReverseTransaction(ctx context.Context, req *pb.ReverseTransactionRequest) (*pb.ReverseTransactionResponse, error) {
+	if !entity.ValidReasonCode(req.ReasonCode) {
+		return &pb.ReverseTransactionResponse{Success: false, Message: entity.ErrUnknownReason.Error()}, nil
+	}
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return &pb.ReverseTransactionResponse{Success: false, Message: ErrActorRequired.Error()}, nil
+	}
+
+	var reversalID uint
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		requested, err := repo.GetTransaction(ctx, uint(req.TransactionId))
+		if err != nil {
+			return ErrTransactionNotFound
+		}
+		if requested.JournalEntryID == 0 {
+			return ErrNotReversible
+		}
+		legs, err := repo.GetTransactionsByJournalEntryID(ctx, requested.JournalEntryID)
+		if err != nil {
+			return err
+		}
+
+		accounts := make(map[uint]*entity.Account, len(legs))
+		for _, leg := range legs {
+			if accounts[leg.AccountID] != nil {
+				continue
+			}
+			account, err := repo.GetAccountByID(ctx, leg.AccountID)
+			if err != nil {
+				return err
+			}
+			if account.Status == entity.AccountClosed {
+				return entity.ErrAccountClosed
+			}
+			accounts[leg.AccountID] = account
+		}
+		// Read the legs again now that the account versions are known, so
+		// that a concurrent reversal makes the version bump below fail.
+		legs, err = repo.GetTransactionsByJournalEntryID(ctx, requested.JournalEntryID)
+		if err != nil {
+			return err
+		}
+		for _, leg := range legs {
+			if leg.IsReversal() {
+				return ErrReverseReversal
+			}
+			if leg.ReversedByTransactionID != nil {
+				return ErrAlreadyReversed
+			}
+			if leg.Type == entity.TransactionInterest || leg.Type == entity.TransactionOverdraftInterest {
+				return ErrInterestReversal
+			}
+		}
+
+		journal, err := NewLedger(repo).Reverse(ctx, fmt.Sprintf("reversal of transaction %d", requested.ID), requested.JournalEntryID)
+		if err != nil {
+			return err
+		}
+
+		for i := range legs {
+			leg := &legs[i]
+			account := accounts[leg.AccountID]
+			reversal := entity.Transaction{
+				CustomerID:            leg.CustomerID,
+				AccountID:             leg.AccountID,
+				JournalEntryID:        journal.ID,
+				ReversesTransactionID: &leg.ID,
+				Type:                  entity.ReversalType(leg.Type),
+				Amount:                leg.Amount,
+				Currency:              leg.Currency,
+				Reference:             leg.Reference,
+				ReasonCode:            req.ReasonCode,
+				Actor:                 actor,
+				Date:                  journal.Date,
+			}
+			if err := bookTransaction(ctx, repo, account, &reversal); err != nil {
+				return err
+			}
+			if err := markReversed(ctx, repo, account, leg, &reversal); err != nil {
+				return err
+			}
+			if leg.ID == requested.ID {
+				reversalID = reversal.ID
+			}
+
+			if reversal.Type == entity.TransactionReversalDebit {
+				balance, available, err := balances(ctx, repo, account, time.Now())
+				if err != nil {
+					return err
+				}
+				if available+account.OverdraftLimit < 0 {
+					return ErrInsufficientFunds
+				}
+				if err := noteOverdrawn(ctx, repo, account, balance+reversal.Amount, reversal.Amount, reversal.ID, journal.Date); err != nil {
+					return err
+				}
+			}
+			if err := recordEvent(ctx, repo, events.TransactionReversed{
+				AccountNumber:         account.Number,
+				TransactionID:         leg.ID,
+				ReversalTransactionID: reversal.ID,
+				Amount:                events.NewAmount(leg.Money()),
+				ReasonCode:            req.ReasonCode,
+				Actor:                 actor,
+				OccurredAt:            journal.Date,
+			}); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]uint, 0, len(accounts))
+		for id := range accounts {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			if err := repo.BumpAccountVersion(ctx, accounts[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &pb.ReverseTransactionResponse{Success: false, Message: failureMessage(err, "reversal failed")}, nil
+	}
+
+	return &pb.ReverseTransactionResponse{Success: true, Message: "transaction reversed", ReversalTransactionId: uint32(reversalID)}, nil
+}
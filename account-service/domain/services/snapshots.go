@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	pb "github.com/m-dehghani/account-service/proto"
+)
+
+var ErrAsOfRequired = errors.New("as_of is required")
+
+// BalanceAsOf returns the balance of an account at a point in time, from the
+// latest balance snapshot before it plus the transactions booked since. An
+// account without such a snapshot is summed from the ledger.
+func (s *AccountService) BalanceAsOf(ctx context.Context, req *pb.BalanceAsOfRequest) (*pb.BalanceAsOfResponse, error) {
+	if req.AsOf == "" {
+		return &pb.BalanceAsOfResponse{Success: false, Message: ErrAsOfRequired.Error()}, nil
+	}
+	at, err := parseHistoryTime(req.AsOf, true)
+	if err != nil {
+		return &pb.BalanceAsOfResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	var balance entity.Money
+	var snapshot *entity.BalanceSnapshot
+	err = s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		account, err := repo.GetAccountByNumber(ctx, req.AccountNumber)
+		if err != nil {
+			return ErrAccountNotFound
+		}
+		balance.Currency = account.Currency
+		balance.Units, snapshot, err = balanceAsOf(ctx, repo, account, at)
+		return err
+	})
+	if err != nil {
+		return &pb.BalanceAsOfResponse{Success: false, Message: failureMessage(err, "reading balance failed")}, nil
+	}
+
+	res := &pb.BalanceAsOfResponse{
+		Success: true,
+		Message: "balance retrieved",
+		Balance: moneyToProto(balance),
+		AsOf:    at.UTC().Format(time.RFC3339),
+	}
+	if snapshot != nil {
+		res.SnapshotAsOf = snapshot.AsOf.UTC().Format(time.RFC3339)
+	}
+	return res, nil
+}
+
+// balanceAsOf works out the balance of an account from everything booked
+// before at, and returns the snapshot it started from, if any.
+func balanceAsOf(ctx context.Context, repo repository.AccountRepository, account *entity.Account, at time.Time) (int64, *entity.BalanceSnapshot, error) {
+	snapshot, found, err := repo.LatestBalanceSnapshot(ctx, account.ID, at)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !found {
+		ledger := NewLedger(repo)
+		customerLedger, err := ledger.CustomerAccount(ctx, account)
+		if err != nil {
+			return 0, nil, err
+		}
+		balance, err := ledger.BalanceBefore(ctx, customerLedger, at)
+		return balance, nil, err
+	}
+	replayed, err := repo.SumTransactions(ctx, account.ID, snapshot.AsOf, at)
+	if err != nil {
+		return 0, nil, err
+	}
+	return snapshot.Balance + replayed, snapshot, nil
+}
+
+// TakeBalanceSnapshots snapshots, as of the start of the day of now, the open
+// accounts that have had transactions since their previous snapshot, and
+// returns how many snapshots it took.
+func (s *AccountService) TakeBalanceSnapshots(ctx context.Context, now time.Time) (int, error) {
+	asOf := startOfDay(now)
+	productTypes := []string{entity.ProductChecking, entity.ProductSavings}
+
+	taken := 0
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListAccountsByProduct(ctx, productTypes, afterID, interestBatchSize)
+		if err != nil || len(accounts) == 0 {
+			return taken, err
+		}
+		for i := range accounts {
+			account := &accounts[i]
+			afterID = account.ID
+			if err := ctx.Err(); err != nil {
+				return taken, err
+			}
+			took, err := s.snapshotAccount(ctx, account, asOf)
+			if err != nil {
+				return taken, err
+			}
+			if took {
+				taken++
+			}
+		}
+	}
+}
+
+func (s *AccountService) snapshotAccount(ctx context.Context, account *entity.Account, asOf time.Time) (bool, error) {
+	previous, found, err := s.repo.LatestBalanceSnapshot(ctx, account.ID, asOf)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		if !previous.AsOf.Before(asOf) {
+			return false, nil
+		}
+		since, err := s.repo.ListTransactions(ctx, repository.TransactionFilter{AccountID: account.ID, From: previous.AsOf, To: asOf, Limit: 1})
+		if err != nil || len(since) == 0 {
+			return false, err
+		}
+	}
+
+	customerLedger, err := s.ledger.CustomerAccount(ctx, account)
+	if err != nil {
+		return false, err
+	}
+	balance, err := s.ledger.BalanceBefore(ctx, customerLedger, asOf)
+	if err != nil {
+		return false, err
+	}
+	err = s.repo.CreateBalanceSnapshot(ctx, &entity.BalanceSnapshot{
+		AccountID: account.ID,
+		AsOf:      asOf,
+		Balance:   balance,
+		Currency:  account.Currency,
+	})
+	return err == nil, err
+}
+
+// RunBalanceSnapshots takes balance snapshots every interval until ctx is
+// cancelled.
+func (s *AccountService) RunBalanceSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.TakeBalanceSnapshots(ctx, time.Now()); err != nil {
+			log.Printf("balance snapshots: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	pb "github.com/m-dehghani/account-service/proto"
+	"gorm.io/gorm"
+)
+
+const (
+	// StandingOrderMaxAttempts is how often an occurrence is tried before it
+	// is given up.
+	StandingOrderMaxAttempts = 4
+	// StandingOrderRetryInterval is the wait after an attempt that failed
+	// for lack of funds or for an internal error.
+	StandingOrderRetryInterval = 6 * time.Hour
+
+	standingOrderBatchSize = 100
+)
+
+var (
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	ErrStartInPast           = errors.New("start date must not be in the past")
+	ErrEndBeforeStart        = errors.New("end date is before the first execution")
+)
+
+// CreateStandingOrder schedules a recurring transfer from one of the
+// customer's accounts.
+func (s *AccountService) CreateStandingOrder(ctx context.Context, req *pb.CreateStandingOrderRequest) (*pb.CreateStandingOrderResponse, error) {
+	order, err := s.createStandingOrder(ctx, req, time.Now())
+	if err != nil {
+		return &pb.CreateStandingOrderResponse{Success: false, Message: failureMessage(err, "creating standing order failed")}, nil
+	}
+	return &pb.CreateStandingOrderResponse{
+		Success:       true,
+		Message:       "standing order created",
+		StandingOrder: standingOrderToProto(order, req.FromAccountNumber),
+	}, nil
+}
+
+func (s *AccountService) createStandingOrder(ctx context.Context, req *pb.CreateStandingOrderRequest, now time.Time) (*entity.StandingOrder, error) {
+	amount, err := moneyFromProto(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !entity.ValidFrequency(req.Frequency) {
+		return nil, entity.ErrUnknownFrequency
+	}
+	if req.FromAccountNumber == req.ToAccountNumber {
+		return nil, ErrSameAccount
+	}
+	today := startOfDay(now)
+	start := today
+	if req.StartDate != "" {
+		if start, err = time.Parse(time.DateOnly, req.StartDate); err != nil {
+			return nil, ErrInvalidDate
+		}
+		if start.Before(today) {
+			return nil, ErrStartInPast
+		}
+	}
+	order := &entity.StandingOrder{
+		ToAccountNumber: req.ToAccountNumber,
+		Amount:          amount.Units,
+		Currency:        amount.Currency,
+		Reference:       req.Reference,
+		Frequency:       req.Frequency,
+		StartDate:       start,
+		Status:          entity.StandingOrderActive,
+	}
+	if req.Frequency == entity.FrequencyMonthly {
+		order.DayOfMonth = int(req.DayOfMonth)
+		if order.DayOfMonth == 0 {
+			order.DayOfMonth = start.Day()
+		}
+		if order.DayOfMonth < 1 || order.DayOfMonth > 31 {
+			return nil, entity.ErrInvalidDayOfMonth
+		}
+	}
+	if req.EndDate != "" {
+		end, err := time.Parse(time.DateOnly, req.EndDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		order.EndDate = &end
+	}
+	order.DueDate = order.FirstOccurrence()
+	order.NextRunAt = order.DueDate
+	if order.Ends(order.DueDate) {
+		return nil, ErrEndBeforeStart
+	}
+
+	err = s.repo.WithTx(ctx, func(repo repository.AccountRepository) error {
+		from, err := ownedAccount(ctx, repo, req.Customerid, req.FromAccountNumber)
+		if err != nil {
+			return err
+		}
+		to, err := repo.GetAccountByNumber(ctx, req.ToAccountNumber)
+		if err != nil {
+			return ErrAccountNotFound
+		}
+		if err := from.CanDebit(); err != nil {
+			return err
+		}
+		if err := to.CanCredit(); err != nil {
+			return err
+		}
+		if from.Currency != amount.Currency || to.Currency != amount.Currency {
+			return entity.ErrCurrencyMismatch
+		}
+		order.CustomerID = from.CustomerID
+		order.AccountID = from.ID
+		if err := repo.CreateStandingOrder(ctx, order); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, events.StandingOrderCreated{
+			AccountNumber:   from.Number,
+			StandingOrderID: order.ID,
+			ToAccountNumber: order.ToAccountNumber,
+			Amount:          events.NewAmount(amount),
+			Frequency:       order.Frequency,
+			FirstDueDate:    order.DueDate.Format(time.DateOnly),
+			OccurredAt:      now,
+		})
+	})
+	return order, err
+}
+
+// ListStandingOrders returns the standing orders of a customer, or of one of
+// its accounts, including cancelled and completed ones.
+func (s *AccountService) ListStandingOrders(ctx context.Context, req *pb.ListStandingOrdersRequest) (*pb.ListStandingOrdersResponse, error) {
+	var accountID uint
+	if req.AccountNumber != "" {
+		account, err := ownedAccount(ctx, s.repo, req.Customerid, req.AccountNumber)
+		if err != nil {
+			return &pb.ListStandingOrdersResponse{Success: false, Message: err.Error()}, nil
+		}
+		accountID = account.ID
+	}
+	orders, err := s.repo.ListStandingOrders(ctx, uint(req.Customerid), accountID)
+	if err != nil {
+		return &pb.ListStandingOrdersResponse{Success: false, Message: "failed to list standing orders"}, nil
+	}
+
+	numbers := make(map[uint]string)
+	res := &pb.ListStandingOrdersResponse{Success: true, Message: "standing orders retrieved"}
+	for i := range orders {
+		number, ok := numbers[orders[i].AccountID]
+		if !ok {
+			account, err := s.repo.GetAccountByID(ctx, orders[i].AccountID)
+			if err != nil {
+				return &pb.ListStandingOrdersResponse{Success: false, Message: "failed to list standing orders"}, nil
+			}
+			number = account.Number
+			numbers[account.ID] = number
+		}
+		res.StandingOrders = append(res.StandingOrders, standingOrderToProto(&orders[i], number))
+	}
+	return res, nil
+}
+
+// CancelStandingOrder stops a standing order. An execution that is already
+// running completes first; the cancellation then applies to the next one.
+func (s *AccountService) CancelStandingOrder(ctx context.Context, req *pb.CancelStandingOrderRequest) (*pb.CancelStandingOrderResponse, error) {
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		order, err := customerStandingOrder(ctx, repo, req.Customerid, req.StandingOrderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.StandingOrderActive {
+			return entity.ErrStandingOrderStopped
+		}
+		order.Status = entity.StandingOrderCancelled
+		if err := repo.UpdateStandingOrder(ctx, order); err != nil {
+			return err
+		}
+		account, err := repo.GetAccountByID(ctx, order.AccountID)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, events.StandingOrderCancelled{
+			AccountNumber:   account.Number,
+			StandingOrderID: order.ID,
+			OccurredAt:      time.Now(),
+		})
+	})
+	if err != nil {
+		return &pb.CancelStandingOrderResponse{Success: false, Message: failureMessage(err, "cancelling standing order failed")}, nil
+	}
+	return &pb.CancelStandingOrderResponse{Success: true, Message: "standing order cancelled"}, nil
+}
+
+// StandingOrderExecutions returns every execution attempt of a standing
+// order, oldest first.
+func (s *AccountService) StandingOrderExecutions(ctx context.Context, req *pb.StandingOrderExecutionsRequest) (*pb.StandingOrderExecutionsResponse, error) {
+	order, err := customerStandingOrder(ctx, s.repo, req.Customerid, req.StandingOrderId)
+	if err != nil {
+		return &pb.StandingOrderExecutionsResponse{Success: false, Message: err.Error()}, nil
+	}
+	executions, err := s.repo.ListStandingOrderExecutions(ctx, order.ID)
+	if err != nil {
+		return &pb.StandingOrderExecutionsResponse{Success: false, Message: "failed to read executions"}, nil
+	}
+
+	res := &pb.StandingOrderExecutionsResponse{Success: true, Message: "executions retrieved"}
+	for _, e := range executions {
+		res.Executions = append(res.Executions, &pb.StandingOrderExecution{
+			Id:            uint32(e.ID),
+			DueDate:       e.DueDate.UTC().Format(time.DateOnly),
+			Attempt:       int32(e.Attempt),
+			Status:        e.Status,
+			TransactionId: linkedID(e.TransactionID),
+			Message:       e.Message,
+			ExecutedAt:    e.ExecutedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return res, nil
+}
+
+// ExecuteStandingOrders runs every standing order that is due at now and
+// returns how many attempts it made. Occurrences missed while the service
+// was down are executed one after the other. An attempt that fails for lack
+// of funds, or for a reason the customer cannot act on such as a database
+// outage, is retried after StandingOrderRetryInterval, up to
+// StandingOrderMaxAttempts attempts; other failures, and the last attempt,
+// give the occurrence up and move on to the next one. An order that cannot
+// be executed or failed at all is skipped for the rest of the run, and its
+// error is returned once every other due order has run.
+func (s *AccountService) ExecuteStandingOrders(ctx context.Context, now time.Time) (int, error) {
+	attempts := 0
+	var skipped []uint
+	var errs []error
+	for {
+		orders, err := s.repo.GetDueStandingOrders(ctx, now, skipped, standingOrderBatchSize)
+		if err != nil || len(orders) == 0 {
+			return attempts, errors.Join(append(errs, err)...)
+		}
+		for _, order := range orders {
+			if err := ctx.Err(); err != nil {
+				return attempts, err
+			}
+			if err := s.executeStandingOrder(ctx, order.ID, now); err != nil {
+				skipped = append(skipped, order.ID)
+				errs = append(errs, fmt.Errorf("standing order %d: %w", order.ID, err))
+				continue
+			}
+			attempts++
+		}
+	}
+}
+
+func (s *AccountService) executeStandingOrder(ctx context.Context, id uint, now time.Time) error {
+	err := s.inTx(ctx, func(repo repository.AccountRepository) error {
+		order, err := repo.GetStandingOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.StandingOrderActive || order.NextRunAt.After(now) {
+			return nil
+		}
+		from, err := repo.GetAccountByID(ctx, order.AccountID)
+		if err != nil {
+			return err
+		}
+		out, err := s.transfer(ctx, repo, uint32(order.CustomerID), from.Number, order.ToAccountNumber, order.Money(), order.Reference)
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateStandingOrderExecution(ctx, &entity.StandingOrderExecution{
+			StandingOrderID: order.ID,
+			DueDate:         order.DueDate,
+			Attempt:         order.Attempts + 1,
+			Status:          entity.ExecutionSucceeded,
+			TransactionID:   &out.ID,
+			ExecutedAt:      now,
+		}); err != nil {
+			return err
+		}
+		advanceStandingOrder(order)
+		return repo.UpdateStandingOrder(ctx, order)
+	})
+	// A concurrent update leaves the order due for the next run. Any other
+	// error fails the attempt, so that an order that keeps failing, e.g.
+	// during a database outage, is eventually given up.
+	if err == nil || errors.Is(err, repository.ErrConcurrentUpdate) {
+		return err
+	}
+	if failureMessage(err, "") == "" {
+		log.Printf("standing order %d: %v", id, err)
+	}
+	return s.failStandingOrder(ctx, id, now, err)
+}
+
+// failStandingOrder records a failed attempt and schedules a retry or gives
+// the occurrence up.
+func (s *AccountService) failStandingOrder(ctx context.Context, id uint, now time.Time, cause error) error {
+	return s.inTx(ctx, func(repo repository.AccountRepository) error {
+		order, err := repo.GetStandingOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.StandingOrderActive || order.NextRunAt.After(now) {
+			return nil
+		}
+		execution := &entity.StandingOrderExecution{
+			StandingOrderID: order.ID,
+			DueDate:         order.DueDate,
+			Attempt:         order.Attempts + 1,
+			Status:          entity.ExecutionRetrying,
+			Message:         failureMessage(cause, "transfer failed"),
+			ExecutedAt:      now,
+		}
+		order.Attempts++
+		retry := errors.Is(cause, ErrInsufficientFunds) || failureMessage(cause, "") == ""
+		if retry && order.Attempts < StandingOrderMaxAttempts {
+			order.NextRunAt = now.Add(StandingOrderRetryInterval)
+		} else {
+			execution.Status = entity.ExecutionFailed
+			// An order whose account is gone is given up without an event;
+			// there is no account to report it on.
+			account, err := repo.GetAccountByID(ctx, order.AccountID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := recordEvent(ctx, repo, events.StandingOrderFailed{
+					AccountNumber:   account.Number,
+					StandingOrderID: order.ID,
+					DueDate:         order.DueDate.UTC().Format(time.DateOnly),
+					Attempts:        order.Attempts,
+					Reason:          execution.Message,
+					OccurredAt:      now,
+				}); err != nil {
+					return err
+				}
+			}
+			advanceStandingOrder(order)
+		}
+		if err := repo.CreateStandingOrderExecution(ctx, execution); err != nil {
+			return err
+		}
+		return repo.UpdateStandingOrder(ctx, order)
+	})
+}
+
+// advanceStandingOrder moves an order on to its next occurrence, or completes
+// it after its end date.
+func advanceStandingOrder(order *entity.StandingOrder) {
+	next := order.NextOccurrence(order.DueDate.UTC())
+	order.DueDate = next
+	order.NextRunAt = next
+	order.Attempts = 0
+	if order.Ends(next) {
+		order.Status = entity.StandingOrderCompleted
+	}
+}
+
+// RunStandingOrders executes due standing orders every interval until ctx is
+// cancelled.
+func (s *AccountService) RunStandingOrders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExecuteStandingOrders(ctx, time.Now()); err != nil {
+			log.Printf("standing orders: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// customerStandingOrder loads a standing order of a customer. Orders of other
+// customers are reported as not found.
+func customerStandingOrder(ctx context.Context, repo repository.AccountRepository, customerID, id uint32) (*entity.StandingOrder, error) {
+	order, err := repo.GetStandingOrder(ctx, uint(id))
+	if err != nil || order.CustomerID != uint(customerID) {
+		return nil, ErrStandingOrderNotFound
+	}
+	return order, nil
+}
+
+func standingOrderToProto(order *entity.StandingOrder, fromAccountNumber string) *pb.StandingOrder {
+	res := &pb.StandingOrder{
+		Id:                uint32(order.ID),
+		FromAccountNumber: fromAccountNumber,
+		ToAccountNumber:   order.ToAccountNumber,
+		Amount:            moneyToProto(order.Money()),
+		Reference:         order.Reference,
+		Frequency:         order.Frequency,
+		DayOfMonth:        int32(order.DayOfMonth),
+		StartDate:         order.StartDate.UTC().Format(time.DateOnly),
+		Status:            order.Status,
+	}
+	if order.EndDate != nil {
+		res.EndDate = order.EndDate.UTC().Format(time.DateOnly)
+	}
+	if order.Status == entity.StandingOrderActive {
+		res.NextDueDate = order.DueDate.UTC().Format(time.DateOnly)
+		res.NextRunAt = order.NextRunAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/statement"
+	pb "github.com/m-dehghani/account-service/proto"
+	"gorm.io/gorm"
+)
+
+// GetStatement renders the statement of an account for one calendar month.
+// Besides HTML and PDF it can be exported as camt.053, MT940 or OFX.
+// The opening balance comes from the ledger, so that balances carried over
+// from before the ledger existed are included; the lines are the account's
+// transactions of the month, each with the running balance after it.
+func (s *AccountService) GetStatement(ctx context.Context, req *pb.GetStatementRequest) (*pb.GetStatementResponse, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = statement.FormatHTML
+	}
+	if !statement.ValidFormat(format) {
+		return &pb.GetStatementResponse{Success: false, Message: statement.ErrUnknownFormat.Error()}, nil
+	}
+	from, to, err := statement.Month(req.Period)
+	if err != nil {
+		return &pb.GetStatementResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	var result *statement.Statement
+	// Read the opening balance and the lines from one snapshot so that they
+	// describe the same state.
+	err = s.repo.WithSnapshot(ctx, func(repo repository.AccountRepository) error {
+		account, err := ownedAccount(ctx, repo, req.Customerid, req.AccountNumber)
+		if err != nil {
+			return err
+		}
+		// The snapshot is read-only: an account that has never been booked
+		// has no ledger account yet and opens at zero.
+		var opening int64
+		customerLedger, err := repo.GetLedgerAccountByCode(ctx, customerLedgerCode(account.ID), account.Currency)
+		if err == nil {
+			opening, err = NewLedger(repo).BalanceBefore(ctx, customerLedger, from)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var transactions []entity.Transaction
+		filter := repository.TransactionFilter{AccountID: account.ID, From: from, To: to, Limit: streamBatchSize}
+		for {
+			batch, err := repo.ListTransactions(ctx, filter)
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, batch...)
+			if len(batch) < filter.Limit {
+				break
+			}
+			filter.AfterID = batch[len(batch)-1].ID
+		}
+
+		result = statement.New(account, from, to, opening, transactions)
+		return nil
+	})
+	if err != nil {
+		return &pb.GetStatementResponse{Success: false, Message: failureMessage(err, "statement failed")}, nil
+	}
+
+	var content bytes.Buffer
+	if err := result.Render(&content, format); err != nil {
+		return &pb.GetStatementResponse{Success: false, Message: failureMessage(err, "rendering statement failed")}, nil
+	}
+
+	return &pb.GetStatementResponse{
+		Success:        true,
+		Message:        "statement generated",
+		ContentType:    statement.ContentType(format),
+		Filename:       result.Filename(format),
+		Content:        content.Bytes(),
+		OpeningBalance: moneyToProto(entity.Money{Units: result.Opening, Currency: result.Currency}),
+		ClosingBalance: moneyToProto(entity.Money{Units: result.Closing, Currency: result.Currency}),
+	}, nil
+}
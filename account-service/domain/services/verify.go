@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	repository "github.com/m-dehghani/account-service/domain/data"
+	"github.com/m-dehghani/account-service/domain/entity"
+	"gorm.io/gorm"
+)
+
+// openingBalanceDescription describes the journal entries that carried the
+// balances of the accounts over from before the ledger. They stand for the
+// transactions recorded back then, which have no journal entry.
+const openingBalanceDescription = "opening balance"
+
+// LedgerReport is the outcome of VerifyLedger. Amounts are minor units.
+type LedgerReport struct {
+	CheckedAt time.Time      `json:"checked_at"`
+	Accounts  int            `json:"accounts"`
+	Drifted   []AccountDrift `json:"drifted"`
+}
+
+// OK reports whether every account agrees with its transaction log.
+func (r *LedgerReport) OK() bool {
+	return len(r.Drifted) == 0
+}
+
+// AccountDrift describes an account whose ledger balance, or one of whose
+// balance snapshots, disagrees with the balance recomputed from its
+// transactions. Drift is the ledger balance minus the recomputed one. An
+// account without a ledger account always drifts; its ledger balance counts
+// as zero.
+type AccountDrift struct {
+	AccountNumber        string          `json:"account_number"`
+	Currency             string          `json:"currency"`
+	MissingLedgerAccount bool            `json:"missing_ledger_account,omitempty"`
+	LedgerBalance        int64           `json:"ledger_balance"`
+	LogBalance           int64           `json:"log_balance"`
+	Drift                int64           `json:"drift"`
+	FirstDivergence      *Divergence     `json:"first_divergence,omitempty"`
+	Snapshots            []SnapshotDrift `json:"snapshots,omitempty"`
+}
+
+// Divergence is the point from which the ledger and the transaction log
+// disagree: the first transaction of the journal entry where the running
+// balances parted and did not meet again. TransactionID is zero when the
+// entry has no transaction.
+type Divergence struct {
+	TransactionID  uint      `json:"transaction_id,omitempty"`
+	JournalEntryID uint      `json:"journal_entry_id,omitempty"`
+	Date           time.Time `json:"date"`
+	LedgerBalance  int64     `json:"ledger_balance"`
+	LogBalance     int64     `json:"log_balance"`
+}
+
+// SnapshotDrift is a balance snapshot that disagrees with the transactions
+// dated before it.
+type SnapshotDrift struct {
+	AsOf       time.Time `json:"as_of"`
+	Balance    int64     `json:"balance"`
+	LogBalance int64     `json:"log_balance"`
+}
+
+// VerifyLedger recomputes the balance of every account from its transaction
+// log and compares it with the ledger and with the balance snapshots.
+func (s *AccountService) VerifyLedger(ctx context.Context) (*LedgerReport, error) {
+	report := &LedgerReport{CheckedAt: time.Now().UTC(), Drifted: []AccountDrift{}}
+	var afterID uint
+	for {
+		accounts, err := s.repo.ListAccounts(ctx, afterID, interestBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			return report, nil
+		}
+		for i := range accounts {
+			account := &accounts[i]
+			afterID = account.ID
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var drift *AccountDrift
+			// Read the ledger and the log from one snapshot so that a
+			// booking committed in between is in both or in neither.
+			err := s.repo.WithSnapshot(ctx, func(repo repository.AccountRepository) error {
+				var err error
+				drift, err = verifyAccount(ctx, repo, account)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			report.Accounts++
+			if drift != nil {
+				report.Drifted = append(report.Drifted, *drift)
+			}
+		}
+	}
+}
+
+// journalStep is what one journal entry changed, according to the ledger and
+// according to the transaction log.
+type journalStep struct {
+	ledger, log   int64
+	transactionID uint
+	date          time.Time
+}
+
+// verifyAccount returns the drift of an account, or nil if it has none. It
+// only reads: a missing ledger account is reported, not created.
+func verifyAccount(ctx context.Context, repo repository.AccountRepository, account *entity.Account) (*AccountDrift, error) {
+	drift := &AccountDrift{AccountNumber: account.Number, Currency: account.Currency}
+	customerLedger, err := repo.GetLedgerAccountByCode(ctx, customerLedgerCode(account.ID), account.Currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		drift.MissingLedgerAccount = true
+	} else if err != nil {
+		return nil, err
+	}
+	var movements []repository.JournalMovement
+	if !drift.MissingLedgerAccount {
+		if movements, err = repo.ListJournalMovements(ctx, customerLedger.ID); err != nil {
+			return nil, err
+		}
+	}
+	var transactions []entity.Transaction
+	filter := repository.TransactionFilter{AccountID: account.ID, Limit: streamBatchSize}
+	for {
+		batch, err := repo.ListTransactions(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, batch...)
+		if len(batch) < filter.Limit {
+			break
+		}
+		filter.AfterID = batch[len(batch)-1].ID
+	}
+
+	steps := make(map[uint]*journalStep)
+	step := func(journalEntryID uint) *journalStep {
+		if steps[journalEntryID] == nil {
+			steps[journalEntryID] = &journalStep{}
+		}
+		return steps[journalEntryID]
+	}
+	var opening uint
+	for _, movement := range movements {
+		st := step(movement.JournalEntryID)
+		st.ledger = customerLedger.Balance(movement.Amount)
+		st.date = movement.Date
+		if opening == 0 && movement.Description == openingBalanceDescription {
+			opening = movement.JournalEntryID
+		}
+	}
+	var legacy int64
+	for _, t := range transactions {
+		journalEntryID := t.JournalEntryID
+		if journalEntryID == 0 {
+			journalEntryID = opening
+			legacy += signedAmount(&t)
+		}
+		st := step(journalEntryID)
+		st.log += signedAmount(&t)
+		if st.transactionID == 0 {
+			st.transactionID = t.ID
+			st.date = t.Date
+		}
+	}
+
+	ids := make([]uint, 0, len(steps))
+	for id := range steps {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		st := steps[id]
+		drift.LedgerBalance += st.ledger
+		drift.LogBalance += st.log
+		switch {
+		case drift.LedgerBalance == drift.LogBalance:
+			drift.FirstDivergence = nil
+		case drift.FirstDivergence == nil:
+			drift.FirstDivergence = &Divergence{
+				TransactionID:  st.transactionID,
+				JournalEntryID: id,
+				Date:           st.date.UTC(),
+				LedgerBalance:  drift.LedgerBalance,
+				LogBalance:     drift.LogBalance,
+			}
+		}
+	}
+	drift.Drift = drift.LedgerBalance - drift.LogBalance
+
+	snapshots, err := repo.ListBalanceSnapshots(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		// Transactions from before the ledger predate every snapshot.
+		logBalance := legacy
+		for _, t := range transactions {
+			if t.JournalEntryID != 0 && t.Date.Before(snapshot.AsOf) {
+				logBalance += signedAmount(&t)
+			}
+		}
+		if logBalance != snapshot.Balance {
+			drift.Snapshots = append(drift.Snapshots, SnapshotDrift{
+				AsOf:       snapshot.AsOf.UTC(),
+				Balance:    snapshot.Balance,
+				LogBalance: logBalance,
+			})
+		}
+	}
+
+	if drift.Drift == 0 && len(drift.Snapshots) == 0 && !drift.MissingLedgerAccount {
+		return nil, nil
+	}
+	return drift, nil
+}
+
+// signedAmount is what a transaction added to the balance of its account.
+func signedAmount(t *entity.Transaction) int64 {
+	if entity.IsCredit(t.Type) {
+		return t.Amount
+	}
+	return -t.Amount
+}
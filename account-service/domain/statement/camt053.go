@@ -0,0 +1,193 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// The types below cover the part of the camt.053.001.02 schema the export
+// fills in, with the elements in schema order.
+type camtDocument struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Report  camtBkToCstmr `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmr struct {
+	GroupHeader camtGroupHeader `xml:"GrpHdr"`
+	Statement   camtStatement   `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	ID        string        `xml:"Id"`
+	CreatedAt string        `xml:"CreDtTm"`
+	Period    camtPeriod    `xml:"FrToDt"`
+	Account   camtAccount   `xml:"Acct"`
+	Balances  []camtBalance `xml:"Bal"`
+	Summary   camtSummary   `xml:"TxsSummry"`
+	Entries   []camtEntry   `xml:"Ntry"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtSummary struct {
+	Total   camtTotal     `xml:"TtlNtries"`
+	Credits camtSideTotal `xml:"TtlCdtNtries"`
+	Debits  camtSideTotal `xml:"TtlDbtNtries"`
+}
+
+type camtTotal struct {
+	Count     int    `xml:"NbOfNtries"`
+	Sum       string `xml:"Sum"`
+	Net       string `xml:"TtlNetNtryAmt"`
+	Indicator string `xml:"CdtDbtInd"`
+}
+
+type camtSideTotal struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference       string       `xml:"NtryRef"`
+	Amount          camtAmount   `xml:"Amt"`
+	Indicator       string       `xml:"CdtDbtInd"`
+	Reversal        bool         `xml:"RvslInd,omitempty"`
+	Status          string       `xml:"Sts"`
+	BookingDate     string       `xml:"BookgDt>DtTm"`
+	ValueDate       string       `xml:"ValDt>Dt"`
+	ServicerRef     string       `xml:"AcctSvcrRef"`
+	TransactionCode string       `xml:"BkTxCd>Prtry>Cd"`
+	Details         *camtDetails `xml:"NtryDtls,omitempty"`
+}
+
+type camtDetails struct {
+	Remittance string `xml:"TxDtls>RmtInf>Ustrd"`
+}
+
+// RenderCAMT053 writes the statement as an ISO 20022 camt.053 bank to
+// customer statement. Reversals are flagged with RvslInd.
+func (s *Statement) RenderCAMT053(w io.Writer) error {
+	id := s.AccountNumber + "-" + s.From.Format("200601")
+	doc := camtDocument{
+		Xmlns: camt053Namespace,
+		Report: camtBkToCstmr{
+			GroupHeader: camtGroupHeader{MessageID: "STMT-" + id, CreatedAt: s.GeneratedAt.Format(time.RFC3339)},
+			Statement: camtStatement{
+				ID:        id,
+				CreatedAt: s.GeneratedAt.Format(time.RFC3339),
+				Period: camtPeriod{
+					From: s.From.Format(time.RFC3339),
+					To:   s.To.Add(-time.Second).Format(time.RFC3339),
+				},
+				Account: camtAccount{ID: s.AccountNumber, Currency: s.Currency},
+				Balances: []camtBalance{
+					s.camtBalance("OPBD", s.Opening, s.From),
+					s.camtBalance("CLBD", s.Closing, s.lastDay()),
+				},
+			},
+		},
+	}
+
+	stmt := &doc.Report.Statement
+	var credits, debits int64
+	for _, line := range s.Lines {
+		if line.Amount >= 0 {
+			stmt.Summary.Credits.Count++
+			credits += line.Amount
+		} else {
+			stmt.Summary.Debits.Count++
+			debits -= line.Amount
+		}
+		id := strconv.FormatUint(uint64(line.TransactionID), 10)
+		var details *camtDetails
+		if line.Reference != "" {
+			details = &camtDetails{Remittance: line.Reference}
+		}
+		stmt.Entries = append(stmt.Entries, camtEntry{
+			Reference:       id,
+			Amount:          camtAmount{Currency: s.Currency, Value: s.decimal(line.Amount)},
+			Indicator:       camtIndicator(line.Amount),
+			Reversal:        isReversal(line.Type),
+			Status:          "BOOK",
+			BookingDate:     line.Date.Format(time.RFC3339),
+			ValueDate:       line.Date.Format(time.DateOnly),
+			ServicerRef:     id,
+			TransactionCode: line.Type,
+			Details:         details,
+		})
+	}
+	stmt.Summary.Total = camtTotal{
+		Count:     len(s.Lines),
+		Sum:       s.decimal(credits + debits),
+		Net:       s.decimal(credits - debits),
+		Indicator: camtIndicator(credits - debits),
+	}
+	stmt.Summary.Credits.Sum = s.decimal(credits)
+	stmt.Summary.Debits.Sum = s.decimal(debits)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (s *Statement) camtBalance(code string, units int64, date time.Time) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amount:    camtAmount{Currency: s.Currency, Value: s.decimal(units)},
+		Indicator: camtIndicator(units),
+		Date:      date.Format(time.DateOnly),
+	}
+}
+
+// camtIndicator tells credits from debits. Customer balances are credits
+// from the customer's point of view, so zero counts as a credit.
+func camtIndicator(units int64) string {
+	if units < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// isReversal reports whether a line compensates an earlier transaction.
+func isReversal(transactionType string) bool {
+	return transactionType == entity.TransactionReversalCredit || transactionType == entity.TransactionReversalDebit
+}
@@ -0,0 +1,62 @@
+package statement
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("statement").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.AccountNumber}} {{.Period}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.amount, th.amount { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>
+Account {{.AccountNumber}} ({{.ProductType}}, {{.Currency}})<br>
+Period {{.From.Format "2006-01-02"}} to {{(.To.AddDate 0 0 -1).Format "2006-01-02"}}
+</p>
+<table>
+<tr><th>Opening balance</th><td class="amount">{{.Money .Opening}}</td></tr>
+<tr><th>Closing balance</th><td class="amount">{{.Money .Closing}}</td></tr>
+</table>
+<h2>Transactions</h2>
+<table>
+<thead>
+<tr><th>Date</th><th>ID</th><th>Type</th><th>Reference</th><th class="amount">Amount</th><th class="amount">Balance</th></tr>
+</thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.Date.Format "2006-01-02 15:04"}}</td><td>{{.TransactionID}}</td><td>{{.Type}}</td><td>{{.Reference}}</td><td class="amount">{{$.Money .Amount}}</td><td class="amount">{{$.Money .Balance}}</td></tr>
+{{- else}}
+<tr><td colspan="6">No transactions in this period.</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h2>Totals by type</h2>
+<table>
+<thead>
+<tr><th>Type</th><th class="amount">Count</th><th class="amount">Amount</th></tr>
+</thead>
+<tbody>
+{{- range .Totals}}
+<tr><td>{{.Type}}</td><td class="amount">{{.Count}}</td><td class="amount">{{$.Money .Amount}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
+
+// RenderHTML writes the statement as a standalone HTML page.
+func (s *Statement) RenderHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, s)
+}
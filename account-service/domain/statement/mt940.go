@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// mt940LineLength is the longest line a narrative field may have.
+const mt940LineLength = 65
+
+// RenderMT940 writes the statement as the text block of a SWIFT MT940
+// customer statement message: one :61: line per transaction, each followed
+// by a :86: line carrying the type and the reference.
+func (s *Statement) RenderMT940(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	field := func(tag, value string) {
+		b.WriteString(":" + tag + ":" + value + "\r\n")
+	}
+
+	field("20", "STMT"+s.From.Format("200601"))
+	field("25", s.AccountNumber)
+	field("28C", strconv.Itoa(int(s.From.Month()))+"/1")
+	field("60F", s.mt940Balance(s.Opening, s.From))
+	for _, line := range s.Lines {
+		// A reversal is marked RC (reversal of credit) or RD (reversal of
+		// debit) instead of D or C.
+		mark := "C"
+		switch {
+		case isReversal(line.Type) && line.Amount < 0:
+			mark = "RC"
+		case isReversal(line.Type):
+			mark = "RD"
+		case line.Amount < 0:
+			mark = "D"
+		}
+		// "//" separates the bank's reference and may not appear in the
+		// customer's.
+		reference := strings.ReplaceAll(mt940Text(line.Reference, 16), "//", "/")
+		if reference == "" {
+			reference = "NONREF"
+		}
+		field("61", line.Date.Format("060102")+line.Date.Format("0102")+mark+
+			mt940Amount(s.decimal(line.Amount))+mt940Code(line.Type)+reference+
+			"//"+strconv.FormatUint(uint64(line.TransactionID), 10))
+		field("86", mt940Text(strings.TrimSpace(line.Type+" "+line.Reference), mt940LineLength))
+	}
+	field("62F", s.mt940Balance(s.Closing, s.lastDay()))
+	b.WriteString("-\r\n")
+	return b.Flush()
+}
+
+func (s *Statement) mt940Balance(units int64, date time.Time) string {
+	mark := "C"
+	if units < 0 {
+		mark = "D"
+	}
+	return mark + date.Format("060102") + s.Currency + mt940Amount(s.decimal(units))
+}
+
+// mt940Amount uses a decimal comma, which is always present.
+func mt940Amount(decimal string) string {
+	if !strings.Contains(decimal, ".") {
+		return decimal + ","
+	}
+	return strings.Replace(decimal, ".", ",", 1)
+}
+
+// mt940Code is the transaction type identification code: N followed by a
+// SWIFT code.
+func mt940Code(transactionType string) string {
+	switch transactionType {
+	case entity.TransactionTransferIn, entity.TransactionTransferOut:
+		return "NTRF"
+	case entity.TransactionInterest, entity.TransactionOverdraftInterest:
+		return "NINT"
+	case entity.TransactionWithholdingTax, entity.TransactionWithdrawalFee, entity.TransactionDepositFee,
+		entity.TransactionTransferFee, entity.TransactionMaintenanceFee:
+		return "NCHG"
+	}
+	return "NMSC"
+}
+
+// mt940Text keeps to the SWIFT X character set and cuts the text to max
+// characters.
+func mt940Text(text string, max int) string {
+	var b strings.Builder
+	for _, r := range text {
+		if b.Len() == max {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			b.WriteRune(r)
+		case r == '_':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// The types below cover the OFX 2.2 bank statement response, with the
+// elements in specification order.
+type ofxDocument struct {
+	XMLName xml.Name     `xml:"OFX"`
+	SignOn  ofxSignOn    `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStatement `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	Time     string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStatement struct {
+	TransactionID string           `xml:"TRNUID"`
+	Status        ofxStatus        `xml:"STATUS"`
+	Currency      string           `xml:"STMTRS>CURDEF"`
+	Account       ofxAccount       `xml:"STMTRS>BANKACCTFROM"`
+	List          ofxBankTranList  `xml:"STMTRS>BANKTRANLIST"`
+	Ledger        ofxLedgerBalance `xml:"STMTRS>LEDGERBAL"`
+}
+
+type ofxAccount struct {
+	BankID string `xml:"BANKID"`
+	ID     string `xml:"ACCTID"`
+	Type   string `xml:"ACCTTYPE"`
+}
+
+type ofxBankTranList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	ID     string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxLedgerBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// RenderOFX writes the statement as an OFX 2.2 bank statement download.
+// OFX has no opening balance; the closing balance is the ledger balance.
+func (s *Statement) RenderOFX(w io.Writer) error {
+	accountType := "CHECKING"
+	if s.ProductType == entity.ProductSavings {
+		accountType = "SAVINGS"
+	}
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+	doc := ofxDocument{
+		SignOn: ofxSignOn{Status: ok, Time: ofxTime(s.GeneratedAt), Language: "ENG"},
+		Bank: ofxStatement{
+			TransactionID: "0",
+			Status:        ok,
+			Currency:      s.Currency,
+			Account:       ofxAccount{BankID: BankID, ID: s.AccountNumber, Type: accountType},
+			List:          ofxBankTranList{Start: ofxTime(s.From), End: ofxTime(s.To)},
+			Ledger:        ofxLedgerBalance{Amount: s.signedDecimal(s.Closing), AsOf: ofxTime(s.To)},
+		},
+	}
+	for _, line := range s.Lines {
+		doc.Bank.List.Transactions = append(doc.Bank.List.Transactions, ofxTransaction{
+			Type:   ofxType(line),
+			Posted: ofxTime(line.Date),
+			Amount: s.signedDecimal(line.Amount),
+			ID:     strconv.FormatUint(uint64(line.TransactionID), 10),
+			Name:   strings.ReplaceAll(line.Type, "_", " "),
+			Memo:   line.Reference,
+		})
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (s *Statement) signedDecimal(units int64) string {
+	return entity.Money{Units: units, Currency: s.Currency}.Decimal()
+}
+
+func ofxType(line Line) string {
+	switch line.Type {
+	case entity.TransactionDeposit:
+		return "DEP"
+	case entity.TransactionTransferIn, entity.TransactionTransferOut:
+		return "XFER"
+	case entity.TransactionInterest, entity.TransactionOverdraftInterest:
+		return "INT"
+	case entity.TransactionWithdrawalFee, entity.TransactionDepositFee, entity.TransactionTransferFee, entity.TransactionMaintenanceFee:
+		return "FEE"
+	}
+	if line.Amount < 0 {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
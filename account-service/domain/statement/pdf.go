@@ -0,0 +1,168 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The PDF is laid out as plain text on A4 pages using the standard Courier
+// and Helvetica fonts, which every reader provides, so no font has to be
+// embedded. A monospaced font keeps the table columns aligned.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 40
+	bodySize     = 8
+	headingSize  = 14
+	lineHeight   = 11
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+type pdfLine struct {
+	heading bool
+	text    string
+}
+
+// RenderPDF writes the statement as a PDF document.
+func (s *Statement) RenderPDF(w io.Writer) error {
+	var lines []pdfLine
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, pdfLine{text: fmt.Sprintf(format, args...)})
+	}
+	heading := func(text string) {
+		lines = append(lines, pdfLine{heading: true, text: text}, pdfLine{})
+	}
+
+	heading("Account statement")
+	add("Account %s (%s, %s)", s.AccountNumber, s.ProductType, s.Currency)
+	add("Period  %s to %s", s.From.Format("2006-01-02"), s.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	add("")
+	add("Opening balance %20s", s.Money(s.Opening))
+	add("Closing balance %20s", s.Money(s.Closing))
+	add("")
+	heading("Transactions")
+	add("%-16s %8s %-15s %-20s %18s %18s", "Date", "ID", "Type", "Reference", "Amount", "Balance")
+	add("%s", strings.Repeat("-", 100))
+	for _, line := range s.Lines {
+		add("%-16s %8d %-15s %-20s %18s %18s",
+			line.Date.Format("2006-01-02 15:04"), line.TransactionID, line.Type,
+			truncate(line.Reference, 20), s.Money(line.Amount), s.Money(line.Balance))
+	}
+	if len(s.Lines) == 0 {
+		add("No transactions in this period.")
+	}
+	add("")
+	heading("Totals by type")
+	add("%-16s %8s %18s", "Type", "Count", "Amount")
+	add("%s", strings.Repeat("-", 44))
+	for _, total := range s.Totals {
+		add("%-16s %8d %18s", total.Type, total.Count, s.Money(total.Amount))
+	}
+	add("")
+	add("Generated %s", s.GeneratedAt.Format("2006-01-02 15:04 MST"))
+
+	_, err := w.Write(buildPDF(lines))
+	return err
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "~"
+}
+
+// buildPDF paginates the lines and serializes the document. Objects 1 to 4
+// are the catalog, the page tree and the two fonts; every page adds a page
+// object and its content stream.
+func buildPDF(lines []pdfLine) []byte {
+	var pages [][]pdfLine
+	for len(lines) > 0 {
+		n := linesPerPage - 2 // room for the page footer
+		if n > len(lines) {
+			n = len(lines)
+		}
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		content := pageContent(page, i+1, len(pages))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pageContent(lines []pdfLine, page, pages int) string {
+	var b strings.Builder
+	y := pageHeight - pageMargin
+	for _, line := range lines {
+		y -= lineHeight
+		if line.text == "" {
+			continue
+		}
+		font, size := "F1", bodySize
+		if line.heading {
+			font, size = "F2", headingSize
+		}
+		fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, pageMargin, y, pdfString(line.text))
+	}
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d %d Td (%s) Tj ET", bodySize, pageMargin, pageMargin/2, pdfString("Page "+strconv.Itoa(page)+" of "+strconv.Itoa(pages)))
+	return b.String()
+}
+
+// pdfString escapes text for a PDF literal string. Characters outside Latin-1
+// cannot be shown with the standard fonts and are replaced.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "\\%03o", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+// Package statement builds account statements from the transaction history
+// and renders them as HTML or PDF.
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+// Formats a statement can be rendered in. HTML and PDF are for people; the
+// others are the bank standards accounting software imports.
+const (
+	FormatHTML    = "html"
+	FormatPDF     = "pdf"
+	FormatCAMT053 = "camt053"
+	FormatMT940   = "mt940"
+	FormatOFX     = "ofx"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("period must be a month in the form YYYY-MM")
+	ErrUnknownFormat = errors.New("statement format must be html, pdf, camt053, mt940 or ofx")
+)
+
+// BankID identifies the bank in the exports that need one, such as the OFX
+// BANKID. It is set at startup.
+var BankID = "000000000"
+
+// ValidFormat reports whether a statement can be rendered in format.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatHTML, FormatPDF, FormatCAMT053, FormatMT940, FormatOFX:
+		return true
+	}
+	return false
+}
+
+// Statement covers one account for the half-open period [From, To).
+type Statement struct {
+	AccountNumber string
+	ProductType   string
+	Currency      string
+	From          time.Time
+	To            time.Time
+	Opening       int64
+	Closing       int64
+	Lines         []Line
+	Totals        []Total
+	GeneratedAt   time.Time
+}
+
+// Line is one transaction of the period. Amount is signed: credits are
+// positive and debits negative. Balance is the balance after the line.
+type Line struct {
+	TransactionID uint
+	Date          time.Time
+	Type          string
+	Reference     string
+	Amount        int64
+	Balance       int64
+}
+
+// Total sums the lines of one transaction type.
+type Total struct {
+	Type   string
+	Count  int
+	Amount int64
+}
+
+// Month parses a period given as YYYY-MM into the first instant of that month
+// and of the next one, in UTC.
+func Month(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// New builds the statement of an account from its opening balance and the
+// transactions of the period, which must be in the order they were booked.
+func New(account *entity.Account, from, to time.Time, opening int64, transactions []entity.Transaction) *Statement {
+	s := &Statement{
+		AccountNumber: account.Number,
+		ProductType:   account.ProductType,
+		Currency:      account.Currency,
+		From:          from,
+		To:            to,
+		Opening:       opening,
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	balance := opening
+	totals := make(map[string]int)
+	for _, t := range transactions {
+		amount := t.Amount
+		if !entity.IsCredit(t.Type) {
+			amount = -amount
+		}
+		balance += amount
+		s.Lines = append(s.Lines, Line{
+			TransactionID: t.ID,
+			Date:          t.Date.UTC(),
+			Type:          t.Type,
+			Reference:     t.Reference,
+			Amount:        amount,
+			Balance:       balance,
+		})
+
+		i, ok := totals[t.Type]
+		if !ok {
+			i = len(s.Totals)
+			totals[t.Type] = i
+			s.Totals = append(s.Totals, Total{Type: t.Type})
+		}
+		s.Totals[i].Count++
+		s.Totals[i].Amount += amount
+	}
+	s.Closing = balance
+	return s
+}
+
+// Money formats an amount in the currency of the statement.
+func (s *Statement) Money(units int64) string {
+	return entity.Money{Units: units, Currency: s.Currency}.String()
+}
+
+// decimal formats the absolute value of an amount in the currency of the
+// statement without the currency code. The exports carry the sign apart.
+func (s *Statement) decimal(units int64) string {
+	if units < 0 {
+		units = -units
+	}
+	return entity.Money{Units: units, Currency: s.Currency}.Decimal()
+}
+
+// lastDay is the last day the statement covers.
+func (s *Statement) lastDay() time.Time {
+	return s.To.AddDate(0, 0, -1)
+}
+
+// Period describes the statement period for people, e.g. "September 2026".
+func (s *Statement) Period() string {
+	return s.From.Format("January 2006")
+}
+
+// Filename is a download name for the statement in the given format.
+func (s *Statement) Filename(format string) string {
+	extension := format
+	switch format {
+	case FormatCAMT053:
+		extension = "xml"
+	case FormatMT940:
+		extension = "sta"
+	}
+	return fmt.Sprintf("statement-%s-%s.%s", s.AccountNumber, s.From.Format("2006-01"), extension)
+}
+
+// ContentType returns the media type of a format.
+func ContentType(format string) string {
+	switch format {
+	case FormatPDF:
+		return "application/pdf"
+	case FormatCAMT053:
+		return "application/xml"
+	case FormatMT940:
+		return "text/plain; charset=us-ascii"
+	case FormatOFX:
+		return "application/x-ofx"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// Render writes the statement in the given format.
+func (s *Statement) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatHTML:
+		return s.RenderHTML(w)
+	case FormatPDF:
+		return s.RenderPDF(w)
+	case FormatCAMT053:
+		return s.RenderCAMT053(w)
+	case FormatMT940:
+		return s.RenderMT940(w)
+	case FormatOFX:
+		return s.RenderOFX(w)
+	}
+	return ErrUnknownFormat
+}
@@ -0,0 +1,77 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func fixture(t *testing.T) *Statement {
+	from, to, err := Month("2026-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := &entity.Account{ID: 42, CustomerID: 7, Number: entity.AccountNumber(42), ProductType: entity.ProductChecking, Currency: "USD"}
+	at := func(day, hour int) time.Time { return time.Date(2026, 9, day, hour, 30, 0, 0, time.UTC) }
+	reversed := uint(103)
+	transactions := []entity.Transaction{
+		{ID: 101, Type: entity.TransactionDeposit, Amount: 50000, Currency: "USD", Reference: "Salary September", Date: at(1, 9)},
+		{ID: 102, Type: entity.TransactionTransferOut, Amount: 120000, Currency: "USD", Reference: "Rent // flat 3", Date: at(3, 10)},
+		{ID: 103, Type: entity.TransactionWithdraw, Amount: 2550, Currency: "USD", Date: at(10, 14)},
+		{ID: 104, Type: entity.TransactionHoldCapture, Amount: 1999, Currency: "USD", Reference: "Café & Co <card>", Date: at(12, 18)},
+		{ID: 105, Type: entity.TransactionReversalCredit, Amount: 2550, Currency: "USD", ReversesTransactionID: &reversed, Date: at(15, 8)},
+		{ID: 106, Type: entity.TransactionTransferIn, Amount: 7500, Currency: "USD", Reference: "Refund", Date: at(28, 23)},
+	}
+	s := New(account, from, to, 100000, transactions)
+	s.GeneratedAt = time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)
+	return s
+}
+
+func TestNew(t *testing.T) {
+	s := fixture(t)
+	if s.Closing != 100000+50000-120000-2550-1999+2550+7500 {
+		t.Errorf("Unexpected closing balance %v", s.Closing)
+	}
+	if last := s.Lines[len(s.Lines)-1]; last.Balance != s.Closing {
+		t.Errorf("Expected the last running balance to be the closing balance, got %v", last.Balance)
+	}
+	if len(s.Totals) != 6 || s.Totals[0].Type != entity.TransactionDeposit || s.Totals[1].Amount != -120000 {
+		t.Errorf("Unexpected totals %+v", s.Totals)
+	}
+}
+
+func TestExportsMatchGoldenFiles(t *testing.T) {
+	for format, golden := range map[string]string{
+		FormatCAMT053: "statement.camt053.xml",
+		FormatMT940:   "statement.mt940",
+		FormatOFX:     "statement.ofx",
+	} {
+		t.Run(format, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := fixture(t).Render(&got, format); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", golden)
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("%s export differs from %s; run go test -update to accept it\n%s", format, path, got.String())
+			}
+		})
+	}
+}
@@ -2,20 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
 	repository "github.com/m-dehghani/account-service/domain/data"
-	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/fees"
+	"github.com/m-dehghani/account-service/domain/interest"
+	"github.com/m-dehghani/account-service/domain/reconciliation"
 	"github.com/m-dehghani/account-service/domain/services"
+	"github.com/m-dehghani/account-service/domain/statement"
 	pb "github.com/m-dehghani/account-service/proto"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// maxMessageSize is the largest request the server accepts, set above
+// gRPC's default of 4 MiB so that payout files of up to 8 MiB fit.
+const maxMessageSize = 16 << 20
+
 type Server struct {
 	accountService *services.AccountService
 }
@@ -25,6 +39,14 @@ func (s *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest
 	return s.accountService.CreateAccount(ctx, req)
 }
 
+func (s *Server) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.OpenAccountResponse, error) {
+	return s.accountService.OpenAccount(ctx, req)
+}
+
+func (s *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	return s.accountService.ListAccounts(ctx, req)
+}
+
 func (s *Server) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
 	return s.accountService.Withdraw(ctx, req)
 }
@@ -33,14 +55,108 @@ func (s *Server) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.Depos
 	return s.accountService.Deposit(ctx, req)
 }
 
+func (s *Server) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	return s.accountService.Transfer(ctx, req)
+}
+
+func (s *Server) PlaceHold(ctx context.Context, req *pb.PlaceHoldRequest) (*pb.PlaceHoldResponse, error) {
+	return s.accountService.PlaceHold(ctx, req)
+}
+
+func (s *Server) CaptureHold(ctx context.Context, req *pb.CaptureHoldRequest) (*pb.CaptureHoldResponse, error) {
+	return s.accountService.CaptureHold(ctx, req)
+}
+
+func (s *Server) ReleaseHold(ctx context.Context, req *pb.ReleaseHoldRequest) (*pb.ReleaseHoldResponse, error) {
+	return s.accountService.ReleaseHold(ctx, req)
+}
+
+func (s *Server) ReverseTransaction(ctx context.Context, req *pb.ReverseTransactionRequest) (*pb.ReverseTransactionResponse, error) {
+	return s.accountService.ReverseTransaction(ctx, req)
+}
+
+func (s *Server) SetOverdraft(ctx context.Context, req *pb.SetOverdraftRequest) (*pb.SetOverdraftResponse, error) {
+	return s.accountService.SetOverdraft(ctx, req)
+}
+
+func (s *Server) SetWithdrawalLimit(ctx context.Context, req *pb.SetWithdrawalLimitRequest) (*pb.SetWithdrawalLimitResponse, error) {
+	return s.accountService.SetWithdrawalLimit(ctx, req)
+}
+
+func (s *Server) CreateStandingOrder(ctx context.Context, req *pb.CreateStandingOrderRequest) (*pb.CreateStandingOrderResponse, error) {
+	return s.accountService.CreateStandingOrder(ctx, req)
+}
+
+func (s *Server) ListStandingOrders(ctx context.Context, req *pb.ListStandingOrdersRequest) (*pb.ListStandingOrdersResponse, error) {
+	return s.accountService.ListStandingOrders(ctx, req)
+}
+
+func (s *Server) CancelStandingOrder(ctx context.Context, req *pb.CancelStandingOrderRequest) (*pb.CancelStandingOrderResponse, error) {
+	return s.accountService.CancelStandingOrder(ctx, req)
+}
+
+func (s *Server) StandingOrderExecutions(ctx context.Context, req *pb.StandingOrderExecutionsRequest) (*pb.StandingOrderExecutionsResponse, error) {
+	return s.accountService.StandingOrderExecutions(ctx, req)
+}
+
+func (s *Server) ChangeAccountStatus(ctx context.Context, req *pb.ChangeAccountStatusRequest) (*pb.ChangeAccountStatusResponse, error) {
+	return s.accountService.ChangeAccountStatus(ctx, req)
+}
+
+func (s *Server) AccountStatusHistory(ctx context.Context, req *pb.AccountStatusHistoryRequest) (*pb.AccountStatusHistoryResponse, error) {
+	return s.accountService.AccountStatusHistory(ctx, req)
+}
+
 func (s *Server) BalanceInquiry(ctx context.Context, req *pb.BalanceInquiryRequest) (*pb.BalanceInquiryResponse, error) {
 	return s.accountService.BalanceInquiry(ctx, req)
 }
 
+func (s *Server) BalanceAsOf(ctx context.Context, req *pb.BalanceAsOfRequest) (*pb.BalanceAsOfResponse, error) {
+	return s.accountService.BalanceAsOf(ctx, req)
+}
+
+func (s *Server) VerifyTransactionChain(ctx context.Context, req *pb.VerifyTransactionChainRequest) (*pb.VerifyTransactionChainResponse, error) {
+	return s.accountService.VerifyTransactionChain(ctx, req)
+}
+
 func (s *Server) TransactionHistory(ctx context.Context, req *pb.TransactionHistoryRequest) (*pb.TransactionHistoryResponse, error) {
 	return s.accountService.TransactionHistory(ctx, req)
 }
 
+func (s *Server) StreamTransactions(req *pb.StreamTransactionsRequest, stream pb.AccountService_StreamTransactionsServer) error {
+	err := s.accountService.StreamTransactions(stream.Context(), req, stream.Send)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case services.IsInvalidRequest(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, "streaming transactions failed")
+	}
+}
+
+func (s *Server) GetStatement(ctx context.Context, req *pb.GetStatementRequest) (*pb.GetStatementResponse, error) {
+	return s.accountService.GetStatement(ctx, req)
+}
+
+func (s *Server) Reconcile(ctx context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	return s.accountService.Reconcile(ctx, req)
+}
+
+func (s *Server) GetReconciliation(ctx context.Context, req *pb.GetReconciliationRequest) (*pb.ReconcileResponse, error) {
+	return s.accountService.GetReconciliation(ctx, req)
+}
+
+func (s *Server) SubmitPayoutBatch(ctx context.Context, req *pb.SubmitPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	return s.accountService.SubmitPayoutBatch(ctx, req)
+}
+
+func (s *Server) GetPayoutBatch(ctx context.Context, req *pb.GetPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	return s.accountService.GetPayoutBatch(ctx, req)
+}
+
 func main() {
 	dsn := "host=" + os.Getenv("POSTGRES_HOST") + " user=" + os.Getenv("POSTGRES_USER") + " password=" + os.Getenv("POSTGRES_PASSWORD") + " dbname=" + os.Getenv("POSTGRES_DB") + " port=5432 sslmode=disable"
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -48,19 +164,96 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db.AutoMigrate(&entity.Account{}, &entity.Transaction{})
+	if bankID := os.Getenv("BANK_ID"); bankID != "" {
+		statement.BankID = bankID
+	}
 
-	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	repo := repository.NewAccountRepository(db)
+	accountService := services.NewAccountService(repo)
+
+	// verify only reads, so it runs before anything below migrates the
+	// schema or the data it is meant to check.
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(accountService, os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	if err := repository.Migrate(db); err != nil {
+		log.Fatal(err)
+	}
+	if path := os.Getenv("INTEREST_CONFIG"); path != "" {
+		config, err := interest.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		accountService.SetInterestConfig(config)
+	}
+	if path := os.Getenv("FEES_CONFIG"); path != "" {
+		config, err := fees.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		accountService.SetFeeConfig(config)
+	}
+	if path := os.Getenv("RECONCILIATION_CONFIG"); path != "" {
+		config, err := reconciliation.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		accountService.SetReconciliationConfig(config)
+	}
+	if value := os.Getenv("DORMANCY_MONTHS"); value != "" {
+		months, err := strconv.Atoi(value)
+		if err != nil || months < 0 {
+			log.Fatalf("invalid DORMANCY_MONTHS %q", value)
+		}
+		accountService.SetDormancyMonths(months)
+	}
+	if err := accountService.MigrateLegacyBalances(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := accountService.ImportAccountStreams(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-projections" {
+		os.Exit(runRebuildProjections(accountService, os.Stdout, os.Stderr))
+	}
+
+	go accountService.RunHoldExpiry(context.Background(), time.Minute)
+	go accountService.RunInterest(context.Background(), time.Hour)
+	go accountService.RunFees(context.Background(), time.Hour)
+	go accountService.RunStandingOrders(context.Background(), time.Minute)
+	go accountService.RunPayoutBatches(context.Background(), 10*time.Second)
+	go accountService.RunDormancy(context.Background(), time.Hour)
+	go accountService.RunBalanceSnapshots(context.Background(), time.Hour)
+	go accountService.RunChainAnchors(context.Background(), time.Hour)
+
+	if host := os.Getenv("RABBITMQ_HOST"); host != "" {
+		publisher := events.NewAMQPPublisher(rabbitMQURL(host), events.DefaultExchange)
+		defer publisher.Close()
+		go services.NewOutboxRelay(repo, publisher).Run(context.Background(), time.Second)
+	} else {
+		log.Println("RABBITMQ_HOST is not set; events stay in the outbox")
+	}
 
 	lis, err := net.Listen("tcp", ":50052")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize))
 	pb.RegisterAccountServiceServer(s, &Server{accountService: accountService})
 
 	if err := s.Serve(lis); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func rabbitMQURL(host string) string {
+	user, password := os.Getenv("RABBITMQ_USER"), os.Getenv("RABBITMQ_PASSWORD")
+	if user == "" {
+		user, password = "guest", "guest"
+	}
+	broker := url.URL{Scheme: "amqp", User: url.UserPassword(user, password), Host: host + ":5672", Path: "/"}
+	return broker.String()
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/m-dehghani/account-service/domain/services"
+)
+
+// runRebuildProjections implements "account-service rebuild-projections": it
+// rebuilds the balance and history projections of every account from the
+// event store and returns the exit code.
+func runRebuildProjections(accountService *services.AccountService, stdout, stderr io.Writer) int {
+	rebuilt, err := accountService.RebuildProjections(context.Background())
+	if err != nil {
+		fmt.Fprintf(stderr, "rebuild-projections: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "rebuilt the projections of %d accounts\n", rebuilt)
+	return 0
+}
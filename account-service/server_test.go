@@ -1,11 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/m-dehghani/account-service/domain/aggregate"
 	repository "github.com/m-dehghani/account-service/domain/data"
 	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/events"
+	"github.com/m-dehghani/account-service/domain/fees"
+	"github.com/m-dehghani/account-service/domain/interest"
 	"github.com/m-dehghani/account-service/domain/services"
 
 	pb "github.com/m-dehghani/account-service/proto"
@@ -15,10 +28,27 @@ import (
 
 func setupTestDB() *gorm.DB {
 	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	db.AutoMigrate(&entity.Account{}, &entity.Transaction{})
+	repository.Migrate(db)
 	return db
 }
 
+// setupFileDB returns a database of its own, for tests that must not see the
+// rows of other tests or that need real concurrent connections.
+func setupFileDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "accounts.db")+"?_busy_timeout=5000"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := repository.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func usd(cents int64) *pb.Money {
+	return &pb.Money{Units: cents, Currency: "USD"}
+}
+
 func TestCreateAccount(t *testing.T) {
 	db := setupTestDB()
 	accountService := services.NewAccountService(repository.NewAccountRepository(db))
@@ -35,7 +65,7 @@ func TestCreateAccount(t *testing.T) {
 		t.Errorf("Error in account creation")
 	}
 
-	_, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1})
+	_, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: resp.AccountNumber})
 	if errB != nil {
 		t.Errorf("Error in account creation")
 	}
@@ -47,26 +77,26 @@ func TestWithdraw(t *testing.T) {
 	s := &Server{accountService: accountService}
 
 	// Create an account first
-	s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
 
-	_, errD := s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, Amount: 1000})
+	_, errD := s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
 
 	if errD != nil {
 		t.Fatalf("error in deposit")
 	}
-	req := &pb.WithdrawRequest{Customerid: 1, Amount: 500}
+	req := &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50000)}
 	_, errW := s.Withdraw(context.Background(), req)
 	if errW != nil {
 		t.Fatalf("Withdraw failed: %v", errW)
 	}
 
-	balanceResp, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1})
+	balanceResp, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
 
 	if errB != nil {
 		t.Error(errB.Error())
 	}
-	if balanceResp.Balance != 500 {
-		t.Errorf("Expected new balance to be 500, got %v", balanceResp.Balance)
+	if balanceResp.Balance.Units != 50000 {
+		t.Errorf("Expected new balance to be 50000, got %v", balanceResp.Balance.Units)
 	}
 }
 
@@ -76,27 +106,27 @@ func TestDeposit(t *testing.T) {
 	s := &Server{accountService: accountService}
 
 	// Create an account first
-	s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
 
-	req := &pb.DepositRequest{Customerid: 1, Amount: 500}
+	req := &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50000)}
 	_, err := s.Deposit(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Deposit failed: %v", err)
 	}
-	req2 := &pb.DepositRequest{Customerid: 1, Amount: 1000}
+	req2 := &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)}
 	_, err2 := s.Deposit(context.Background(), req2)
 	if err2 != nil {
 		t.Fatalf("Deposit failed: %v", err2)
 	}
 
-	balanceResp, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1})
+	balanceResp, errB := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
 
 	if errB != nil {
 		t.Error(errB.Error())
 	}
 
-	if balanceResp.Balance != 2000 {
-		t.Errorf("Expected new balance to be 2000, got %v", balanceResp.Balance)
+	if balanceResp.Balance.Units != 200000 {
+		t.Errorf("Expected new balance to be 200000, got %v", balanceResp.Balance.Units)
 	}
 }
 
@@ -106,22 +136,22 @@ func TestBalanceInquiry(t *testing.T) {
 	s := &Server{accountService: accountService}
 
 	// Create an account first
-	s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
 
-	req2 := &pb.DepositRequest{Customerid: 1, Amount: 1000}
+	req2 := &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)}
 	_, err2 := s.Deposit(context.Background(), req2)
 	if err2 != nil {
 		t.Fatalf("Deposit failed: %v", err2)
 	}
 
-	req := &pb.BalanceInquiryRequest{Customerid: 1}
+	req := &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber}
 	resp, err := s.BalanceInquiry(context.Background(), req)
 	if err != nil {
 		t.Fatalf("BalanceInquiry failed: %v", err)
 	}
 
-	if resp.Balance != 3000 {
-		t.Errorf("Expected balance to be 3000, got %v", resp.Balance)
+	if resp.Balance.Units != 300000 {
+		t.Errorf("Expected balance to be 300000, got %v", resp.Balance.Units)
 	}
 }
 
@@ -131,11 +161,11 @@ func TestTransactionHistory(t *testing.T) {
 	s := &Server{accountService: accountService}
 
 	// Create an account first
-	s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
 
 	// Perform some transactions
-	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, Amount: 500})
-	s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 1, Amount: 200})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50000)})
+	s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(20000)})
 
 	req := &pb.TransactionHistoryRequest{Customerid: 1}
 	resp, err := s.TransactionHistory(context.Background(), req)
@@ -147,3 +177,1740 @@ func TestTransactionHistory(t *testing.T) {
 		t.Errorf("Expected 7 transactions, got %v", len(resp.Transactions))
 	}
 }
+
+func TestLedgerJournalsBalance(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 2, AccountNumber: account.AccountNumber, Amount: usd(30000)})
+	s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 2, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+
+	var journals []entity.JournalEntry
+	if err := db.Preload("Postings").Find(&journals).Error; err != nil {
+		t.Fatalf("failed to load journals: %v", err)
+	}
+	for _, journal := range journals {
+		if err := journal.Validate(); err != nil {
+			t.Errorf("journal %d: %v", journal.ID, err)
+		}
+	}
+
+	resp, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 2, AccountNumber: account.AccountNumber})
+	if resp.Balance.Units != 20000 {
+		t.Errorf("Expected balance to be 20000, got %v", resp.Balance.Units)
+	}
+}
+
+func TestLedgerRejectsUnbalancedJournal(t *testing.T) {
+	db := setupTestDB()
+
+	journal := entity.JournalEntry{
+		Description: "unbalanced",
+		Postings: []entity.Posting{
+			{LedgerAccountID: 1, Amount: 10000, Currency: "USD"},
+			{LedgerAccountID: 2, Amount: -9000, Currency: "USD"},
+		},
+	}
+	if err := db.Create(&journal).Error; err != entity.ErrUnbalancedJournal {
+		t.Errorf("Expected unbalanced journal to be rejected, got %v", err)
+	}
+}
+
+func TestDepositRejectsCurrencyMismatch(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 3, Currency: "EUR"})
+
+	resp, err := s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 3, AccountNumber: account.AccountNumber, Amount: usd(1000)})
+	if err != nil {
+		t.Fatalf("Deposit failed: %v", err)
+	}
+	if resp.Success {
+		t.Errorf("Expected USD deposit into EUR account to be rejected")
+	}
+}
+
+func TestMigrateRescalesFloatAmounts(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file:legacy?mode=memory"), &gorm.Config{})
+	db.Exec("CREATE TABLE accounts (id integer PRIMARY KEY, customer_id integer, balance real)")
+	db.Exec("CREATE TABLE transactions (id integer PRIMARY KEY, customer_id integer, type text, amount real, date datetime)")
+	db.Exec("INSERT INTO accounts (id, customer_id, balance) VALUES (1, 7, 12.34)")
+	db.Exec("INSERT INTO transactions (id, customer_id, type, amount) VALUES (1, 7, 'deposit', 12.34)")
+	db.Exec("INSERT INTO transactions (id, customer_id, type, amount) VALUES (2, 7, 'withdraw', 0)")
+
+	// The second run finds integer columns and leaves the amounts alone.
+	for i := 0; i < 2; i++ {
+		if err := repository.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+	}
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	if err := s.accountService.MigrateLegacyBalances(context.Background()); err != nil {
+		t.Fatalf("MigrateLegacyBalances failed: %v", err)
+	}
+	if _, err := s.accountService.ImportAccountStreams(context.Background()); err != nil {
+		t.Fatalf("ImportAccountStreams failed: %v", err)
+	}
+
+	var transaction entity.Transaction
+	db.First(&transaction, 1)
+	if transaction.Amount != 1234 || transaction.Currency != entity.DefaultCurrency {
+		t.Errorf("Expected 1234 %s, got %d %s", entity.DefaultCurrency, transaction.Amount, transaction.Currency)
+	}
+	// The legacy rows are chained before their sequence numbers are made
+	// unique.
+	var transactions []entity.Transaction
+	db.Where("account_id = ?", transaction.AccountID).Order("sequence").Find(&transactions)
+	if len(transactions) != 2 || transactions[0].Sequence != 1 || transactions[1].Sequence != 2 {
+		t.Errorf("Expected the legacy transactions to be chained, got %+v", transactions)
+	}
+	duplicate := transactions[1]
+	duplicate.ID, duplicate.Sequence = 0, 1
+	if err := db.Session(&gorm.Session{SkipHooks: true}).Create(&duplicate).Error; err == nil {
+		t.Errorf("Expected a duplicate sequence number to be rejected")
+	}
+
+	resp, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 7, AccountNumber: entity.AccountNumber(1)})
+	if resp.Balance.GetUnits() != 1234 {
+		t.Errorf("Expected migrated balance to be 1234, got %v", resp.Balance.GetUnits())
+	}
+}
+
+func TestImportCarriesLegacyBalance(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file:legacy-import?mode=memory"), &gorm.Config{})
+	db.Exec("CREATE TABLE accounts (id integer PRIMARY KEY, customer_id integer, balance real)")
+	db.Exec("CREATE TABLE transactions (id integer PRIMARY KEY, customer_id integer, type text, amount real, date datetime)")
+	// Only the last two transactions survived; the rest of the balance was
+	// carried over from before them.
+	db.Exec("INSERT INTO accounts (id, customer_id, balance) VALUES (1, 7, 100)")
+	db.Exec("INSERT INTO transactions (id, customer_id, type, amount) VALUES (1, 7, 'deposit', 30)")
+	db.Exec("INSERT INTO transactions (id, customer_id, type, amount) VALUES (2, 7, 'withdraw', 10)")
+
+	if err := repository.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	if err := s.accountService.MigrateLegacyBalances(context.Background()); err != nil {
+		t.Fatalf("MigrateLegacyBalances failed: %v", err)
+	}
+	if _, err := s.accountService.ImportAccountStreams(context.Background()); err != nil {
+		t.Fatalf("ImportAccountStreams failed: %v", err)
+	}
+
+	var running []int64
+	db.Model(&entity.HistoryEntry{}).Where("account_id = ?", 1).Order("transaction_id").Pluck("balance_after", &running)
+	if len(running) != 2 || running[0] != 11000 || running[1] != 10000 {
+		t.Errorf("Expected running balances from the legacy balance, got %v", running)
+	}
+	resp, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 7, AccountNumber: entity.AccountNumber(1)})
+	if resp.Balance.GetUnits() != 10000 {
+		t.Errorf("Expected imported balance to be 10000, got %v", resp.Balance.GetUnits())
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	account4, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 4})
+	account5, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 5})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 4, AccountNumber: account4.AccountNumber, Amount: usd(10000)})
+
+	resp, err := s.Transfer(context.Background(), &pb.TransferRequest{FromCustomerid: 4, FromAccountNumber: account4.AccountNumber, ToAccountNumber: account5.AccountNumber, Amount: usd(4000), Reference: "rent"})
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Transfer failed: %v", resp.Message)
+	}
+
+	from, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 4, AccountNumber: account4.AccountNumber})
+	to, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 5, AccountNumber: account5.AccountNumber})
+	if from.Balance.Units != 6000 || to.Balance.Units != 4000 {
+		t.Errorf("Expected balances 6000 and 4000, got %v and %v", from.Balance.Units, to.Balance.Units)
+	}
+
+	history, _ := s.TransactionHistory(context.Background(), &pb.TransactionHistoryRequest{Customerid: 5})
+	if len(history.Transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got %v", len(history.Transactions))
+	}
+	in := history.Transactions[0]
+	if in.Type != entity.TransactionTransferIn || in.LinkedTransactionId == 0 || in.Reference != "rent" {
+		t.Errorf("Expected a linked transfer_in, got %v", in)
+	}
+}
+
+func TestTransferInsufficientFunds(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	account6, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 6})
+	account7, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 7})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 6, AccountNumber: account6.AccountNumber, Amount: usd(1000)})
+
+	resp, _ := s.Transfer(context.Background(), &pb.TransferRequest{FromCustomerid: 6, FromAccountNumber: account6.AccountNumber, ToAccountNumber: account7.AccountNumber, Amount: usd(5000)})
+	if resp.Success || resp.Message != services.ErrInsufficientFunds.Error() {
+		t.Errorf("Expected insufficient funds, got %v", resp.Message)
+	}
+
+	to, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 7, AccountNumber: account7.AccountNumber})
+	if to.Balance.Units != 0 {
+		t.Errorf("Expected balance to be 0, got %v", to.Balance.Units)
+	}
+}
+
+func TestOpenAndListAccounts(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	checking, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 8})
+	savings, err := s.OpenAccount(context.Background(), &pb.OpenAccountRequest{Customerid: 8, ProductType: entity.ProductSavings, Currency: "EUR"})
+	if err != nil || !savings.Success {
+		t.Fatalf("OpenAccount failed: %v %v", err, savings.GetMessage())
+	}
+	if !entity.ValidAccountNumber(savings.Account.Number) || savings.Account.Number == checking.AccountNumber {
+		t.Errorf("Expected a distinct valid account number, got %v", savings.Account.Number)
+	}
+
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 8, AccountNumber: savings.Account.Number, Amount: &pb.Money{Units: 2500, Currency: "EUR"}})
+
+	list, _ := s.ListAccounts(context.Background(), &pb.ListAccountsRequest{Customerid: 8})
+	if len(list.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %v", len(list.Accounts))
+	}
+	if list.Accounts[0].Balance.Units != 0 || list.Accounts[1].Balance.Units != 2500 {
+		t.Errorf("Expected balances 0 and 2500, got %v and %v", list.Accounts[0].Balance.Units, list.Accounts[1].Balance.Units)
+	}
+
+	again, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 8})
+	if again.AccountNumber != checking.AccountNumber {
+		t.Errorf("Expected CreateAccount to return the default account %v, got %v", checking.AccountNumber, again.AccountNumber)
+	}
+}
+
+func TestDepositRejectsForeignAccount(t *testing.T) {
+	db := setupTestDB()
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	other, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 9})
+
+	resp, _ := s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 10, AccountNumber: other.AccountNumber, Amount: usd(100)})
+	if resp.Success || resp.Message != services.ErrAccountNotFound.Error() {
+		t.Errorf("Expected account not found, got %v", resp.Message)
+	}
+}
+
+func TestConcurrentWithdrawalsDoNotDoubleSpend(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+
+	const withdrawals = 10
+	var succeeded int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < withdrawals; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, _ := s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(3000)})
+			if resp.Success {
+				atomic.AddInt64(&succeeded, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if succeeded == 0 || succeeded > 3 {
+		t.Errorf("Expected between 1 and 3 withdrawals to succeed, got %v", succeeded)
+	}
+	balance, _ := s.BalanceInquiry(context.Background(), &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.Balance.Units != 10000-3000*succeeded {
+		t.Errorf("Expected balance %v after %v withdrawals, got %v", 10000-3000*succeeded, succeeded, balance.Balance.Units)
+	}
+}
+
+func TestStaleAccountVersionConflicts(t *testing.T) {
+	db := setupTestDB()
+	repo := repository.NewAccountRepository(db)
+	s := &Server{accountService: services.NewAccountService(repo)}
+
+	created, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 11})
+	first, _ := repo.GetAccountByNumber(context.Background(), created.AccountNumber)
+	second, _ := repo.GetAccountByNumber(context.Background(), created.AccountNumber)
+
+	if err := repo.BumpAccountVersion(context.Background(), first); err != nil {
+		t.Fatalf("BumpAccountVersion failed: %v", err)
+	}
+	if err := repo.BumpAccountVersion(context.Background(), second); !errors.Is(err, repository.ErrConcurrentUpdate) {
+		t.Errorf("Expected ErrConcurrentUpdate for a stale account, got %v", err)
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, message events.Message) error {
+	return errors.New("broker unavailable")
+}
+
+func (failingPublisher) Close() error { return nil }
+
+func TestEventsArePublishedFromOutbox(t *testing.T) {
+	db := setupFileDB(t)
+	repo := repository.NewAccountRepository(db)
+	s := &Server{accountService: services.NewAccountService(repo)}
+
+	account, _ := s.CreateAccount(context.Background(), &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(context.Background(), &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1000)})
+	s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(5000)})
+	s.Withdraw(context.Background(), &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(400)})
+
+	if _, err := services.NewOutboxRelay(repo, failingPublisher{}).PublishPending(context.Background()); err == nil {
+		t.Fatalf("Expected the failing publisher to report an error")
+	}
+
+	publisher := events.NewMemoryPublisher()
+	relay := services.NewOutboxRelay(repo, publisher)
+	published, err := relay.PublishPending(context.Background())
+	if err != nil {
+		t.Fatalf("PublishPending failed: %v", err)
+	}
+	if published != 3 {
+		t.Fatalf("Expected 3 events, the failed withdraw recording none, got %v", published)
+	}
+
+	messages := publisher.Messages()
+	for i, want := range []string{events.TypeAccountCreated, events.TypeDeposited, events.TypeWithdrawn} {
+		if messages[i].Type != want || messages[i].AggregateID != account.AccountNumber {
+			t.Errorf("Expected event %v for %v, got %v for %v", want, account.AccountNumber, messages[i].Type, messages[i].AggregateID)
+		}
+	}
+	var deposited events.Deposited
+	if err := json.Unmarshal(messages[1].Payload, &deposited); err != nil {
+		t.Fatalf("decode Deposited: %v", err)
+	}
+	if deposited.Amount != (events.Amount{Units: 1000, Currency: "USD"}) || deposited.CustomerID != 1 {
+		t.Errorf("Unexpected Deposited payload %+v", deposited)
+	}
+
+	if published, _ := relay.PublishPending(context.Background()); published != 0 {
+		t.Errorf("Expected published events not to be sent again, got %v", published)
+	}
+}
+
+func TestHolds(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+
+	hold, _ := s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(6000), Reference: "card 1234"})
+	if !hold.Success {
+		t.Fatalf("PlaceHold failed: %v", hold.Message)
+	}
+	balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.Balance.Units != 10000 || balance.AvailableBalance.Units != 4000 {
+		t.Errorf("Expected ledger 10000 and available 4000, got %v and %v", balance.Balance.Units, balance.AvailableBalance.Units)
+	}
+
+	withdraw, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(5000)})
+	if withdraw.Success || withdraw.Message != services.ErrInsufficientFunds.Error() {
+		t.Errorf("Expected withdraw beyond the available balance to fail, got %v", withdraw.Message)
+	}
+	if second, _ := s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(5000)}); second.Success {
+		t.Errorf("Expected hold beyond the available balance to fail")
+	}
+	if foreign, _ := s.CaptureHold(ctx, &pb.CaptureHoldRequest{Customerid: 2, HoldId: hold.HoldId}); foreign.Message != services.ErrHoldNotFound.Error() {
+		t.Errorf("Expected hold of another customer not to be found, got %v", foreign.Message)
+	}
+
+	capture, _ := s.CaptureHold(ctx, &pb.CaptureHoldRequest{Customerid: 1, HoldId: hold.HoldId, Amount: usd(2500)})
+	if !capture.Success {
+		t.Fatalf("CaptureHold failed: %v", capture.Message)
+	}
+	balance, _ = s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.Balance.Units != 7500 || balance.AvailableBalance.Units != 7500 {
+		t.Errorf("Expected the uncaptured rest to be released, got ledger %v and available %v", balance.Balance.Units, balance.AvailableBalance.Units)
+	}
+	if again, _ := s.CaptureHold(ctx, &pb.CaptureHoldRequest{Customerid: 1, HoldId: hold.HoldId}); again.Message != entity.ErrHoldNotActive.Error() {
+		t.Errorf("Expected a captured hold not to be captured again, got %v", again.Message)
+	}
+
+	released, _ := s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1000)})
+	if resp, _ := s.ReleaseHold(ctx, &pb.ReleaseHoldRequest{Customerid: 1, HoldId: released.HoldId}); !resp.Success {
+		t.Errorf("ReleaseHold failed: %v", resp.Message)
+	}
+
+	s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(3000)})
+	expired, err := s.accountService.ExpireHolds(ctx, time.Now().Add(services.DefaultHoldLifetime+time.Hour))
+	if err != nil || expired != 1 {
+		t.Errorf("Expected 1 hold to expire, got %v (%v)", expired, err)
+	}
+	balance, _ = s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.AvailableBalance.Units != 7500 {
+		t.Errorf("Expected released and expired holds to free their funds, got available %v", balance.AvailableBalance.Units)
+	}
+}
+
+func TestReverseTransaction(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	from, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	to, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: from.AccountNumber, Amount: usd(10000)})
+	s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(4000)})
+
+	history, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 2})
+	transferIn := history.Transactions[0]
+
+	if resp, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: transferIn.Id, ReasonCode: "oops", Actor: "support"}); resp.Message != entity.ErrUnknownReason.Error() {
+		t.Errorf("Expected unknown reason code to be rejected, got %v", resp.Message)
+	}
+	reversed, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: transferIn.Id, ReasonCode: entity.ReasonOperatorError, Actor: "support"})
+	if !reversed.Success {
+		t.Fatalf("ReverseTransaction failed: %v", reversed.Message)
+	}
+	if again, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: transferIn.Id, ReasonCode: entity.ReasonOperatorError, Actor: "support"}); again.Message != services.ErrAlreadyReversed.Error() {
+		t.Errorf("Expected a second reversal to be refused, got %v", again.Message)
+	}
+	if nested, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: reversed.ReversalTransactionId, ReasonCode: entity.ReasonOperatorError, Actor: "support"}); nested.Message != services.ErrReverseReversal.Error() {
+		t.Errorf("Expected a reversal not to be reversible, got %v", nested.Message)
+	}
+
+	fromBalance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: from.AccountNumber})
+	toBalance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 2, AccountNumber: to.AccountNumber})
+	if fromBalance.Balance.Units != 10000 || toBalance.Balance.Units != 0 {
+		t.Errorf("Expected the whole transfer to be undone, got %v and %v", fromBalance.Balance.Units, toBalance.Balance.Units)
+	}
+
+	history, _ = s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 2})
+	if len(history.Transactions) != 2 {
+		t.Fatalf("Expected the transfer and its reversal, got %v transactions", len(history.Transactions))
+	}
+	original, reversal := history.Transactions[0], history.Transactions[1]
+	if original.ReversedByTransactionId != reversal.Id || reversal.ReversesTransactionId != original.Id {
+		t.Errorf("Expected the reversal to be linked both ways, got %v and %v", original.ReversedByTransactionId, reversal.ReversesTransactionId)
+	}
+	if reversal.Type != entity.TransactionReversalDebit || reversal.ReasonCode != entity.ReasonOperatorError || reversal.Actor != "support" {
+		t.Errorf("Unexpected reversal %+v", reversal)
+	}
+
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: from.AccountNumber, Amount: usd(9000)})
+	history, _ = s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1})
+	deposit := history.Transactions[0]
+	if resp, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: deposit.Id, ReasonCode: entity.ReasonDuplicate, Actor: "support"}); resp.Message != services.ErrInsufficientFunds.Error() {
+		t.Errorf("Expected reversing a spent deposit to fail, got %v", resp.Message)
+	}
+}
+
+func TestTransactionHistoryPaging(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	for i := int64(1); i <= 4; i++ {
+		s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(i * 100)})
+	}
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50)})
+
+	var ids []uint32
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("Expected paging to end after 3 pages")
+		}
+		page, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, Limit: 2, Cursor: cursor})
+		for _, tx := range page.Transactions {
+			ids = append(ids, tx.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(ids) != 5 {
+		t.Fatalf("Expected 5 transactions over all pages, got %v", len(ids))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Errorf("Expected transactions in booking order, got %v", ids)
+		}
+	}
+
+	withdrawals, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, Type: entity.TransactionWithdraw})
+	if len(withdrawals.Transactions) != 1 || withdrawals.Transactions[0].Amount.Units != 50 {
+		t.Errorf("Expected only the withdrawal, got %v", withdrawals.Transactions)
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+	if inRange, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, From: today, To: today}); len(inRange.Transactions) != 5 {
+		t.Errorf("Expected today's 5 transactions, got %v", len(inRange.Transactions))
+	}
+	if later, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, From: tomorrow}); len(later.Transactions) != 0 {
+		t.Errorf("Expected no transactions from tomorrow on, got %v", len(later.Transactions))
+	}
+
+	if invalid, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, Cursor: "not a cursor"}); invalid.Message != services.ErrInvalidCursor.Error() {
+		t.Errorf("Expected invalid cursor to be rejected, got %v", invalid.Message)
+	}
+}
+
+func TestStreamTransactions(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	for i := 0; i < 3; i++ {
+		s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100)})
+	}
+	req := &pb.StreamTransactionsRequest{Customerid: 1, AccountNumber: account.AccountNumber}
+
+	var streamed []*pb.Transaction
+	err := s.accountService.StreamTransactions(ctx, req, func(tx *pb.Transaction) error {
+		streamed = append(streamed, tx)
+		return nil
+	})
+	if err != nil || len(streamed) != 3 {
+		t.Fatalf("Expected 3 streamed transactions, got %v (%v)", len(streamed), err)
+	}
+
+	gone := errors.New("client went away")
+	sent := 0
+	err = s.accountService.StreamTransactions(ctx, req, func(tx *pb.Transaction) error {
+		sent++
+		return gone
+	})
+	if !errors.Is(err, gone) || sent != 1 {
+		t.Errorf("Expected streaming to stop at the first failed send, sent %v (%v)", sent, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = s.accountService.StreamTransactions(cancelled, req, func(tx *pb.Transaction) error {
+		t.Errorf("Expected nothing to be sent after cancellation")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGetStatement(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+
+	// Move the first deposit into the previous month.
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Add(-24 * time.Hour)
+	db.Exec("UPDATE transactions SET date = ?", lastMonth)
+	db.Exec("UPDATE journal_entries SET date = ?", lastMonth)
+
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(2500)})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1000)})
+
+	res, err := s.GetStatement(ctx, &pb.GetStatementRequest{Customerid: 1, AccountNumber: account.AccountNumber, Period: now.Format("2006-01")})
+	if err != nil || !res.Success {
+		t.Fatalf("Expected statement, got %v (%v)", res, err)
+	}
+	if res.OpeningBalance.Units != 10000 || res.ClosingBalance.Units != 11500 {
+		t.Errorf("Expected opening 10000 and closing 11500, got %v and %v", res.OpeningBalance.Units, res.ClosingBalance.Units)
+	}
+	html := string(res.Content)
+	for _, want := range []string{account.AccountNumber, "100.00 USD", "125.00 USD", "115.00 USD", "-10.00 USD"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected HTML statement to contain %q", want)
+		}
+	}
+
+	res, _ = s.GetStatement(ctx, &pb.GetStatementRequest{Customerid: 1, AccountNumber: account.AccountNumber, Period: now.Format("2006-01"), Format: "pdf"})
+	if !res.Success || res.ContentType != "application/pdf" || !bytes.HasPrefix(res.Content, []byte("%PDF-")) || !bytes.Contains(res.Content, []byte("%%EOF")) {
+		t.Errorf("Expected a PDF statement, got %v", res.Message)
+	}
+
+	res, _ = s.GetStatement(ctx, &pb.GetStatementRequest{Customerid: 1, AccountNumber: account.AccountNumber, Period: "2026-13"})
+	if res.Success {
+		t.Errorf("Expected an invalid period to be rejected")
+	}
+	res, _ = s.GetStatement(ctx, &pb.GetStatementRequest{Customerid: 2, AccountNumber: account.AccountNumber, Period: now.Format("2006-01")})
+	if res.Success {
+		t.Errorf("Expected another customer's statement to be refused")
+	}
+}
+
+func TestInterestAccrualCatchesUpAndPostsMonthly(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	config, err := interest.Parse(strings.NewReader(`{"products": {"savings": {"withholding_tax_bp": 2500, "schedules": [
+		{"effective_from": "2026-08-01", "tiers": [{"from_balance": 0, "rate_bp": 365}, {"from_balance": 500000, "rate_bp": 730}]},
+		{"effective_from": "2026-09-01", "tiers": [{"from_balance": 0, "rate_bp": 365}]}
+	]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountService.SetInterestConfig(config)
+
+	checking, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	savings, _ := s.OpenAccount(ctx, &pb.OpenAccountRequest{Customerid: 1, ProductType: entity.ProductSavings})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: checking.AccountNumber, Amount: usd(1000000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: savings.Account.Number, Amount: usd(1000000)})
+	opened := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	db.Exec("UPDATE journal_entries SET date = ?", opened)
+	db.Exec("UPDATE transactions SET date = ?", opened)
+
+	// The service was down since the deposit: accrual catches up on every
+	// day from August 20 to September 2 with the rates of each day.
+	now := time.Date(2026, 9, 3, 1, 0, 0, 0, time.UTC)
+	accrued, err := accountService.AccrueInterest(ctx, now)
+	if err != nil || accrued != 14 {
+		t.Fatalf("Expected 14 days accrued, got %v (%v)", accrued, err)
+	}
+	if again, _ := accountService.AccrueInterest(ctx, now); again != 0 {
+		t.Errorf("Expected a second run to accrue nothing, got %v", again)
+	}
+
+	posted, err := accountService.PostInterest(ctx, now)
+	if err != nil || posted != 1 {
+		t.Fatalf("Expected August to be posted, got %v (%v)", posted, err)
+	}
+	// 12 days at 150 a day; 25% of 1800 is withheld.
+	balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: savings.Account.Number})
+	if balance.Balance.Units != 1000000+1800-450 {
+		t.Errorf("Expected balance %v, got %v", 1000000+1800-450, balance.Balance.Units)
+	}
+	unchanged, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: checking.AccountNumber})
+	if unchanged.Balance.Units != 1000000 {
+		t.Errorf("Expected checking accounts to earn no interest, got %v", unchanged.Balance.Units)
+	}
+
+	history, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, AccountNumber: savings.Account.Number, Type: entity.TransactionInterest + "," + entity.TransactionWithholdingTax})
+	if len(history.Transactions) != 2 || history.Transactions[0].Amount.Units != 1800 || history.Transactions[1].Amount.Units != 450 {
+		t.Fatalf("Expected interest and withholding tax transactions, got %v", history.Transactions)
+	}
+	if posted, _ := accountService.PostInterest(ctx, now); posted != 0 {
+		t.Errorf("Expected August to be posted once, got %v more", posted)
+	}
+	// The posted accruals point at the interest, so it stays booked.
+	for _, transaction := range history.Transactions {
+		if res, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: transaction.Id, ReasonCode: entity.ReasonOperatorError, Actor: "support"}); res.Message != services.ErrInterestReversal.Error() {
+			t.Errorf("Expected the interest posting not to be reversed, got %v", res)
+		}
+	}
+}
+
+func TestInterestRemainderCarriesOver(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	config, err := interest.Parse(strings.NewReader(`{"products": {"savings": {"schedules": [
+		{"effective_from": "2026-08-01", "tiers": [{"from_balance": 0, "rate_bp": 365}]}
+	]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountService.SetInterestConfig(config)
+
+	s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	savings, _ := s.OpenAccount(ctx, &pb.OpenAccountRequest{Customerid: 1, ProductType: entity.ProductSavings})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: savings.Account.Number, Amount: usd(4500)})
+	opened := time.Date(2026, 8, 28, 10, 0, 0, 0, time.UTC)
+	db.Exec("UPDATE journal_entries SET date = ?", opened)
+	db.Exec("UPDATE transactions SET date = ?", opened)
+
+	// 0.45 cents a day: August's 4 days come to 1.8 cents and September's
+	// 30 to 13.5, so the 0.8 left over from August makes September 14.
+	now := time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC)
+	accountService.AccrueInterest(ctx, now)
+	if posted, err := accountService.PostInterest(ctx, now); err != nil || posted != 2 {
+		t.Fatalf("Expected August and September to be posted, got %v (%v)", posted, err)
+	}
+	history, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, AccountNumber: savings.Account.Number, Type: entity.TransactionInterest})
+	if len(history.Transactions) != 2 || history.Transactions[0].Amount.Units != 1 || history.Transactions[1].Amount.Units != 14 {
+		t.Errorf("Expected 0.01 and 0.14 USD of interest, got %v", history.Transactions)
+	}
+}
+
+func TestClosingWaitsForInterest(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	config, err := interest.Parse(strings.NewReader(`{"products": {"savings": {"schedules": [
+		{"effective_from": "2026-08-01", "tiers": [{"from_balance": 0, "rate_bp": 365}]}
+	]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountService.SetInterestConfig(config)
+
+	s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	savings, _ := s.OpenAccount(ctx, &pb.OpenAccountRequest{Customerid: 1, ProductType: entity.ProductSavings})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: savings.Account.Number, Amount: usd(4500)})
+	opened := time.Date(2026, 8, 28, 10, 0, 0, 0, time.UTC)
+	db.Exec("UPDATE journal_entries SET date = ?", opened)
+	db.Exec("UPDATE transactions SET date = ?", opened)
+	now := time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC)
+	accountService.AccrueInterest(ctx, now)
+
+	withdraw := func(cents int64) {
+		if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: savings.Account.Number, Amount: usd(cents)}); !res.Success {
+			t.Fatalf("Withdraw failed: %v", res.Message)
+		}
+	}
+	closeAccount := func() *pb.ChangeAccountStatusResponse {
+		res, _ := s.ChangeAccountStatus(ctx, &pb.ChangeAccountStatusRequest{AccountNumber: savings.Account.Number, Status: entity.AccountClosed, Reason: "test", Actor: "ops"})
+		return res
+	}
+	withdraw(4500)
+	if res := closeAccount(); res.Success || res.Message != services.ErrInterestUnposted.Error() {
+		t.Fatalf("Expected closing with unposted interest to be refused, got %v", res)
+	}
+
+	// Once posted, the interest is paid out and the account can be closed.
+	if _, err := accountService.PostInterest(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	withdraw(15)
+	if res := closeAccount(); !res.Success {
+		t.Fatalf("Closing failed: %v", res.Message)
+	}
+}
+
+func TestOverdraft(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1000)})
+
+	withdraw := func(cents int64) *pb.WithdrawResponse {
+		res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(cents)})
+		return res
+	}
+	if res := withdraw(2000); res.Success {
+		t.Fatalf("Expected a withdrawal beyond the balance to fail without an overdraft")
+	}
+
+	set, _ := s.SetOverdraft(ctx, &pb.SetOverdraftRequest{AccountNumber: account.AccountNumber, Limit: usd(5000), RateBp: 3650, Actor: "ops"})
+	if !set.Success {
+		t.Fatalf("SetOverdraft failed: %v", set.Message)
+	}
+	if res := withdraw(4000); !res.Success {
+		t.Fatalf("Expected a withdrawal within the overdraft to succeed, got %v", res.Message)
+	}
+	balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.Balance.Units != -3000 || balance.OverdraftLimit.Units != 5000 || balance.OverdraftHeadroom.Units != 2000 {
+		t.Errorf("Expected balance -3000 with 2000 headroom, got %v and %v", balance.Balance.Units, balance.OverdraftHeadroom.Units)
+	}
+	if res := withdraw(2500); res.Success || res.Message != services.ErrInsufficientFunds.Error() {
+		t.Errorf("Expected a withdrawal beyond the overdraft limit to fail, got %v", res.Message)
+	}
+	if res := withdraw(1000); !res.Success {
+		t.Fatalf("Expected a second withdrawal within the overdraft to succeed, got %v", res.Message)
+	}
+
+	var warnings int64
+	db.Model(&entity.OutboxMessage{}).Where("event_type = ?", events.TypeOverdrawn).Count(&warnings)
+	if warnings != 1 {
+		t.Errorf("Expected one overdrawn warning, got %v", warnings)
+	}
+
+	// 4000 overdrawn at 36.5% costs 4 cents a day.
+	now := time.Now().UTC()
+	if _, err := accountService.AccrueInterest(ctx, now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := accountService.PostInterest(ctx, now.AddDate(0, 1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	balance, _ = s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+	if balance.Balance.Units != -4004 || balance.OverdraftHeadroom.Units != 996 {
+		t.Errorf("Expected balance -4004 after overdraft interest, got %v", balance.Balance.Units)
+	}
+
+	if res, _ := s.SetOverdraft(ctx, &pb.SetOverdraftRequest{AccountNumber: account.AccountNumber, Limit: usd(-1), Actor: "ops"}); res.Success {
+		t.Errorf("Expected a negative overdraft limit to be rejected")
+	}
+}
+
+func TestFees(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	config, err := fees.Parse(strings.NewReader(`{"rules": [
+		{"name": "atm", "event": "withdraw", "products": ["checking"], "currency": "USD", "flat": 100, "waive_min_balance": 100000},
+		{"name": "outgoing", "event": "transfer", "products": ["checking"], "currency": "USD", "percent_bp": 100, "min": 50},
+		{"name": "maintenance", "event": "monthly", "products": ["checking"], "currency": "USD", "flat": 500, "waive_min_balance": 50000}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountService.SetFeeConfig(config)
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	balanceOf := func() int64 {
+		res, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+		return res.Balance.Units
+	}
+
+	if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1000)}); !res.Success {
+		t.Fatalf("Withdraw failed: %v", res.Message)
+	}
+	if got := balanceOf(); got != 10000-1000-100 {
+		t.Errorf("Expected the withdrawal fee to be charged, balance %v", got)
+	}
+	if res, _ := s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(3000)}); !res.Success {
+		t.Fatalf("Transfer failed: %v", res.Message)
+	}
+	if got := balanceOf(); got != 8900-3000-50 {
+		t.Errorf("Expected the transfer fee to be charged, balance %v", got)
+	}
+	// The amount is covered but the fee is not.
+	if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(5850)}); res.Success {
+		t.Errorf("Expected a withdrawal that leaves nothing for the fee to fail")
+	}
+
+	history, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, AccountNumber: account.AccountNumber, Type: entity.TransactionWithdrawalFee + "," + entity.TransactionTransferFee})
+	if len(history.Transactions) != 2 || history.Transactions[0].Reference != "atm" || history.Transactions[0].LinkedTransactionId == 0 {
+		t.Fatalf("Expected fee transactions linked to their operations, got %v", history.Transactions)
+	}
+	// A fee is its own journal entry and can be refunded on its own.
+	refund, _ := s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: history.Transactions[0].Id, ReasonCode: entity.ReasonCustomerRequest, Actor: "ops"})
+	if !refund.Success {
+		t.Fatalf("Expected the fee to be refunded, got %v", refund.Message)
+	}
+	if got := balanceOf(); got != 5850+100 {
+		t.Errorf("Expected balance %v after the refund, got %v", 5850+100, got)
+	}
+
+	// The maintenance fee for the previous month is charged once to each
+	// account that held money then.
+	db.Exec("UPDATE journal_entries SET date = ?", time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC))
+	now := time.Date(2026, 9, 2, 1, 0, 0, 0, time.UTC)
+	charged, err := accountService.ChargeMaintenanceFees(ctx, now)
+	if err != nil || charged != 2 {
+		t.Fatalf("Expected two maintenance fees, got %v (%v)", charged, err)
+	}
+	if again, _ := accountService.ChargeMaintenanceFees(ctx, now); again != 0 {
+		t.Errorf("Expected August to be charged once, got %v more", again)
+	}
+	if got := balanceOf(); got != 5950-500 {
+		t.Errorf("Expected the maintenance fee to be charged, balance %v", got)
+	}
+
+	// A balance above the minimum waives the fee; the other account still
+	// pays it.
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	if charged, _ := accountService.ChargeMaintenanceFees(ctx, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)); charged != 1 {
+		t.Errorf("Expected one maintenance fee to be waived, got %v charged", charged)
+	}
+	if got := balanceOf(); got != 105450 {
+		t.Errorf("Expected no maintenance fee on balance %v", got)
+	}
+	var waived int64
+	db.Model(&entity.FeeCharge{}).Where("waived = ?", true).Count(&waived)
+	if waived != 1 {
+		t.Errorf("Expected the waiver to be recorded, got %v", waived)
+	}
+
+	var charges int64
+	db.Model(&entity.OutboxMessage{}).Where("event_type = ?", events.TypeFeeCharged).Count(&charges)
+	if charges != 5 {
+		t.Errorf("Expected five fee events, got %v", charges)
+	}
+}
+
+func TestWithdrawalLimits(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	savings, _ := s.OpenAccount(ctx, &pb.OpenAccountRequest{Customerid: 1, ProductType: entity.ProductSavings})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: savings.Account.Number, Amount: usd(100000)})
+	withdraw := func(number string, cents int64) *pb.WithdrawResponse {
+		res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: number, Amount: usd(cents)})
+		return res
+	}
+
+	if res, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{AccountNumber: account.AccountNumber, MaxSingle: 5000, MaxDaily: 8000, MaxPerHour: 3}); res.Success {
+		t.Errorf("Expected a limit without an actor to be rejected")
+	}
+	set, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{AccountNumber: account.AccountNumber, MaxSingle: 5000, MaxDaily: 8000, MaxPerHour: 3, Actor: "risk"})
+	if !set.Success {
+		t.Fatalf("SetWithdrawalLimit failed: %v", set.Message)
+	}
+
+	res := withdraw(account.AccountNumber, 6000)
+	if res.Success || res.LimitViolation == nil || res.LimitViolation.Reason != entity.LimitSingleAmount || res.LimitViolation.Limit != 5000 {
+		t.Fatalf("Expected the single amount limit to reject, got %v", res)
+	}
+	withdraw(account.AccountNumber, 5000)
+	res = withdraw(account.AccountNumber, 4000)
+	if res.Success || res.LimitViolation.Reason != entity.LimitDailyTotal || res.LimitViolation.Used != 5000 || res.LimitViolation.ResetsAt == "" {
+		t.Fatalf("Expected the daily limit to reject, got %v", res)
+	}
+	withdraw(account.AccountNumber, 1000)
+	withdraw(account.AccountNumber, 1000)
+	res = withdraw(account.AccountNumber, 100)
+	if res.Success || res.LimitViolation.Reason != entity.LimitHourlyCount || res.LimitViolation.Used != 3 {
+		t.Fatalf("Expected the hourly count to reject, got %v", res)
+	}
+
+	// An override lifts the policy until it expires.
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if res, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{AccountNumber: account.AccountNumber, MaxSingle: 20000, ExpiresAt: expires, Actor: "risk"}); !res.Success {
+		t.Fatalf("Setting an override failed: %v", res.Message)
+	}
+	if res := withdraw(account.AccountNumber, 15000); !res.Success {
+		t.Errorf("Expected the override to allow the withdrawal, got %v", res.Message)
+	}
+	db.Exec("UPDATE withdrawal_limits SET expires_at = ? WHERE expires_at IS NOT NULL", time.Now().Add(-time.Minute))
+	if res := withdraw(account.AccountNumber, 100); res.Success || res.LimitViolation.Reason != entity.LimitHourlyCount {
+		t.Errorf("Expected the policy to apply again after the override expired, got %v", res)
+	}
+
+	// A customer limit spans all the customer's accounts.
+	if res, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{Customerid: 1, Currency: "USD", MaxMonthly: 30000, Actor: "risk"}); !res.Success {
+		t.Fatalf("Setting a customer limit failed: %v", res.Message)
+	}
+	res = withdraw(savings.Account.Number, 9000)
+	if res.Success || res.LimitViolation.Scope != entity.LimitScopeCustomer || res.LimitViolation.Reason != entity.LimitMonthlyTotal || res.LimitViolation.Used != 22000 {
+		t.Fatalf("Expected the customer's monthly limit to reject, got %v", res)
+	}
+	if res := withdraw(savings.Account.Number, 3000); !res.Success {
+		t.Errorf("Expected a withdrawal within the customer's limit to succeed, got %v", res.Message)
+	}
+
+	if res, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{AccountNumber: account.AccountNumber, MaxDaily: 1, ExpiresAt: "2020-01-01T00:00:00Z", Actor: "risk"}); res.Success {
+		t.Errorf("Expected an override that already expired to be rejected")
+	}
+}
+
+func TestTransfersAndHoldsCountAgainstLimits(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	if res, _ := s.SetWithdrawalLimit(ctx, &pb.SetWithdrawalLimitRequest{AccountNumber: account.AccountNumber, MaxDaily: 8000, MaxPerHour: 3, Actor: "risk"}); !res.Success {
+		t.Fatalf("SetWithdrawalLimit failed: %v", res.Message)
+	}
+	transfer := func(cents int64) *pb.TransferResponse {
+		res, _ := s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(cents)})
+		return res
+	}
+	refused := func(message string) bool {
+		return message == services.ErrLimitExceeded.Error()
+	}
+
+	if res := transfer(5000); !res.Success {
+		t.Fatalf("Transfer failed: %v", res.Message)
+	}
+	if res := transfer(4000); res.Success || !refused(res.Message) {
+		t.Fatalf("Expected the daily limit to refuse the transfer, got %v", res)
+	}
+
+	// An active hold uses up the limit until it is captured, and the capture
+	// then counts in its place.
+	hold, _ := s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(4000)})
+	if hold.Success || !refused(hold.Message) {
+		t.Fatalf("Expected the daily limit to refuse the hold, got %v", hold)
+	}
+	hold, _ = s.PlaceHold(ctx, &pb.PlaceHoldRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(2000)})
+	if !hold.Success {
+		t.Fatalf("PlaceHold failed: %v", hold.Message)
+	}
+	if res := transfer(1500); res.Success || !refused(res.Message) {
+		t.Errorf("Expected the hold to count against the daily limit, got %v", res)
+	}
+	if res, _ := s.CaptureHold(ctx, &pb.CaptureHoldRequest{Customerid: 1, HoldId: hold.HoldId}); !res.Success {
+		t.Fatalf("CaptureHold failed: %v", res.Message)
+	}
+
+	// The first transfer and the capture leave one of the three per hour;
+	// the amounts stay well inside the daily total.
+	if res := transfer(100); !res.Success {
+		t.Fatalf("Transfer failed: %v", res.Message)
+	}
+	if res := transfer(100); res.Success || !refused(res.Message) {
+		t.Errorf("Expected the hourly count to refuse the transfer, got %v", res)
+	}
+}
+
+func TestStandingOrders(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	from, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	to, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: from.AccountNumber, Amount: usd(100000)})
+	balanceOf := func(customerID uint32, number string) int64 {
+		res, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: customerID, AccountNumber: number})
+		return res.Balance.Units
+	}
+	at := func(value string) time.Time {
+		t, _ := time.Parse(time.RFC3339, value)
+		return t
+	}
+
+	// Rent on the 31st runs on the last day of shorter months.
+	rent, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{
+		Customerid: 1, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(20000),
+		Reference: "rent", Frequency: entity.FrequencyMonthly, StartDate: "2027-01-15", DayOfMonth: 31, EndDate: "2027-03-31",
+	})
+	if !rent.Success || rent.StandingOrder.NextDueDate != "2027-01-31" {
+		t.Fatalf("CreateStandingOrder failed: %v", rent)
+	}
+	if res, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{Customerid: 1, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(1), Frequency: "daily"}); res.Success {
+		t.Errorf("Expected an unknown frequency to be rejected")
+	}
+	if res, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{Customerid: 2, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(1), Frequency: entity.FrequencyWeekly}); res.Success {
+		t.Errorf("Expected a standing order from another customer's account to be rejected")
+	}
+
+	// The service was down: every missed occurrence is executed.
+	executed, err := accountService.ExecuteStandingOrders(ctx, at("2027-03-31T01:00:00Z"))
+	if err != nil || executed != 3 {
+		t.Fatalf("Expected three executions, got %v (%v)", executed, err)
+	}
+	if got := balanceOf(2, to.AccountNumber); got != 60000 {
+		t.Errorf("Expected 60000 transferred, got %v", got)
+	}
+	history, _ := s.StandingOrderExecutions(ctx, &pb.StandingOrderExecutionsRequest{Customerid: 1, StandingOrderId: rent.StandingOrder.Id})
+	if len(history.Executions) != 3 || history.Executions[1].DueDate != "2027-02-28" || history.Executions[1].TransactionId == 0 {
+		t.Fatalf("Expected an execution on February 28, got %v", history.Executions)
+	}
+	list, _ := s.ListStandingOrders(ctx, &pb.ListStandingOrdersRequest{Customerid: 1})
+	if len(list.StandingOrders) != 1 || list.StandingOrders[0].Status != entity.StandingOrderCompleted {
+		t.Fatalf("Expected the order to complete after its end date, got %v", list.StandingOrders)
+	}
+
+	// A fortnightly transfer the account cannot cover is retried.
+	savings, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{
+		Customerid: 1, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(50000),
+		Frequency: entity.FrequencyBiweekly, StartDate: "2027-04-05",
+	})
+	if !savings.Success {
+		t.Fatalf("CreateStandingOrder failed: %v", savings.Message)
+	}
+	accountService.ExecuteStandingOrders(ctx, at("2027-04-05T01:00:00Z"))
+	if again, _ := accountService.ExecuteStandingOrders(ctx, at("2027-04-05T02:00:00Z")); again != 0 {
+		t.Errorf("Expected no attempt before the retry is due, got %v", again)
+	}
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: from.AccountNumber, Amount: usd(20000)})
+	accountService.ExecuteStandingOrders(ctx, at("2027-04-05T08:00:00Z"))
+	history, _ = s.StandingOrderExecutions(ctx, &pb.StandingOrderExecutionsRequest{Customerid: 1, StandingOrderId: savings.StandingOrder.Id})
+	if len(history.Executions) != 2 || history.Executions[0].Status != entity.ExecutionRetrying ||
+		history.Executions[0].Message != services.ErrInsufficientFunds.Error() || history.Executions[1].Status != entity.ExecutionSucceeded {
+		t.Fatalf("Expected a retry that succeeded, got %v", history.Executions)
+	}
+	list, _ = s.ListStandingOrders(ctx, &pb.ListStandingOrdersRequest{Customerid: 1, AccountNumber: from.AccountNumber})
+	if list.StandingOrders[1].NextDueDate != "2027-04-19" {
+		t.Errorf("Expected the next occurrence two weeks later, got %v", list.StandingOrders[1].NextDueDate)
+	}
+
+	// After the last attempt the occurrence is given up.
+	for _, now := range []string{"2027-04-19T01:00:00Z", "2027-04-19T07:00:00Z", "2027-04-19T13:00:00Z", "2027-04-19T19:00:00Z"} {
+		accountService.ExecuteStandingOrders(ctx, at(now))
+	}
+	history, _ = s.StandingOrderExecutions(ctx, &pb.StandingOrderExecutionsRequest{Customerid: 1, StandingOrderId: savings.StandingOrder.Id})
+	if last := history.Executions[len(history.Executions)-1]; len(history.Executions) != 6 || last.Status != entity.ExecutionFailed || last.Attempt != services.StandingOrderMaxAttempts {
+		t.Fatalf("Expected the occurrence to fail after %v attempts, got %v", services.StandingOrderMaxAttempts, history.Executions)
+	}
+	var failures int64
+	db.Model(&entity.OutboxMessage{}).Where("event_type = ?", events.TypeStandingFailed).Count(&failures)
+	if failures != 1 {
+		t.Errorf("Expected one failure event, got %v", failures)
+	}
+
+	if res, _ := s.CancelStandingOrder(ctx, &pb.CancelStandingOrderRequest{Customerid: 2, StandingOrderId: savings.StandingOrder.Id}); res.Success {
+		t.Errorf("Expected another customer's cancellation to be rejected")
+	}
+	if res, _ := s.CancelStandingOrder(ctx, &pb.CancelStandingOrderRequest{Customerid: 1, StandingOrderId: savings.StandingOrder.Id}); !res.Success {
+		t.Fatalf("CancelStandingOrder failed: %v", res.Message)
+	}
+	if executed, _ := accountService.ExecuteStandingOrders(ctx, at("2027-06-01T00:00:00Z")); executed != 0 {
+		t.Errorf("Expected a cancelled order not to run, got %v", executed)
+	}
+}
+
+func TestStandingOrderInternalFailure(t *testing.T) {
+	db := setupFileDB(t)
+	accountService := services.NewAccountService(repository.NewAccountRepository(db))
+	s := &Server{accountService: accountService}
+	ctx := context.Background()
+
+	from, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	to, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: from.AccountNumber, Amount: usd(100000)})
+	create := func(reference string) uint32 {
+		res, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{
+			Customerid: 1, FromAccountNumber: from.AccountNumber, ToAccountNumber: to.AccountNumber, Amount: usd(100),
+			Reference: reference, Frequency: entity.FrequencyWeekly, StartDate: "2027-01-04",
+		})
+		if !res.Success {
+			t.Fatalf("CreateStandingOrder failed: %v", res.Message)
+		}
+		return res.StandingOrder.Id
+	}
+	broken, working := create("broken"), create("working")
+	// The source account of the first order can no longer be read.
+	db.Model(&entity.StandingOrder{}).Where("id = ?", broken).Update("account_id", 9999)
+
+	now, _ := time.Parse(time.RFC3339, "2027-01-04T01:00:00Z")
+	if executed, err := accountService.ExecuteStandingOrders(ctx, now); err != nil || executed != 2 {
+		t.Fatalf("Expected both orders to be attempted, got %v (%v)", executed, err)
+	}
+	history, _ := s.StandingOrderExecutions(ctx, &pb.StandingOrderExecutionsRequest{Customerid: 1, StandingOrderId: working})
+	if len(history.Executions) != 1 || history.Executions[0].Status != entity.ExecutionSucceeded {
+		t.Fatalf("Expected the second order to run, got %v", history.Executions)
+	}
+
+	// The failing order is retried and finally given up.
+	for i := 1; i < services.StandingOrderMaxAttempts; i++ {
+		now = now.Add(services.StandingOrderRetryInterval)
+		if _, err := accountService.ExecuteStandingOrders(ctx, now); err != nil {
+			t.Fatalf("ExecuteStandingOrders failed: %v", err)
+		}
+	}
+	history, _ = s.StandingOrderExecutions(ctx, &pb.StandingOrderExecutionsRequest{Customerid: 1, StandingOrderId: broken})
+	if last := history.Executions[len(history.Executions)-1]; len(history.Executions) != services.StandingOrderMaxAttempts ||
+		history.Executions[0].Status != entity.ExecutionRetrying || history.Executions[0].Message != "transfer failed" || last.Status != entity.ExecutionFailed {
+		t.Fatalf("Expected the order to be retried and given up, got %v", history.Executions)
+	}
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 2, AccountNumber: other.AccountNumber, Amount: usd(500)})
+	change := func(number, status string) *pb.ChangeAccountStatusResponse {
+		res, _ := s.ChangeAccountStatus(ctx, &pb.ChangeAccountStatusRequest{AccountNumber: number, Status: status, Reason: "test", Actor: "ops"})
+		return res
+	}
+
+	if res, _ := s.ChangeAccountStatus(ctx, &pb.ChangeAccountStatusRequest{AccountNumber: account.AccountNumber, Status: entity.AccountFrozen, Actor: "ops"}); res.Success {
+		t.Errorf("Expected a status change without a reason to be rejected")
+	}
+	if res := change(account.AccountNumber, entity.AccountFrozen); !res.Success || res.Account.Status != entity.AccountFrozen {
+		t.Fatalf("Freezing failed: %v", res)
+	}
+
+	// A frozen account takes credits only.
+	if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100)}); res.Success || res.Message != entity.ErrAccountFrozen.Error() {
+		t.Errorf("Expected a withdrawal from a frozen account to be rejected, got %v", res)
+	}
+	if res, _ := s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(2000)}); !res.Success {
+		t.Errorf("Expected a deposit to a frozen account to succeed, got %v", res.Message)
+	}
+	if res, _ := s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 2, FromAccountNumber: other.AccountNumber, ToAccountNumber: account.AccountNumber, Amount: usd(100)}); !res.Success {
+		t.Errorf("Expected a transfer to a frozen account to succeed, got %v", res.Message)
+	}
+	if res, _ := s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(100)}); res.Success {
+		t.Errorf("Expected a transfer from a frozen account to be rejected")
+	}
+	if res := change(account.AccountNumber, entity.AccountDormant); res.Success || res.Message != entity.ErrInvalidTransition.Error() {
+		t.Errorf("Expected frozen to dormant to be refused, got %v", res)
+	}
+
+	// Closing needs a zero balance, and cancels the account's standing orders.
+	if res := change(account.AccountNumber, entity.AccountActive); !res.Success {
+		t.Fatalf("Unfreezing failed: %v", res.Message)
+	}
+	if res := change(account.AccountNumber, entity.AccountClosed); res.Success || res.Message != services.ErrBalanceNotZero.Error() {
+		t.Errorf("Expected closing with a balance to be refused, got %v", res)
+	}
+	order, _ := s.CreateStandingOrder(ctx, &pb.CreateStandingOrderRequest{Customerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(100), Frequency: entity.FrequencyWeekly})
+	if !order.Success {
+		t.Fatalf("CreateStandingOrder failed: %v", order.Message)
+	}
+	if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(12100)}); !res.Success {
+		t.Fatalf("Withdraw failed: %v", res.Message)
+	}
+	if res := change(account.AccountNumber, entity.AccountClosed); !res.Success {
+		t.Fatalf("Closing failed: %v", res.Message)
+	}
+	orders, _ := s.ListStandingOrders(ctx, &pb.ListStandingOrdersRequest{Customerid: 1})
+	if len(orders.StandingOrders) != 1 || orders.StandingOrders[0].Status != entity.StandingOrderCancelled {
+		t.Errorf("Expected closing to cancel the standing order, got %v", orders.StandingOrders)
+	}
+	if res, _ := s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100)}); res.Success || res.Message != entity.ErrAccountClosed.Error() {
+		t.Errorf("Expected a deposit to a closed account to be rejected, got %v", res)
+	}
+	if res := change(account.AccountNumber, entity.AccountActive); res.Success {
+		t.Errorf("Expected a closed account to stay closed")
+	}
+
+	// Accounts without customer activity for the dormancy period become
+	// dormant; closed ones are left alone.
+	flagged, err := s.accountService.FlagDormantAccounts(ctx, time.Now().AddDate(0, services.DefaultDormancyMonths+1, 0))
+	if err != nil || flagged != 1 {
+		t.Fatalf("Expected one account to be flagged dormant, got %d, %v", flagged, err)
+	}
+	if res, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 2, AccountNumber: other.AccountNumber, Amount: usd(100)}); res.Success || res.Message != entity.ErrAccountDormant.Error() {
+		t.Errorf("Expected a withdrawal from a dormant account to be rejected, got %v", res)
+	}
+
+	history, _ := s.AccountStatusHistory(ctx, &pb.AccountStatusHistoryRequest{AccountNumber: other.AccountNumber})
+	if !history.Success || history.Status != entity.AccountDormant || len(history.Changes) != 1 || history.Changes[0].Actor != "dormancy-job" {
+		t.Errorf("Expected the dormancy to be audited, got %v", history)
+	}
+	history, _ = s.AccountStatusHistory(ctx, &pb.AccountStatusHistoryRequest{AccountNumber: account.AccountNumber})
+	if len(history.Changes) != 3 || history.Changes[2].FromStatus != entity.AccountActive || history.Changes[2].ToStatus != entity.AccountClosed {
+		t.Errorf("Expected three audited changes, got %v", history.Changes)
+	}
+	var n int64
+	db.Model(&entity.OutboxMessage{}).Where("event_type = ?", "account.status_changed").Count(&n)
+	if n != 4 {
+		t.Errorf("Expected 4 status change events, got %d", n)
+	}
+}
+
+func TestBalanceAsOf(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(30000)})
+	now := time.Now()
+	day := func(days int) time.Time { return now.AddDate(0, 0, days) }
+	backdate := func(transactionID uint, date time.Time) {
+		db.Exec("UPDATE transactions SET date = ? WHERE id = ?", date, transactionID)
+		db.Exec("UPDATE journal_entries SET date = ? WHERE id = (SELECT journal_entry_id FROM transactions WHERE id = ?)", date, transactionID)
+	}
+	backdate(1, day(-10))
+	backdate(2, day(-5))
+
+	if n, err := s.accountService.TakeBalanceSnapshots(ctx, day(-7)); err != nil || n != 1 {
+		t.Fatalf("Expected one snapshot, got %d, %v", n, err)
+	}
+	if n, _ := s.accountService.TakeBalanceSnapshots(ctx, day(-7)); n != 0 {
+		t.Errorf("Expected the snapshot not to be taken twice, got %d", n)
+	}
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50000)})
+
+	for _, tc := range []struct {
+		asOf         string
+		want         int64
+		fromSnapshot bool
+	}{
+		{day(-12).Format(time.DateOnly), 0, false},
+		{day(-8).Format(time.RFC3339), 100000, false},
+		{day(-6).Format(time.RFC3339), 100000, true},
+		{day(-3).Format(time.DateOnly), 70000, true},
+		{day(1).Format(time.RFC3339), 120000, true},
+	} {
+		res, _ := s.BalanceAsOf(ctx, &pb.BalanceAsOfRequest{AccountNumber: account.AccountNumber, AsOf: tc.asOf})
+		if !res.Success || res.Balance.Units != tc.want || (res.SnapshotAsOf != "") != tc.fromSnapshot {
+			t.Errorf("BalanceAsOf(%s) = %v, want %d (from snapshot: %v)", tc.asOf, res, tc.want, tc.fromSnapshot)
+		}
+	}
+
+	// Replay starts from the snapshot rather than from the first transaction.
+	db.Exec("UPDATE balance_snapshots SET balance = balance + 1")
+	if res, _ := s.BalanceAsOf(ctx, &pb.BalanceAsOfRequest{AccountNumber: account.AccountNumber, AsOf: day(-3).Format(time.DateOnly)}); res.Balance.Units != 70001 {
+		t.Errorf("Expected the balance to be replayed from the snapshot, got %v", res.Balance)
+	}
+	if res, _ := s.BalanceAsOf(ctx, &pb.BalanceAsOfRequest{AccountNumber: account.AccountNumber, AsOf: "March 31st"}); res.Success {
+		t.Errorf("Expected an invalid as_of to be rejected")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(2500)})
+	s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(1000)})
+	s.accountService.TakeBalanceSnapshots(ctx, time.Now().AddDate(0, 0, 1))
+
+	var out, errOut bytes.Buffer
+	if code := runVerify(s.accountService, nil, &out, &errOut); code != 0 || !strings.Contains(out.String(), "2 accounts checked, 0 drifting") {
+		t.Fatalf("Expected a clean ledger to verify, got %d: %s%s", code, out.String(), errOut.String())
+	}
+
+	// A withdrawal row that no longer matches its journal entry.
+	var withdrawal entity.Transaction
+	db.Where("type = ?", entity.TransactionWithdraw).First(&withdrawal)
+	db.Exec("UPDATE transactions SET amount = amount + 5 WHERE id = ?", withdrawal.ID)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	out.Reset()
+	if code := runVerify(s.accountService, []string{"-json", path}, &out, &errOut); code != 1 {
+		t.Fatalf("Expected drift to exit 1, got %d: %s%s", code, out.String(), errOut.String())
+	}
+	if !strings.Contains(out.String(), "account "+account.AccountNumber+": ledger 65.00 USD, transactions 64.95 USD, drift 0.05 USD") {
+		t.Errorf("Unexpected report: %s", out.String())
+	}
+
+	var report services.LedgerReport
+	raw, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(raw, &report) != nil {
+		t.Fatalf("Expected a JSON report, got %v: %s", err, raw)
+	}
+	if report.Accounts != 2 || len(report.Drifted) != 1 {
+		t.Fatalf("Expected one drifting account out of two, got %+v", report)
+	}
+	drift := report.Drifted[0]
+	if drift.Drift != 5 || drift.FirstDivergence == nil || drift.FirstDivergence.TransactionID != withdrawal.ID || len(drift.Snapshots) != 1 {
+		t.Errorf("Expected the withdrawal to be the first divergence and the snapshot to drift, got %+v", drift)
+	}
+
+	// A missing ledger account is reported as drift, and verify leaves it
+	// missing.
+	var ledgerAccounts int64
+	db.Exec("DELETE FROM ledger_accounts WHERE account_id = (SELECT id FROM accounts WHERE number = ?)", other.AccountNumber)
+	out.Reset()
+	if code := runVerify(s.accountService, nil, &out, &errOut); code != 1 || !strings.Contains(out.String(), "account "+other.AccountNumber+": ledger 0.00 USD, transactions 10.00 USD, drift -10.00 USD\n  no ledger account") {
+		t.Errorf("Expected the missing ledger account to be reported, got %d: %s", code, out.String())
+	}
+	db.Model(&entity.LedgerAccount{}).Where("account_id = (SELECT id FROM accounts WHERE number = ?)", other.AccountNumber).Count(&ledgerAccounts)
+	if ledgerAccounts != 0 {
+		t.Errorf("Expected verify not to create a ledger account")
+	}
+}
+
+func TestVerifyLegacyBalances(t *testing.T) {
+	for _, tc := range []struct {
+		balance string
+		code    int
+	}{
+		{"12.34", 0},
+		{"20.00", 1},
+	} {
+		db, _ := gorm.Open(sqlite.Open("file:verify-legacy-"+tc.balance+"?mode=memory"), &gorm.Config{})
+		db.Exec("CREATE TABLE accounts (id integer PRIMARY KEY, customer_id integer, balance real)")
+		db.Exec("CREATE TABLE transactions (id integer PRIMARY KEY, customer_id integer, type text, amount real, date datetime)")
+		db.Exec("INSERT INTO accounts (id, customer_id, balance) VALUES (1, 7, " + tc.balance + ")")
+		db.Exec("INSERT INTO transactions (id, customer_id, type, amount) VALUES (1, 7, 'deposit', 12.34)")
+		repository.Migrate(db)
+		accountService := services.NewAccountService(repository.NewAccountRepository(db))
+		accountService.MigrateLegacyBalances(context.Background())
+
+		var out bytes.Buffer
+		if code := runVerify(accountService, []string{"-json", "-"}, &out, &out); code != tc.code {
+			t.Errorf("Legacy balance %s: expected exit %d, got %d: %s", tc.balance, tc.code, code, out.String())
+		}
+	}
+}
+
+func TestTransactionChain(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(20000)})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(5000)})
+
+	var transactions []entity.Transaction
+	db.Where("account_id = (SELECT id FROM accounts WHERE number = ?)", account.AccountNumber).Order("sequence").Find(&transactions)
+	if len(transactions) != 4 || transactions[0].Sequence != 1 || transactions[1].PrevHash != transactions[0].Hash {
+		t.Fatalf("Expected four chained transactions, got %+v", transactions)
+	}
+
+	res, _ := s.VerifyTransactionChain(ctx, &pb.VerifyTransactionChainRequest{})
+	if !res.Success || !res.Intact || res.Accounts != 2 || res.Transactions != 5 {
+		t.Fatalf("Expected an intact chain, got %v", res)
+	}
+
+	// Anchoring tomorrow covers every transaction made so far.
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	if n, err := s.accountService.AnchorTransactionChains(ctx, tomorrow); err != nil || n != 1 {
+		t.Fatalf("Expected one anchor, got %d, %v", n, err)
+	}
+	if n, _ := s.accountService.AnchorTransactionChains(ctx, tomorrow); n != 0 {
+		t.Errorf("Expected the day not to be anchored twice, got %d", n)
+	}
+	var published int64
+	db.Model(&entity.OutboxMessage{}).Where("event_type = ?", events.TypeChainAnchored).Count(&published)
+	if published != 1 {
+		t.Errorf("Expected the anchor to be published, got %d events", published)
+	}
+
+	// Removing the last transaction leaves the chain consistent, but not the
+	// anchor.
+	db.Exec("DELETE FROM transactions WHERE id = ?", transactions[3].ID)
+	res, _ = s.VerifyTransactionChain(ctx, &pb.VerifyTransactionChainRequest{})
+	if res.Intact || len(res.Breaks) != 0 || len(res.AnchorMismatches) != 1 || res.Anchors != 1 {
+		t.Errorf("Expected the anchor to no longer match, got %v", res)
+	}
+
+	db.Exec("UPDATE transactions SET amount = ? WHERE id = ?", 1000, transactions[2].ID)
+	res, _ = s.VerifyTransactionChain(ctx, &pb.VerifyTransactionChainRequest{AccountNumber: account.AccountNumber})
+	if res.Intact || len(res.Breaks) != 1 || res.Breaks[0].TransactionId != uint32(transactions[2].ID) || res.Breaks[0].Sequence != 3 {
+		t.Errorf("Expected the edited transaction to be located, got %v", res)
+	}
+
+	db.Exec("DELETE FROM transactions WHERE id = ?", transactions[1].ID)
+	res, _ = s.VerifyTransactionChain(ctx, &pb.VerifyTransactionChainRequest{AccountNumber: account.AccountNumber})
+	if res.Intact || len(res.Breaks) != 1 || res.Breaks[0].Sequence != 2 || res.Breaks[0].TransactionId != 0 {
+		t.Errorf("Expected the deleted transaction to be located, got %v", res)
+	}
+}
+
+func TestEventSourcedProjections(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(100000)})
+	s.Transfer(ctx, &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(20000)})
+	// The outgoing leg of the transfer is transaction 2.
+	s.ReverseTransaction(ctx, &pb.ReverseTransactionRequest{TransactionId: 2, ReasonCode: entity.ReasonOperatorError, Actor: "support"})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	s.SetOverdraft(ctx, &pb.SetOverdraftRequest{AccountNumber: account.AccountNumber, Limit: usd(5000), Actor: "support"})
+	s.ChangeAccountStatus(ctx, &pb.ChangeAccountStatusRequest{AccountNumber: account.AccountNumber, Status: entity.AccountFrozen, Reason: "review", Actor: "support"})
+
+	var stream []entity.AccountEvent
+	db.Where("account_id = (SELECT id FROM accounts WHERE number = ?)", account.AccountNumber).Order("version").Find(&stream)
+	var types []string
+	for i, event := range stream {
+		if event.Version != uint(i+1) {
+			t.Fatalf("Expected versions without gaps, got %d at %d", event.Version, i)
+		}
+		types = append(types, event.Type)
+	}
+	want := []string{
+		aggregate.TypeOpened, aggregate.TypeBooked, aggregate.TypeBooked, aggregate.TypeLinked,
+		aggregate.TypeBooked, aggregate.TypeReversed, aggregate.TypeBooked,
+		aggregate.TypeOverdraftChanged, aggregate.TypeStatusChanged,
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected events %v, got %v", want, types)
+	}
+
+	check := func(when string) {
+		t.Helper()
+		balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+		if balance.Balance.GetUnits() != 90000 {
+			t.Errorf("%s: expected balance 90000, got %v", when, balance.Balance.GetUnits())
+		}
+		history, _ := s.TransactionHistory(ctx, &pb.TransactionHistoryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+		if len(history.Transactions) != 4 || history.Transactions[1].ReversedByTransactionId != history.Transactions[2].Id || history.Transactions[1].LinkedTransactionId == 0 {
+			t.Errorf("%s: unexpected history %v", when, history.Transactions)
+		}
+		var status, overdraft string
+		db.Raw("SELECT status, overdraft_limit FROM accounts WHERE number = ?", account.AccountNumber).Row().Scan(&status, &overdraft)
+		if status != entity.AccountFrozen || overdraft != "5000" {
+			t.Errorf("%s: expected a frozen account with an overdraft, got %s and %s", when, status, overdraft)
+		}
+	}
+	check("live")
+
+	var running []int64
+	db.Model(&entity.HistoryEntry{}).Where("account_id = ?", stream[0].AccountID).Order("transaction_id").Pluck("balance_after", &running)
+	if len(running) != 4 || running[0] != 100000 || running[1] != 80000 || running[2] != 100000 || running[3] != 90000 {
+		t.Errorf("Expected running balances in the history, got %v", running)
+	}
+
+	// Projections are disposable: wreck them and build them again.
+	db.Exec("UPDATE account_balances SET balance = 0")
+	db.Exec("DELETE FROM account_history")
+	db.Exec("UPDATE accounts SET status = ?, overdraft_limit = 0", entity.AccountActive)
+	var out bytes.Buffer
+	if code := runRebuildProjections(s.accountService, &out, &out); code != 0 {
+		t.Fatalf("Expected rebuild to succeed, got %d: %s", code, out.String())
+	}
+	check("rebuilt")
+
+	var before, after int64
+	db.Model(&entity.AccountEvent{}).Count(&before)
+	if n, err := s.accountService.ImportAccountStreams(ctx); err != nil || n != 0 {
+		t.Errorf("Expected no streams to import, got %d (%v)", n, err)
+	}
+	db.Model(&entity.AccountEvent{}).Count(&after)
+	if after != before {
+		t.Errorf("Expected the rebuild to leave the event store alone, got %d events instead of %d", after, before)
+	}
+}
+
+func TestIdempotentRequests(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	other, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	balance := func() int64 {
+		res, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: account.AccountNumber})
+		return res.Balance.GetUnits()
+	}
+
+	// Concurrent retries of one deposit book it once and all get its answer.
+	var succeeded int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			res, _ := s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000), RequestId: "deposit-1"})
+			if res.Success && res.Message == "deposit successful" {
+				atomic.AddInt64(&succeeded, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if succeeded != 5 || balance() != 10000 {
+		t.Fatalf("Expected every retry to succeed and one deposit to be booked, got %d successes and balance %d", succeeded, balance())
+	}
+
+	res, _ := s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(20000), RequestId: "deposit-1"})
+	if res.Success || res.Message != services.ErrRequestIDReused.Error() {
+		t.Errorf("Expected a different deposit under the same request_id to fail, got %v", res)
+	}
+	withdrawn, _ := s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000), RequestId: "deposit-1"})
+	if withdrawn.Success || withdrawn.Message != services.ErrRequestIDReused.Error() {
+		t.Errorf("Expected a withdrawal under a deposit's request_id to fail, got %v", withdrawn)
+	}
+
+	// A failed request is not remembered; it can be retried once it can
+	// succeed.
+	withdraw := &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(15000), RequestId: "withdraw-1"}
+	if withdrawn, _ = s.Withdraw(ctx, withdraw); withdrawn.Success {
+		t.Fatalf("Expected the withdrawal to fail for lack of funds")
+	}
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(10000)})
+	for i := 0; i < 2; i++ {
+		if withdrawn, _ = s.Withdraw(ctx, withdraw); !withdrawn.Success {
+			t.Fatalf("Expected the withdrawal to succeed, got %v", withdrawn.Message)
+		}
+	}
+	if balance() != 5000 {
+		t.Errorf("Expected one withdrawal to be booked, got balance %d", balance())
+	}
+
+	transfer := &pb.TransferRequest{FromCustomerid: 1, FromAccountNumber: account.AccountNumber, ToAccountNumber: other.AccountNumber, Amount: usd(1000), RequestId: "transfer-1"}
+	s.Transfer(ctx, transfer)
+	if transferred, _ := s.Transfer(ctx, transfer); !transferred.Success || balance() != 4000 {
+		t.Errorf("Expected the repeated transfer to be answered without booking it again, got %v and balance %d", transferred, balance())
+	}
+
+	// Request IDs are the customer's own: another customer's request with
+	// the same ID is booked.
+	if res, _ := s.Deposit(ctx, &pb.DepositRequest{Customerid: 2, AccountNumber: other.AccountNumber, Amount: usd(500), RequestId: "deposit-1"}); !res.Success {
+		t.Errorf("Expected another customer's deposit under the same request_id to succeed, got %v", res.Message)
+	}
+	if res, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 2, AccountNumber: other.AccountNumber}); res.Balance.GetUnits() != 1500 {
+		t.Errorf("Expected the other customer's deposit to be booked, got balance %d", res.Balance.GetUnits())
+	}
+
+	long := &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(1), RequestId: strings.Repeat("x", entity.MaxRequestIDLength+1)}
+	if res, _ := s.Deposit(ctx, long); res.Success {
+		t.Errorf("Expected an overlong request_id to be rejected")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	account, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(50000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(20000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(20000)})
+	s.Withdraw(ctx, &pb.WithdrawRequest{Customerid: 1, AccountNumber: account.AccountNumber, Amount: usd(2550)})
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	file := "id,date,amount,currency,reference\n" +
+		"BANK-1," + today + ",500.00,USD,salary\n" +
+		"BANK-2," + today + ",-25.50,USD,\n" +
+		"BANK-3," + today + ",200.00,USD,\n" +
+		"BANK-4," + today + ",99.00,USD,\n"
+	reconcile := func(source string) *pb.ReconcileResponse {
+		res, _ := s.Reconcile(ctx, &pb.ReconcileRequest{Format: "csv", Source: source, Content: []byte(file), Actor: "ops"})
+		if !res.Success {
+			t.Fatalf("Reconcile failed: %v", res.Message)
+		}
+		return res
+	}
+
+	first := reconcile("settlement-1.csv")
+	var statuses []string
+	for _, item := range first.Items {
+		statuses = append(statuses, item.Status)
+	}
+	want := []string{"matched", "matched", "ambiguous", "unmatched"}
+	if strings.Join(statuses, " ") != strings.Join(want, " ") {
+		t.Fatalf("Expected %v, got %v", want, statuses)
+	}
+	if first.Matched != 2 || first.Ambiguous != 1 || first.Unmatched != 1 || len(first.Items[2].Candidates) != 2 {
+		t.Errorf("Unexpected report %v", first)
+	}
+
+	// Importing the file again matches nothing twice.
+	second := reconcile("settlement-1.csv")
+	if second.Duplicates != 2 || second.Matched != 0 || second.Items[0].Candidates[0] != first.Items[0].TransactionId {
+		t.Errorf("Expected the matched lines to be reported as duplicates, got %v", second)
+	}
+
+	stored, _ := s.GetReconciliation(ctx, &pb.GetReconciliationRequest{ReconciliationId: first.ReconciliationId})
+	if !stored.Success || stored.Matched != 2 || len(stored.Items) != 4 || stored.Items[1].TransactionId != first.Items[1].TransactionId {
+		t.Errorf("Unexpected stored report %v", stored)
+	}
+
+	if res, _ := s.Reconcile(ctx, &pb.ReconcileRequest{Format: "mt940", Content: []byte(file), Actor: "ops"}); res.Success {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+func TestPayoutBatches(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	payroll, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	jane, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: payroll.AccountNumber, Amount: usd(100000)})
+
+	invalid := "name,to_account_number,amount,currency\n" +
+		"Jane Roe," + jane.AccountNumber + ",100.00,USD\n" +
+		"Nobody,000000999999,1.00,USD\n" +
+		"Nobody," + jane.AccountNumber + ",-1,USD\n"
+	res, _ := s.SubmitPayoutBatch(ctx, &pb.SubmitPayoutBatchRequest{Customerid: 1, AccountNumber: payroll.AccountNumber, Format: "csv", Content: []byte(invalid)})
+	if res.Success || len(res.Errors) != 2 || res.Errors[0].Line != 3 || res.Errors[1].Line != 4 {
+		t.Fatalf("Expected lines 3 and 4 to be rejected, got %v", res)
+	}
+
+	file := "name,to_account_number,amount,currency,type,reference\n" +
+		"Jane Roe," + jane.AccountNumber + ",600.00,USD,transfer,Salary September\n" +
+		"John Doe,,300.00,USD,withdrawal,\n" +
+		"Jane Roe," + jane.AccountNumber + ",200.00,USD,transfer,Bonus\n"
+	submit := &pb.SubmitPayoutBatchRequest{Customerid: 1, AccountNumber: payroll.AccountNumber, Format: "csv", Source: "payroll.csv", Content: []byte(file), RequestId: "payroll-2026-09"}
+	batch, _ := s.SubmitPayoutBatch(ctx, submit)
+	if !batch.Success || batch.Status != "pending" || batch.LineCount != 3 || batch.Total.Units != 110000 {
+		t.Fatalf("Unexpected batch %v", batch)
+	}
+	if again, _ := s.SubmitPayoutBatch(ctx, submit); again.BatchId != batch.BatchId {
+		t.Errorf("Expected a retried upload to return batch %d, got %v", batch.BatchId, again)
+	}
+
+	executed, err := s.accountService.ExecutePayoutBatches(ctx, time.Now())
+	if err != nil || executed != 3 {
+		t.Fatalf("Expected 3 lines to be executed, got %d: %v", executed, err)
+	}
+	if executed, _ := s.accountService.ExecutePayoutBatches(ctx, time.Now()); executed != 0 {
+		t.Errorf("Expected a completed batch not to be executed again, got %d lines", executed)
+	}
+
+	if res, _ := s.GetPayoutBatch(ctx, &pb.GetPayoutBatchRequest{Customerid: 2, BatchId: batch.BatchId}); res.Success {
+		t.Errorf("Expected the batch to be hidden from another customer")
+	}
+	done, _ := s.GetPayoutBatch(ctx, &pb.GetPayoutBatchRequest{Customerid: 1, BatchId: batch.BatchId})
+	if !done.Success || done.Status != "completed" || done.Succeeded != 2 || done.Failed != 1 || done.CompletedAt == "" {
+		t.Fatalf("Unexpected batch %v", done)
+	}
+	var statuses []string
+	for _, line := range done.Lines {
+		statuses = append(statuses, line.Status)
+	}
+	if strings.Join(statuses, " ") != "succeeded succeeded failed" || done.Lines[0].TransactionId == 0 || done.Lines[2].Message == "" {
+		t.Errorf("Unexpected lines %v", done.Lines)
+	}
+
+	if balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 1, AccountNumber: payroll.AccountNumber}); balance.Balance.Units != 10000 {
+		t.Errorf("Expected 100.00 left on the payroll account, got %v", balance.Balance)
+	}
+	if balance, _ := s.BalanceInquiry(ctx, &pb.BalanceInquiryRequest{Customerid: 2, AccountNumber: jane.AccountNumber}); balance.Balance.Units != 60000 {
+		t.Errorf("Expected 600.00 on the recipient account, got %v", balance.Balance)
+	}
+}
+
+func TestPayoutBatchInternalFailure(t *testing.T) {
+	db := setupFileDB(t)
+	s := &Server{accountService: services.NewAccountService(repository.NewAccountRepository(db))}
+	ctx := context.Background()
+
+	broken, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 1})
+	payroll, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 2})
+	jane, _ := s.CreateAccount(ctx, &pb.CreateAccountRequest{Customerid: 3})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 1, AccountNumber: broken.AccountNumber, Amount: usd(10000)})
+	s.Deposit(ctx, &pb.DepositRequest{Customerid: 2, AccountNumber: payroll.AccountNumber, Amount: usd(10000)})
+	file := "to_account_number,amount,currency\n" + jane.AccountNumber + ",10.00,USD\n"
+	first, _ := s.SubmitPayoutBatch(ctx, &pb.SubmitPayoutBatchRequest{Customerid: 1, AccountNumber: broken.AccountNumber, Format: "csv", Content: []byte(file)})
+	second, _ := s.SubmitPayoutBatch(ctx, &pb.SubmitPayoutBatchRequest{Customerid: 2, AccountNumber: payroll.AccountNumber, Format: "csv", Content: []byte(file)})
+	if !first.Success || !second.Success {
+		t.Fatalf("SubmitPayoutBatch failed: %v, %v", first.Message, second.Message)
+	}
+	// The account of the first batch can no longer be read.
+	db.Model(&entity.PayoutBatch{}).Where("id = ?", first.BatchId).Update("account_id", 9999)
+
+	executed, err := s.accountService.ExecutePayoutBatches(ctx, time.Now())
+	if err == nil || executed != 1 {
+		t.Fatalf("Expected the second batch to be paid and the first reported, got %d: %v", executed, err)
+	}
+	if done, _ := s.GetPayoutBatch(ctx, &pb.GetPayoutBatchRequest{Customerid: 2, BatchId: second.BatchId}); done.Status != "completed" || done.Succeeded != 1 {
+		t.Errorf("Expected the second batch to complete, got %v", done)
+	}
+
+	// The line is retried, and failed on the last attempt.
+	for i := 1; i < services.PayoutLineMaxAttempts; i++ {
+		s.accountService.ExecutePayoutBatches(ctx, time.Now())
+	}
+	var line entity.PayoutLine
+	db.Where("batch_id = ?", first.BatchId).Take(&line)
+	if line.Status != entity.PayoutLineFailed || line.Message != "payment failed" || line.Attempts != services.PayoutLineMaxAttempts {
+		t.Errorf("Expected the line to fail after %d attempts, got %+v", services.PayoutLineMaxAttempts, line)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/m-dehghani/account-service/domain/entity"
+	"github.com/m-dehghani/account-service/domain/services"
+)
+
+// Exit codes of the verify subcommand.
+const (
+	verifyOK      = 0
+	verifyDrift   = 1
+	verifyFailure = 2
+)
+
+// runVerify implements "account-service verify [-json path]": it recomputes
+// every balance from the transaction log, prints the accounts that drift and
+// returns the exit code. -json also writes the report as JSON to path, or to
+// stdout when path is "-".
+func runVerify(accountService *services.AccountService, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonPath := flags.String("json", "", `write the report as JSON to this file, or to stdout for "-"`)
+	if err := flags.Parse(args); err != nil {
+		return verifyFailure
+	}
+
+	report, err := accountService.VerifyLedger(context.Background())
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return verifyFailure
+	}
+
+	if *jsonPath != "-" {
+		printReport(stdout, report)
+	}
+	if *jsonPath != "" {
+		if err := writeReport(*jsonPath, stdout, report); err != nil {
+			fmt.Fprintf(stderr, "verify: %v\n", err)
+			return verifyFailure
+		}
+	}
+	if !report.OK() {
+		return verifyDrift
+	}
+	return verifyOK
+}
+
+func printReport(w io.Writer, report *services.LedgerReport) {
+	for _, drift := range report.Drifted {
+		money := func(units int64) string { return entity.Money{Units: units, Currency: drift.Currency}.String() }
+		fmt.Fprintf(w, "account %s: ledger %s, transactions %s, drift %s\n",
+			drift.AccountNumber, money(drift.LedgerBalance), money(drift.LogBalance), money(drift.Drift))
+		if drift.MissingLedgerAccount {
+			fmt.Fprintf(w, "  no ledger account\n")
+		}
+		if d := drift.FirstDivergence; d != nil {
+			fmt.Fprintf(w, "  first divergence: transaction %d, journal entry %d on %s (ledger %s, transactions %s)\n",
+				d.TransactionID, d.JournalEntryID, d.Date.Format(time.RFC3339), money(d.LedgerBalance), money(d.LogBalance))
+		}
+		for _, snapshot := range drift.Snapshots {
+			fmt.Fprintf(w, "  snapshot as of %s: %s, transactions %s\n",
+				snapshot.AsOf.Format(time.RFC3339), money(snapshot.Balance), money(snapshot.LogBalance))
+		}
+	}
+	fmt.Fprintf(w, "%d accounts checked, %d drifting\n", report.Accounts, len(report.Drifted))
+}
+
+func writeReport(path string, stdout io.Writer, report *services.LedgerReport) error {
+	payload, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	payload = append(payload, '\n')
+	if path == "-" {
+		_, err = stdout.Write(payload)
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/m-dehghani/gateway-service/models/valueobjects"
 	pb "github.com/m-dehghani/gateway-service/proto"
 
 	"github.com/gin-gonic/gin"
@@ -45,13 +46,13 @@ func (m *mockAccountServiceClient) Withdraw(ctx context.Context, in *pb.Withdraw
 }
 
 func (m *mockAccountServiceClient) BalanceInquiry(ctx context.Context, in *pb.BalanceInquiryRequest, opts ...grpc.CallOption) (*pb.BalanceInquiryResponse, error) {
-	return &pb.BalanceInquiryResponse{Balance: 100.0, Message: "balance inquiry successful"}, nil
+	return &pb.BalanceInquiryResponse{Balance: &pb.Money{Units: 10000, Currency: "USD"}, Message: "balance inquiry successful"}, nil
 }
 
 func (m *mockAccountServiceClient) TransactionHistory(ctx context.Context, in *pb.TransactionHistoryRequest, opts ...grpc.CallOption) (*pb.TransactionHistoryResponse, error) {
 	transactions := []*pb.Transaction{
-		{Id: 1, Customerid: 1, Type: "deposit", Amount: 50.0, Date: "2023-01-01T00:00:00Z"},
-		{Id: 2, Customerid: 1, Type: "withdraw", Amount: 30.0, Date: "2023-01-02T00:00:00Z"},
+		{Id: 1, Customerid: 1, Type: "deposit", Amount: &pb.Money{Units: 5000, Currency: "USD"}, Date: "2023-01-01T00:00:00Z"},
+		{Id: 2, Customerid: 1, Type: "withdraw", Amount: &pb.Money{Units: 3000, Currency: "USD"}, Date: "2023-01-02T00:00:00Z"},
 	}
 	return &pb.TransactionHistoryResponse{Transactions: transactions, Message: "transaction history retrieved"}, nil
 }
@@ -128,17 +129,23 @@ func setupRouter() *gin.Engine {
 
 	r.POST("/deposit", func(c *gin.Context) {
 		var req struct {
-			CustomerID uint32  `json:"customer_id"`
-			Amount     float64 `json:"amount"`
+			CustomerID uint32 `json:"customer_id"`
+			Amount     string `json:"amount"`
+			Currency   string `json:"currency"`
 		}
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		grpcReq := &pb.DepositRequest{
 			Customerid: req.CustomerID,
-			Amount:     req.Amount,
+			Amount:     &pb.Money{Units: amount.Units, Currency: amount.Currency},
 		}
 
 		grpcRes, err := accountClient.Deposit(context.Background(), grpcReq)
@@ -155,17 +162,23 @@ func setupRouter() *gin.Engine {
 
 	r.POST("/withdraw", func(c *gin.Context) {
 		var req struct {
-			CustomerID uint32  `json:"customer_id"`
-			Amount     float64 `json:"amount"`
+			CustomerID uint32 `json:"customer_id"`
+			Amount     string `json:"amount"`
+			Currency   string `json:"currency"`
 		}
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		grpcReq := &pb.WithdrawRequest{
 			Customerid: req.CustomerID,
-			Amount:     req.Amount,
+			Amount:     &pb.Money{Units: amount.Units, Currency: amount.Currency},
 		}
 
 		grpcRes, err := accountClient.Withdraw(context.Background(), grpcReq)
@@ -257,3 +270,48 @@ func TestLogin(t *testing.T) {
 	assert.Equal(t, "mock_token", response["token"])
 	assert.Equal(t, "login successful", response["message"])
 }
+
+func TestDepositRejectsInexactAmount(t *testing.T) {
+	router := setupRouter()
+
+	for _, body := range []string{
+		`{"customer_id":1,"amount":10.5}`,
+		`{"customer_id":1,"amount":"10.505","currency":"USD"}`,
+		`{"customer_id":1,"amount":"1e3"}`,
+		`{"customer_id":1,"amount":"-5"}`,
+		`{"customer_id":1,"amount":"5","currency":"XXX"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/deposit", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		units    int64
+	}{
+		{"10.50", "USD", 1050},
+		{"10.5", "usd", 1050},
+		{"7", "", 700},
+		{"1500", "JPY", 1500},
+		{"0.001", "BHD", 1},
+	}
+	for _, c := range cases {
+		money, err := valueobjects.NewMoney(c.amount, c.currency)
+		if assert.NoError(t, err, c.amount) {
+			assert.Equal(t, c.units, money.Units, c.amount)
+		}
+	}
+
+	_, err := valueobjects.NewMoney("1.5", "JPY")
+	assert.Equal(t, valueobjects.ErrTooManyDecimals, err)
+	_, err = valueobjects.NewMoney("99999999999999999999", "USD")
+	assert.Equal(t, valueobjects.ErrAmountTooLarge, err)
+	_, err = valueobjects.NewMoney("0.00", "USD")
+	assert.Equal(t, valueobjects.ErrInvalidAmount, err)
+}
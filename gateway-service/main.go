@@ -60,7 +60,15 @@ func main() {
 		handlers.Logout(c, grpcClient, cb)
 	})
 
-	r.POST("/deposit", middleware.Authenticate, func(c *gin.Context) {
+	r.GET("/accounts", middleware.Authenticate, func(c *gin.Context) {
+		handlers.ListAccounts(c, grpcClient, cb)
+	})
+
+	r.POST("/accounts", middleware.Authenticate, func(c *gin.Context) {
+		handlers.OpenAccount(c, grpcClient, cb)
+	})
+
+	r.POST("/deposit", middleware.Authenticate, middleware.Idempotency, func(c *gin.Context) {
 		if !rateLimiter.Allow() {
 			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
 			return
@@ -76,6 +84,14 @@ func main() {
 		handlers.Withdraw(c, grpcClient, cb)
 	})
 
+	r.POST("/transfer", middleware.Authenticate, middleware.Idempotency, func(c *gin.Context) {
+		if !rateLimiter.Allow() {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+		handlers.Transfer(c, grpcClient, cb)
+	})
+
 	r.GET("/balance", middleware.Authenticate, func(c *gin.Context) {
 		handlers.Balance(c, grpcClient, cb)
 	})
@@ -84,6 +100,70 @@ func main() {
 		handlers.Transactions(c, grpcClient, cb)
 	})
 
+	r.GET("/transactions/export", middleware.Authenticate, func(c *gin.Context) {
+		handlers.ExportTransactions(c, grpcClient, cb)
+	})
+
+	r.GET("/statements", middleware.Authenticate, func(c *gin.Context) {
+		handlers.Statement(c, grpcClient, cb)
+	})
+
+	r.GET("/statements/export", middleware.Authenticate, func(c *gin.Context) {
+		handlers.ExportStatement(c, grpcClient, cb)
+	})
+
+	r.POST("/standing-orders", middleware.Authenticate, func(c *gin.Context) {
+		handlers.CreateStandingOrder(c, grpcClient, cb)
+	})
+
+	r.GET("/standing-orders", middleware.Authenticate, func(c *gin.Context) {
+		handlers.ListStandingOrders(c, grpcClient, cb)
+	})
+
+	r.DELETE("/standing-orders/:id", middleware.Authenticate, func(c *gin.Context) {
+		handlers.CancelStandingOrder(c, grpcClient, cb)
+	})
+
+	r.GET("/standing-orders/:id/executions", middleware.Authenticate, func(c *gin.Context) {
+		handlers.StandingOrderExecutions(c, grpcClient, cb)
+	})
+
+	r.POST("/payouts", middleware.Authenticate, middleware.Idempotency, func(c *gin.Context) {
+		handlers.SubmitPayoutBatch(c, grpcClient, cb)
+	})
+
+	r.GET("/payouts/:id", middleware.Authenticate, func(c *gin.Context) {
+		handlers.GetPayoutBatch(c, grpcClient, cb)
+	})
+
+	r.GET("/payouts/:id/results", middleware.Authenticate, func(c *gin.Context) {
+		handlers.PayoutBatchResults(c, grpcClient, cb)
+	})
+
+	r.POST("/admin/accounts/:number/status", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.ChangeAccountStatus(c, grpcClient, cb)
+	})
+
+	r.GET("/admin/accounts/:number/status", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.AccountStatusHistory(c, grpcClient, cb)
+	})
+
+	r.GET("/admin/accounts/:number/balance", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.BalanceAsOf(c, grpcClient, cb)
+	})
+
+	r.GET("/admin/ledger/verify", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.VerifyTransactionChain(c, grpcClient, cb)
+	})
+
+	r.POST("/admin/reconciliations", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.Reconcile(c, grpcClient, cb)
+	})
+
+	r.GET("/admin/reconciliations/:id", middleware.Authenticate, middleware.RequireAdmin, func(c *gin.Context) {
+		handlers.GetReconciliation(c, grpcClient, cb)
+	})
+
 	r.GET("/ping", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, "pong")
 	})
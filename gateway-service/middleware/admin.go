@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin lets through the users listed in ADMIN_USERS, a comma
+// separated list of usernames. It must run after Authenticate.
+func RequireAdmin(c *gin.Context) {
+	username, _ := c.Get("username")
+	for _, admin := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && admin == username {
+			c.Next()
+			return
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+	c.Abort()
+}
@@ -2,28 +2,18 @@ package middleware
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/patrickmn/go-cache"
 )
 
-var idempotencyCache = cache.New(5*time.Minute, 10*time.Minute)
-
+// Idempotency requires an Idempotency-Key header. The handlers pass it to
+// the account service as the request_id, which books the request at most
+// once and answers repeats with the original response.
 func Idempotency(c *gin.Context) {
-	idempotencyKey := c.GetHeader("Idempotency-Key")
-	if idempotencyKey == "" {
+	if c.GetHeader("Idempotency-Key") == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing idempotency key"})
 		c.Abort()
 		return
 	}
-
-	if _, found := idempotencyCache.Get(idempotencyKey); found {
-		c.JSON(http.StatusConflict, gin.H{"error": "duplicate request"})
-		c.Abort()
-		return
-	}
-
-	idempotencyCache.Set(idempotencyKey, true, cache.DefaultExpiration)
 	c.Next()
 }
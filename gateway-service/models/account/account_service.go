@@ -18,6 +18,14 @@ func (s *AccountService) CreateAccount(ctx context.Context, req *pb.CreateAccoun
 	return s.client.CreateAccount(ctx, req)
 }
 
+func (s *AccountService) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.OpenAccountResponse, error) {
+	return s.client.OpenAccount(ctx, req)
+}
+
+func (s *AccountService) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	return s.client.ListAccounts(ctx, req)
+}
+
 func (s *AccountService) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.DepositResponse, error) {
 	return s.client.Deposit(ctx, req)
 }
@@ -26,6 +34,10 @@ func (s *AccountService) Withdraw(ctx context.Context, req *pb.WithdrawRequest)
 	return s.client.Withdraw(ctx, req)
 }
 
+func (s *AccountService) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	return s.client.Transfer(ctx, req)
+}
+
 func (s *AccountService) BalanceInquiry(ctx context.Context, req *pb.BalanceInquiryRequest) (*pb.BalanceInquiryResponse, error) {
 	return s.client.BalanceInquiry(ctx, req)
 }
@@ -33,3 +45,59 @@ func (s *AccountService) BalanceInquiry(ctx context.Context, req *pb.BalanceInqu
 func (s *AccountService) TransactionHistory(ctx context.Context, req *pb.TransactionHistoryRequest) (*pb.TransactionHistoryResponse, error) {
 	return s.client.TransactionHistory(ctx, req)
 }
+
+func (s *AccountService) StreamTransactions(ctx context.Context, req *pb.StreamTransactionsRequest) (pb.AccountService_StreamTransactionsClient, error) {
+	return s.client.StreamTransactions(ctx, req)
+}
+
+func (s *AccountService) GetStatement(ctx context.Context, req *pb.GetStatementRequest) (*pb.GetStatementResponse, error) {
+	return s.client.GetStatement(ctx, req)
+}
+
+func (s *AccountService) CreateStandingOrder(ctx context.Context, req *pb.CreateStandingOrderRequest) (*pb.CreateStandingOrderResponse, error) {
+	return s.client.CreateStandingOrder(ctx, req)
+}
+
+func (s *AccountService) ListStandingOrders(ctx context.Context, req *pb.ListStandingOrdersRequest) (*pb.ListStandingOrdersResponse, error) {
+	return s.client.ListStandingOrders(ctx, req)
+}
+
+func (s *AccountService) CancelStandingOrder(ctx context.Context, req *pb.CancelStandingOrderRequest) (*pb.CancelStandingOrderResponse, error) {
+	return s.client.CancelStandingOrder(ctx, req)
+}
+
+func (s *AccountService) StandingOrderExecutions(ctx context.Context, req *pb.StandingOrderExecutionsRequest) (*pb.StandingOrderExecutionsResponse, error) {
+	return s.client.StandingOrderExecutions(ctx, req)
+}
+
+func (s *AccountService) ChangeAccountStatus(ctx context.Context, req *pb.ChangeAccountStatusRequest) (*pb.ChangeAccountStatusResponse, error) {
+	return s.client.ChangeAccountStatus(ctx, req)
+}
+
+func (s *AccountService) AccountStatusHistory(ctx context.Context, req *pb.AccountStatusHistoryRequest) (*pb.AccountStatusHistoryResponse, error) {
+	return s.client.AccountStatusHistory(ctx, req)
+}
+
+func (s *AccountService) BalanceAsOf(ctx context.Context, req *pb.BalanceAsOfRequest) (*pb.BalanceAsOfResponse, error) {
+	return s.client.BalanceAsOf(ctx, req)
+}
+
+func (s *AccountService) VerifyTransactionChain(ctx context.Context, req *pb.VerifyTransactionChainRequest) (*pb.VerifyTransactionChainResponse, error) {
+	return s.client.VerifyTransactionChain(ctx, req)
+}
+
+func (s *AccountService) Reconcile(ctx context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	return s.client.Reconcile(ctx, req)
+}
+
+func (s *AccountService) GetReconciliation(ctx context.Context, req *pb.GetReconciliationRequest) (*pb.ReconcileResponse, error) {
+	return s.client.GetReconciliation(ctx, req)
+}
+
+func (s *AccountService) SubmitPayoutBatch(ctx context.Context, req *pb.SubmitPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	return s.client.SubmitPayoutBatch(ctx, req)
+}
+
+func (s *AccountService) GetPayoutBatch(ctx context.Context, req *pb.GetPayoutBatchRequest) (*pb.PayoutBatchResponse, error) {
+	return s.client.GetPayoutBatch(ctx, req)
+}
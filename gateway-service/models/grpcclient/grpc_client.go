@@ -9,13 +9,18 @@ import (
 	"google.golang.org/grpc"
 )
 
+// MaxMessageSize is the largest message exchanged with the account service,
+// set above gRPC's default of 4 MiB so that payout files fit.
+const MaxMessageSize = 16 << 20
+
 type GRPCClient struct {
 	AccountService  *account.AccountService
 	CustomerService *customer.CustomerService
 }
 
 func NewGRPCClient() *GRPCClient {
-	accountConn, err := grpc.Dial("account-service:50052", grpc.WithInsecure())
+	accountConn, err := grpc.Dial("account-service:50052", grpc.WithInsecure(),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(MaxMessageSize), grpc.MaxCallRecvMsgSize(MaxMessageSize)))
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -2,35 +2,249 @@ package handlers
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	"github.com/m-dehghani/gateway-service/models/valueobjects"
 	pb "github.com/m-dehghani/gateway-service/proto"
 	"github.com/sony/gobreaker"
 )
 
 // BalanceRequest represents the request parameters for the Balance endpoint
 type BalanceRequest struct {
-	CustomerID uint32 `json:"customer_id"`
+	CustomerID    uint32 `json:"customer_id"`
+	AccountNumber string `json:"account_number"`
 }
 
 // TransactionsRequest represents the request parameters for the Transactions endpoint
 type TransactionsRequest struct {
-	CustomerID uint32 `json:"customer_id"`
+	CustomerID    uint32 `json:"customer_id"`
+	AccountNumber string `json:"account_number"`
+}
+
+// OpenAccountRequest represents the request body for the OpenAccount endpoint
+type OpenAccountRequest struct {
+	CustomerID  uint32 `json:"customer_id"`
+	ProductType string `json:"product_type" example:"savings"`
+	Currency    string `json:"currency" example:"USD"`
 }
 
 // DepositRequest represents the request body for the Deposit endpoint
 type DepositRequest struct {
-	CustomerID uint32  `json:"customer_id"`
-	Amount     float64 `json:"amount"`
+	CustomerID    uint32 `json:"customer_id"`
+	AccountNumber string `json:"account_number" example:"000000000197"`
+	Amount        string `json:"amount" example:"10.50"`
+	Currency      string `json:"currency" example:"USD"`
 }
 
 // WithdrawRequest represents the request body for the Withdraw endpoint
 type WithdrawRequest struct {
-	CustomerID uint32  `json:"customer_id"`
-	Amount     float64 `json:"amount"`
+	CustomerID    uint32 `json:"customer_id"`
+	AccountNumber string `json:"account_number" example:"000000000197"`
+	Amount        string `json:"amount" example:"10.50"`
+	Currency      string `json:"currency" example:"USD"`
+}
+
+// TransferRequest represents the request body for the Transfer endpoint
+type TransferRequest struct {
+	FromCustomerID    uint32 `json:"from_customer_id"`
+	FromAccountNumber string `json:"from_account_number" example:"000000000197"`
+	ToAccountNumber   string `json:"to_account_number" example:"000000000294"`
+	Amount            string `json:"amount" example:"10.50"`
+	Currency          string `json:"currency" example:"USD"`
+	Reference         string `json:"reference"`
+}
+
+// AccountResponse represents one account of a customer
+type AccountResponse struct {
+	Number      string        `json:"number"`
+	ProductType string        `json:"product_type"`
+	Currency    string        `json:"currency"`
+	Balance     MoneyResponse `json:"balance"`
+	Status      string        `json:"status"`
+}
+
+// MoneyResponse is an amount rendered as a decimal string in major units
+type MoneyResponse struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// TransactionResponse represents one entry of the transaction history
+type TransactionResponse struct {
+	ID                  uint32        `json:"id"`
+	CustomerID          uint32        `json:"customer_id"`
+	Type                string        `json:"type"`
+	Amount              MoneyResponse `json:"amount"`
+	Date                string        `json:"date"`
+	LinkedTransactionID uint32        `json:"linked_transaction_id,omitempty"`
+	Reference           string        `json:"reference,omitempty"`
+	AccountNumber       string        `json:"account_number"`
+	ReversesID          uint32        `json:"reverses_transaction_id,omitempty"`
+	ReversedByID        uint32        `json:"reversed_by_transaction_id,omitempty"`
+	ReasonCode          string        `json:"reason_code,omitempty"`
+	Actor               string        `json:"actor,omitempty"`
+}
+
+func moneyFromProto(m *pb.Money) MoneyResponse {
+	money := valueobjects.Money{Units: m.GetUnits(), Currency: m.GetCurrency()}
+	return MoneyResponse{Amount: money.Decimal(), Currency: money.Currency}
+}
+
+func moneyToProto(m *valueobjects.Money) *pb.Money {
+	return &pb.Money{Units: m.Units, Currency: m.Currency}
+}
+
+func transactionsFromProto(transactions []*pb.Transaction) []TransactionResponse {
+	response := make([]TransactionResponse, 0, len(transactions))
+	for _, t := range transactions {
+		response = append(response, TransactionResponse{
+			ID:                  t.Id,
+			CustomerID:          t.Customerid,
+			Type:                t.Type,
+			Amount:              moneyFromProto(t.Amount),
+			Date:                t.Date,
+			LinkedTransactionID: t.LinkedTransactionId,
+			Reference:           t.Reference,
+			AccountNumber:       t.AccountNumber,
+			ReversesID:          t.ReversesTransactionId,
+			ReversedByID:        t.ReversedByTransactionId,
+			ReasonCode:          t.ReasonCode,
+			Actor:               t.Actor,
+		})
+	}
+	return response
+}
+
+func accountFromProto(a *pb.Account) AccountResponse {
+	return AccountResponse{
+		Number:      a.Number,
+		ProductType: a.ProductType,
+		Currency:    a.Currency,
+		Balance:     moneyFromProto(a.Balance),
+		Status:      a.Status,
+	}
+}
+
+func accountsFromProto(accounts []*pb.Account) []AccountResponse {
+	response := make([]AccountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		response = append(response, accountFromProto(a))
+	}
+	return response
+}
+
+// @Summary		Open an account
+// @Description	Open an additional checking or savings account for the customer
+// @Tags			Account
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header	string				true	"Token"
+// @Param			request			body	OpenAccountRequest	true	"Open Account Request"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/accounts [post]
+func OpenAccount(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	var req OpenAccountRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, _ := c.Get("username")
+
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: req.CustomerID,
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(context.Background(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	grpcReq := &pb.OpenAccountRequest{
+		Customerid:  req.CustomerID,
+		ProductType: req.ProductType,
+		Currency:    req.Currency,
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.OpenAccount(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.OpenAccountResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": res.Message,
+		"account": accountFromProto(res.Account),
+	})
+}
+
+// @Summary		List accounts
+// @Description	List the customer's accounts with their balances
+// @Tags			Account
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Success		200
+// @Failure		401
+// @Failure		500
+// @Router			/accounts [get]
+func ListAccounts(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	username, _ := c.Get("username")
+
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: uint32(customerID),
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(context.Background(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	grpcReq := &pb.ListAccountsRequest{
+		Customerid: uint32(customerID),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.ListAccounts(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accountsFromProto(grpcRes.(*pb.ListAccountsResponse).Accounts),
+		"message":  grpcRes.(*pb.ListAccountsResponse).Message,
+	})
 }
 
 // @Summary		Deposit money into account
@@ -39,6 +253,7 @@ type WithdrawRequest struct {
 // @Accept			json
 // @Produce		json
 // @Param			Authorization	header	string			true	"Token"
+// @Param			Idempotency-Key	header	string			true	"Retries with the same key are booked once"
 // @Param			request			body	DepositRequest	true	"Deposit Request"
 // @Success		200
 // @Failure		400
@@ -51,6 +266,11 @@ func Deposit(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.Ci
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	username, _ := c.Get("username")
 
@@ -68,8 +288,10 @@ func Deposit(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.Ci
 	}
 
 	grpcReq := &pb.DepositRequest{
-		Customerid: req.CustomerID,
-		Amount:     req.Amount,
+		Customerid:    req.CustomerID,
+		AccountNumber: req.AccountNumber,
+		Amount:        moneyToProto(amount),
+		RequestId:     c.GetHeader("Idempotency-Key"),
 	}
 
 	grpcRes, err := cb.Execute(func() (interface{}, error) {
@@ -91,11 +313,14 @@ func Deposit(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.Ci
 // @Tags			Account
 // @Accept			json
 // @Param			Authorization	header	string	true	"Token"
+// @Param			Idempotency-Key	header	string	true	"Retries with the same key are booked once"
 // @Produce		json
 // @Param			request	body	WithdrawRequest	true	"Withdraw Request"
 // @Success		200
 // @Failure		400
 // @Failure		401
+// @Failure		422	"Above the single withdrawal limit; the body's limit tells which"
+// @Failure		429	"Above a daily, monthly or hourly withdrawal limit; Retry-After tells when it resets"
 // @Failure		500
 // @Router			/withdraw [post]
 func Withdraw(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
@@ -104,6 +329,11 @@ func Withdraw(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.C
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	username, _ := c.Get("username")
 
 	userValidationReq := &pb.VerifyCustomerIDRequest{
@@ -120,8 +350,10 @@ func Withdraw(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.C
 	}
 
 	grpcReq := &pb.WithdrawRequest{
-		Customerid: req.CustomerID,
-		Amount:     req.Amount,
+		Customerid:    req.CustomerID,
+		AccountNumber: req.AccountNumber,
+		Amount:        moneyToProto(amount),
+		RequestId:     c.GetHeader("Idempotency-Key"),
 	}
 
 	grpcRes, err := cb.Execute(func() (interface{}, error) {
@@ -132,19 +364,113 @@ func Withdraw(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.C
 		return
 	}
 
+	res := grpcRes.(*pb.WithdrawResponse)
+	if res.LimitViolation != nil {
+		limitExceeded(c, res.Message, res.LimitViolation)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"success": grpcRes.(*pb.WithdrawResponse).Success,
-		"message": grpcRes.(*pb.WithdrawResponse).Message,
+		"success": res.Success,
+		"message": res.Message,
+	})
+}
+
+// limitExceeded reports a withdrawal rejected by a limit. Limits that reset
+// are rate limits: the client is told when to retry.
+func limitExceeded(c *gin.Context, message string, violation *pb.LimitViolation) {
+	status := http.StatusUnprocessableEntity
+	if resetsAt, err := time.Parse(time.RFC3339, violation.ResetsAt); err == nil {
+		status = http.StatusTooManyRequests
+		if wait := int(math.Ceil(time.Until(resetsAt).Seconds())); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(wait))
+		}
+	}
+	limit := gin.H{
+		"reason": violation.Reason,
+		"scope":  violation.Scope,
+		"limit":  violation.Limit,
+		"used":   violation.Used,
+	}
+	if violation.Currency != "" {
+		limit["currency"] = violation.Currency
+	}
+	if violation.ResetsAt != "" {
+		limit["resets_at"] = violation.ResetsAt
+	}
+	c.JSON(status, gin.H{"success": false, "message": message, "limit": limit})
+}
+
+// @Summary		Transfer money to another customer
+// @Description	Move a specified amount from one of the caller's accounts to another account in one transaction
+// @Tags			Account
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header	string			true	"Token"
+// @Param			Idempotency-Key	header	string			true	"Retries with the same key are booked once"
+// @Param			request			body	TransferRequest	true	"Transfer Request"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/transfer [post]
+func Transfer(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	var req TransferRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, _ := c.Get("username")
+
+	// Only the owner of the source account may move money out of it.
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: req.FromCustomerID,
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(context.Background(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	grpcReq := &pb.TransferRequest{
+		FromCustomerid:    req.FromCustomerID,
+		FromAccountNumber: req.FromAccountNumber,
+		ToAccountNumber:   req.ToAccountNumber,
+		Amount:            moneyToProto(amount),
+		Reference:         req.Reference,
+		RequestId:         c.GetHeader("Idempotency-Key"),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.Transfer(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": grpcRes.(*pb.TransferResponse).Success,
+		"message": grpcRes.(*pb.TransferResponse).Message,
 	})
 }
 
 //	@Summary		Get account balance
-//	@Description	Get the balance of the customer's account
+//	@Description	Get the ledger balance, the available balance net of active holds, and the overdraft limit and remaining headroom of the customer's account
 //	@Tags			Account
 //	@Accept			json
 //	@Produce		json
 //	@Param			Authorization	header	string	true	"Token"
 //	@Param			customer_id		query	uint32	true	"Customer ID"
+//	@Param			account_number	query	string	true	"Account number"
 //	@Success		200
 //	@Failure		401
 //	@Failure		500
@@ -172,7 +498,8 @@ func Balance(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.Ci
 	}
 
 	grpcReq := &pb.BalanceInquiryRequest{
-		Customerid: uint32(customerID),
+		Customerid:    uint32(customerID),
+		AccountNumber: c.Query("account_number"),
 	}
 
 	grpcRes, err := cb.Execute(func() (interface{}, error) {
@@ -184,18 +511,27 @@ func Balance(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.Ci
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"balance": grpcRes.(*pb.BalanceInquiryResponse).Balance,
-		"message": grpcRes.(*pb.BalanceInquiryResponse).Message,
+		"balance":            moneyFromProto(grpcRes.(*pb.BalanceInquiryResponse).Balance),
+		"available_balance":  moneyFromProto(grpcRes.(*pb.BalanceInquiryResponse).AvailableBalance),
+		"overdraft_limit":    moneyFromProto(grpcRes.(*pb.BalanceInquiryResponse).OverdraftLimit),
+		"overdraft_headroom": moneyFromProto(grpcRes.(*pb.BalanceInquiryResponse).OverdraftHeadroom),
+		"message":            grpcRes.(*pb.BalanceInquiryResponse).Message,
 	})
 }
 
 // @Summary		Get transaction history
 // @Param			Authorization	header	string	true	"Token"
-// @Description	Get the transaction history of the customer's account
+// @Description	Get a page of the transaction history of the customer's accounts, oldest first
 // @Tags			Account
 // @Accept			json
 // @Produce		json
-// @Param			customer_id	query	uint32	true	"Customer ID"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Param			account_number	query	string	false	"Account number, all accounts when omitted"
+// @Param			limit			query	int		false	"Page size, 50 by default and at most 500"
+// @Param			cursor			query	string	false	"next_cursor of the previous page"
+// @Param			from			query	string	false	"Start, RFC 3339 time or YYYY-MM-DD, inclusive"
+// @Param			to				query	string	false	"End, RFC 3339 time (exclusive) or YYYY-MM-DD (inclusive)"
+// @Param			type			query	string	false	"Comma separated transaction types"
 // @Success		200
 // @Failure		401
 // @Failure		500
@@ -221,8 +557,23 @@ func Transactions(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreak
 		return
 	}
 
+	var limit int64
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a non-negative integer"})
+			return
+		}
+	}
+
 	grpcReq := &pb.TransactionHistoryRequest{
-		Customerid: uint32(customerID),
+		Customerid:    uint32(customerID),
+		AccountNumber: c.Query("account_number"),
+		Limit:         int32(limit),
+		Cursor:        c.Query("cursor"),
+		From:          c.Query("from"),
+		To:            c.Query("to"),
+		Type:          c.Query("type"),
 	}
 
 	grpcRes, err := cb.Execute(func() (interface{}, error) {
@@ -234,7 +585,8 @@ func Transactions(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreak
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"transactions": grpcRes.(*pb.TransactionHistoryResponse).Transactions,
+		"transactions": transactionsFromProto(grpcRes.(*pb.TransactionHistoryResponse).Transactions),
 		"message":      grpcRes.(*pb.TransactionHistoryResponse).Message,
+		"next_cursor":  grpcRes.(*pb.TransactionHistoryResponse).NextCursor,
 	})
 }
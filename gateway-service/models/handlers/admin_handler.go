@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+)
+
+// AccountStatusRequest represents the request body for the ChangeAccountStatus endpoint
+type AccountStatusRequest struct {
+	Status string `json:"status" example:"frozen"`
+	Reason string `json:"reason" example:"fraud investigation"`
+}
+
+// AccountStatusChangeResponse represents one audited status change of an account
+type AccountStatusChangeResponse struct {
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	Actor      string `json:"actor"`
+	ChangedAt  string `json:"changed_at"`
+}
+
+// @Summary		Change the status of an account
+// @Description	Move an account to active, frozen, dormant or closed. Frozen and dormant accounts accept credits only, closed ones nothing; closing needs a zero balance. The logged in admin is recorded as the actor.
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header	string					true	"Token"
+// @Param			number			path	string					true	"Account number"
+// @Param			request			body	AccountStatusRequest	true	"Account Status Request"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/accounts/{number}/status [post]
+func ChangeAccountStatus(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	var req AccountStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, _ := c.Get("username")
+
+	grpcReq := &pb.ChangeAccountStatusRequest{
+		AccountNumber: c.Param("number"),
+		Status:        req.Status,
+		Reason:        req.Reason,
+		Actor:         username.(string),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.ChangeAccountStatus(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.ChangeAccountStatusResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": res.Message,
+		"account": accountFromProto(res.Account),
+	})
+}
+
+// @Summary		Account status history
+// @Description	Get the current status of an account and every audited change that led to it, oldest first
+// @Tags			Admin
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			number			path	string	true	"Account number"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/accounts/{number}/status [get]
+func AccountStatusHistory(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	grpcReq := &pb.AccountStatusHistoryRequest{AccountNumber: c.Param("number")}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.AccountStatusHistory(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.AccountStatusHistoryResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	changes := make([]AccountStatusChangeResponse, 0, len(res.Changes))
+	for _, change := range res.Changes {
+		changes = append(changes, AccountStatusChangeResponse{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			Reason:     change.Reason,
+			Actor:      change.Actor,
+			ChangedAt:  change.ChangedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": res.Message,
+		"status":  res.Status,
+		"changes": changes,
+	})
+}
+
+// @Summary		Balance at a point in time
+// @Description	Get the balance of an account from every transaction booked before as_of, an RFC 3339 time or a date for the end of that day
+// @Tags			Admin
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			number			path	string	true	"Account number"
+// @Param			as_of			query	string	true	"Point in time"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/accounts/{number}/balance [get]
+func BalanceAsOf(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	grpcReq := &pb.BalanceAsOfRequest{
+		AccountNumber: c.Param("number"),
+		AsOf:          c.Query("as_of"),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.BalanceAsOf(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.BalanceAsOfResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        res.Message,
+		"balance":        moneyFromProto(res.Balance),
+		"as_of":          res.AsOf,
+		"snapshot_as_of": res.SnapshotAsOf,
+	})
+}
+
+// ChainBreakResponse is the first transaction at which the hash chain of an account stops matching
+type ChainBreakResponse struct {
+	AccountNumber string `json:"account_number"`
+	Sequence      uint32 `json:"sequence"`
+	TransactionID uint32 `json:"transaction_id,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// AnchorMismatchResponse is a published daily anchor the transactions no longer hash to
+type AnchorMismatchResponse struct {
+	Day          string `json:"day"`
+	AnchorHash   string `json:"anchor_hash"`
+	ComputedHash string `json:"computed_hash"`
+}
+
+// @Summary		Verify the transaction chain
+// @Description	Recompute the hash chain of the transactions of one account, or of every account and the published daily anchors, and locate where it was tampered with
+// @Tags			Admin
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			account_number	query	string	false	"Account number"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/ledger/verify [get]
+func VerifyTransactionChain(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	grpcReq := &pb.VerifyTransactionChainRequest{AccountNumber: c.Query("account_number")}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.VerifyTransactionChain(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.VerifyTransactionChainResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	breaks := make([]ChainBreakResponse, 0, len(res.Breaks))
+	for _, b := range res.Breaks {
+		breaks = append(breaks, ChainBreakResponse{
+			AccountNumber: b.AccountNumber,
+			Sequence:      b.Sequence,
+			TransactionID: b.TransactionId,
+			Reason:        b.Reason,
+		})
+	}
+	mismatches := make([]AnchorMismatchResponse, 0, len(res.AnchorMismatches))
+	for _, m := range res.AnchorMismatches {
+		mismatches = append(mismatches, AnchorMismatchResponse{
+			Day:          m.Day,
+			AnchorHash:   m.AnchorHash,
+			ComputedHash: m.ComputedHash,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"message":           res.Message,
+		"intact":            res.Intact,
+		"accounts":          res.Accounts,
+		"transactions":      res.Transactions,
+		"breaks":            breaks,
+		"anchors":           res.Anchors,
+		"anchor_mismatches": mismatches,
+	})
+}
@@ -53,7 +53,7 @@ func Register(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.C
 	}
 
 	accountReq := &pb.CreateAccountRequest{Customerid: grpcRes.(*pb.RegisterResponse).Customerid}
-	_, err = cb.Execute(func() (interface{}, error) {
+	accountRes, err := cb.Execute(func() (interface{}, error) {
 		return grpcClient.AccountService.CreateAccount(context.Background(), accountReq)
 	})
 	if err != nil {
@@ -61,7 +61,11 @@ func Register(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.C
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": grpcRes.(*pb.RegisterResponse).Message, "customer-id": grpcRes.(*pb.RegisterResponse).Customerid})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        grpcRes.(*pb.RegisterResponse).Message,
+		"customer-id":    grpcRes.(*pb.RegisterResponse).Customerid,
+		"account-number": accountRes.(*pb.CreateAccountResponse).AccountNumber,
+	})
 }
 
 // @Summary		Login a user
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Content types ExportTransactions can produce.
+const (
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+// exportFlushEvery is how many rows are written between flushes of the
+// response.
+const exportFlushEvery = 100
+
+var csvHeader = []string{
+	"id", "date", "account_number", "type", "amount", "currency", "reference",
+	"linked_transaction_id", "reverses_transaction_id", "reversed_by_transaction_id", "reason_code", "actor",
+}
+
+// rowWriter writes one exported transaction in the negotiated format.
+type rowWriter interface {
+	Write(t TransactionResponse) error
+	Flush() error
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func newCSVRowWriter(w io.Writer) (*csvRowWriter, error) {
+	writer := &csvRowWriter{w: csv.NewWriter(w)}
+	return writer, writer.w.Write(csvHeader)
+}
+
+func (r *csvRowWriter) Write(t TransactionResponse) error {
+	return r.w.Write([]string{
+		strconv.FormatUint(uint64(t.ID), 10),
+		t.Date,
+		t.AccountNumber,
+		t.Type,
+		t.Amount.Amount,
+		t.Amount.Currency,
+		t.Reference,
+		optionalID(t.LinkedTransactionID),
+		optionalID(t.ReversesID),
+		optionalID(t.ReversedByID),
+		t.ReasonCode,
+		t.Actor,
+	})
+}
+
+func (r *csvRowWriter) Flush() error {
+	r.w.Flush()
+	return r.w.Error()
+}
+
+type ndjsonRowWriter struct {
+	enc *json.Encoder
+}
+
+func (r *ndjsonRowWriter) Write(t TransactionResponse) error {
+	return r.enc.Encode(t)
+}
+
+func (r *ndjsonRowWriter) Flush() error {
+	return nil
+}
+
+func optionalID(id uint32) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// negotiateExportType picks CSV or NDJSON from an Accept header. CSV is the
+// default; an empty string means neither is acceptable.
+func negotiateExportType(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return contentTypeCSV
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case contentTypeCSV, "*/*", "text/*":
+			return contentTypeCSV
+		case contentTypeNDJSON, "application/ndjson", "application/jsonl":
+			return contentTypeNDJSON
+		}
+	}
+	return ""
+}
+
+// @Summary		Export transaction history
+// @Description	Stream every matching transaction, oldest first, as CSV or NDJSON depending on the Accept header. The response is sent in chunks while it is read from the account service.
+// @Tags			Account
+// @Produce		text/csv
+// @Produce		application/x-ndjson
+// @Param			Authorization	header	string	true	"Token"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Param			account_number	query	string	false	"Account number, all accounts when omitted"
+// @Param			from			query	string	false	"Start, RFC 3339 time or YYYY-MM-DD, inclusive"
+// @Param			to				query	string	false	"End, RFC 3339 time (exclusive) or YYYY-MM-DD (inclusive)"
+// @Param			type			query	string	false	"Comma separated transaction types"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		406
+// @Failure		500
+// @Router			/transactions/export [get]
+func ExportTransactions(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	contentType := negotiateExportType(c.GetHeader("Accept"))
+	if contentType == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "supported formats are " + contentTypeCSV + " and " + contentTypeNDJSON})
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	username, _ := c.Get("username")
+
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: uint32(customerID),
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(c.Request.Context(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	grpcReq := &pb.StreamTransactionsRequest{
+		Customerid:    uint32(customerID),
+		AccountNumber: c.Query("account_number"),
+		From:          c.Query("from"),
+		To:            c.Query("to"),
+		Type:          c.Query("type"),
+	}
+
+	// The stream is bound to the request context, so it is cancelled as soon
+	// as the client disconnects.
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.StreamTransactions(c.Request.Context(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stream := grpcRes.(pb.AccountService_StreamTransactionsClient)
+
+	// Errors about the request arrive with the first message; nothing has
+	// been written yet, so they can still get a proper status code.
+	first, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": status.Convert(err).Message()})
+		}
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	// err is io.EOF when nothing matched; the loop below then writes no rows
+	// and the response is the CSV header alone, or empty.
+	var rows rowWriter
+	if contentType == contentTypeCSV {
+		csvRows, headerErr := newCSVRowWriter(c.Writer)
+		if headerErr != nil {
+			return
+		}
+		rows = csvRows
+	} else {
+		rows = &ndjsonRowWriter{enc: json.NewEncoder(c.Writer)}
+	}
+
+	written := 0
+	for t := first; err == nil; t, err = stream.Recv() {
+		if err := rows.Write(transactionsFromProto([]*pb.Transaction{t})[0]); err != nil {
+			return
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			if rows.Flush() != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+	if !errors.Is(err, io.EOF) {
+		if status.Code(err) != codes.Canceled {
+			log.Printf("export transactions: %v", err)
+		}
+		return
+	}
+	if rows.Flush() == nil {
+		c.Writer.Flush()
+	}
+}
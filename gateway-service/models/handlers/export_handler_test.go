@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/account"
+	"github.com/m-dehghani/gateway-service/models/customer"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type mockCustomerServiceClient struct {
+	pb.CustomerServiceClient
+}
+
+func (m *mockCustomerServiceClient) VerifyCustomerID(ctx context.Context, in *pb.VerifyCustomerIDRequest, opts ...grpc.CallOption) (*pb.VerifyCustomerIDResponse, error) {
+	return &pb.VerifyCustomerIDResponse{Valid: true}, nil
+}
+
+type mockAccountServiceClient struct {
+	pb.AccountServiceClient
+	transactions []*pb.Transaction
+}
+
+func (m *mockAccountServiceClient) StreamTransactions(ctx context.Context, in *pb.StreamTransactionsRequest, opts ...grpc.CallOption) (pb.AccountService_StreamTransactionsClient, error) {
+	return &mockTransactionStream{transactions: m.transactions}, nil
+}
+
+type mockTransactionStream struct {
+	grpc.ClientStream
+	transactions []*pb.Transaction
+}
+
+func (m *mockTransactionStream) Recv() (*pb.Transaction, error) {
+	if len(m.transactions) == 0 {
+		return nil, io.EOF
+	}
+	t := m.transactions[0]
+	m.transactions = m.transactions[1:]
+	return t, nil
+}
+
+func export(transactions []*pb.Transaction, accept string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	grpcClient := &grpcclient.GRPCClient{
+		AccountService:  account.NewAccountService(&mockAccountServiceClient{transactions: transactions}),
+		CustomerService: customer.NewCustomerService(&mockCustomerServiceClient{}),
+	}
+	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+
+	r := gin.New()
+	r.GET("/transactions/export", func(c *gin.Context) {
+		c.Set("username", "jane")
+		ExportTransactions(c, grpcClient, cb)
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/transactions/export?customer_id=1", nil)
+	req.Header.Set("Accept", accept)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestExportTransactionsEmpty(t *testing.T) {
+	w := export(nil, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join(csvHeader, ",")+"\n", w.Body.String())
+
+	w = export(nil, contentTypeNDJSON)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestExportTransactions(t *testing.T) {
+	transactions := func() []*pb.Transaction {
+		return []*pb.Transaction{
+			{Id: 1, AccountNumber: "000000000001", Type: "deposit", Amount: &pb.Money{Units: 5000, Currency: "USD"}, Date: "2023-01-01T00:00:00Z"},
+			{Id: 2, AccountNumber: "000000000001", Type: "withdraw", Amount: &pb.Money{Units: 3000, Currency: "USD"}, Date: "2023-01-02T00:00:00Z", Reference: "rent"},
+		}
+	}
+
+	w := export(transactions(), contentTypeCSV)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeCSV, w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "2,2023-01-02T00:00:00Z,000000000001,withdraw,30.00,USD,rent,,,,,", lines[2])
+
+	w = export(transactions(), contentTypeNDJSON)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeNDJSON, w.Header().Get("Content-Type"))
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"id":1`)
+	assert.Contains(t, lines[1], `"reference":"rent"`)
+
+	assert.Equal(t, http.StatusNotAcceptable, export(transactions(), "application/xml").Code)
+}
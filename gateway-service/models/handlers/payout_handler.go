@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+)
+
+// maxPayoutFileSize bounds the payout files accepted for upload. It must stay
+// below grpcclient.MaxMessageSize, the largest request the account service
+// is sent.
+const maxPayoutFileSize = 8 << 20
+
+var payoutResultsHeader = []string{
+	"line", "type", "to_account_number", "name", "amount", "currency", "reference",
+	"end_to_end_id", "status", "transaction_id", "message", "executed_at",
+}
+
+// PayoutLineResponse is a line of a payout file and its outcome
+type PayoutLineResponse struct {
+	Line            int32         `json:"line"`
+	Type            string        `json:"type"`
+	ToAccountNumber string        `json:"to_account_number,omitempty"`
+	Name            string        `json:"name,omitempty"`
+	Amount          MoneyResponse `json:"amount"`
+	Reference       string        `json:"reference,omitempty"`
+	EndToEndID      string        `json:"end_to_end_id,omitempty"`
+	Status          string        `json:"status"`
+	TransactionID   uint32        `json:"transaction_id,omitempty"`
+	Message         string        `json:"message,omitempty"`
+	ExecutedAt      string        `json:"executed_at,omitempty"`
+}
+
+// PayoutLineErrorResponse is a line of a payout file that cannot be paid
+type PayoutLineErrorResponse struct {
+	Line    int32  `json:"line"`
+	Message string `json:"message"`
+}
+
+// @Summary		Submit a payout batch
+// @Description	Upload a payout file, as CSV or ISO 20022 pain.001, to pay many recipients from one account at once. Every line is validated first, and a file with an invalid line is rejected as a whole, listing the problem of each such line. An accepted batch is paid in the background, line by line; a line that cannot be paid, e.g. for lack of funds, fails on its own. Without account_number the debtor account of a pain.001 file is used.
+// @Tags			Payouts
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			Authorization	header		string	true	"Token"
+// @Param			Idempotency-Key	header		string	true	"Retries with the same key submit the batch once"
+// @Param			customer_id		formData	uint32	true	"Customer ID"
+// @Param			account_number	formData	string	false	"Account to pay from"
+// @Param			format			formData	string	true	"csv or pain001"
+// @Param			file			formData	file	true	"Payout file"
+// @Success		202
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/payouts [post]
+func SubmitPayoutBatch(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	customerID, err := strconv.ParseUint(c.PostForm("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if !authorizeCustomer(c, grpcClient, cb, uint32(customerID)) {
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if header.Size > maxPayoutFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payout file too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grpcReq := &pb.SubmitPayoutBatchRequest{
+		Customerid:    uint32(customerID),
+		AccountNumber: c.PostForm("account_number"),
+		Format:        c.PostForm("format"),
+		Source:        header.Filename,
+		Content:       content,
+		RequestId:     c.GetHeader("Idempotency-Key"),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.SubmitPayoutBatch(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.PayoutBatchResponse)
+	if !res.Success {
+		errs := make([]PayoutLineErrorResponse, 0, len(res.Errors))
+		for _, e := range res.Errors {
+			errs = append(errs, PayoutLineErrorResponse{Line: e.Line, Message: e.Message})
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message, "errors": errs})
+		return
+	}
+	c.JSON(http.StatusAccepted, payoutBatchSummary(res))
+}
+
+// @Summary		Payout batch status
+// @Description	Get the status of a payout batch with the outcome of every line
+// @Tags			Payouts
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			id				path	uint32	true	"Payout batch ID"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/payouts/{id} [get]
+func GetPayoutBatch(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	res, ok := payoutBatch(c, grpcClient, cb)
+	if !ok {
+		return
+	}
+	lines := make([]PayoutLineResponse, 0, len(res.Lines))
+	for _, line := range res.Lines {
+		lines = append(lines, PayoutLineResponse{
+			Line:            line.Line,
+			Type:            line.Type,
+			ToAccountNumber: line.ToAccountNumber,
+			Name:            line.Name,
+			Amount:          moneyFromProto(line.Amount),
+			Reference:       line.Reference,
+			EndToEndID:      line.EndToEndId,
+			Status:          line.Status,
+			TransactionID:   line.TransactionId,
+			Message:         line.Message,
+			ExecutedAt:      line.ExecutedAt,
+		})
+	}
+	summary := payoutBatchSummary(res)
+	summary["lines"] = lines
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary		Download payout batch results
+// @Description	Download the outcome of every line of a payout batch as CSV, in file order
+// @Tags			Payouts
+// @Produce		text/csv
+// @Param			Authorization	header	string	true	"Token"
+// @Param			id				path	uint32	true	"Payout batch ID"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/payouts/{id}/results [get]
+func PayoutBatchResults(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	res, ok := payoutBatch(c, grpcClient, cb)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("payout-%d-results.csv", res.BatchId)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Content-Type", contentTypeCSV)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(payoutResultsHeader)
+	for _, line := range res.Lines {
+		amount := moneyFromProto(line.Amount)
+		w.Write([]string{
+			strconv.Itoa(int(line.Line)),
+			line.Type,
+			line.ToAccountNumber,
+			line.Name,
+			amount.Amount,
+			amount.Currency,
+			line.Reference,
+			line.EndToEndId,
+			line.Status,
+			optionalID(line.TransactionId),
+			line.Message,
+			line.ExecutedAt,
+		})
+	}
+	w.Flush()
+}
+
+// payoutBatch loads the payout batch named by the request, answering the
+// request itself when that fails.
+func payoutBatch(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) (*pb.PayoutBatchResponse, bool) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payout batch id"})
+		return nil, false
+	}
+	if !authorizeCustomer(c, grpcClient, cb, uint32(customerID)) {
+		return nil, false
+	}
+
+	grpcReq := &pb.GetPayoutBatchRequest{
+		Customerid: uint32(customerID),
+		BatchId:    uint32(id),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.GetPayoutBatch(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	res := grpcRes.(*pb.PayoutBatchResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return nil, false
+	}
+	return res, true
+}
+
+func payoutBatchSummary(res *pb.PayoutBatchResponse) gin.H {
+	return gin.H{
+		"success":        true,
+		"message":        res.Message,
+		"batch_id":       res.BatchId,
+		"account_number": res.AccountNumber,
+		"format":         res.Format,
+		"source":         res.Source,
+		"status":         res.Status,
+		"line_count":     res.LineCount,
+		"pending":        res.Pending,
+		"succeeded":      res.Succeeded,
+		"failed":         res.Failed,
+		"total":          moneyFromProto(res.Total),
+		"created_at":     res.CreatedAt,
+		"completed_at":   res.CompletedAt,
+	}
+}
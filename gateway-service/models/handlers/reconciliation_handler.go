@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+)
+
+// maxSettlementFileSize bounds the settlement files accepted for upload.
+const maxSettlementFileSize = 32 << 20
+
+// ReconciliationItemResponse is a line of a settlement file and its outcome
+type ReconciliationItemResponse struct {
+	Line          int32         `json:"line"`
+	ExternalID    string        `json:"external_id,omitempty"`
+	Amount        MoneyResponse `json:"amount"`
+	Date          string        `json:"date"`
+	Reference     string        `json:"reference,omitempty"`
+	Status        string        `json:"status"`
+	TransactionID uint32        `json:"transaction_id,omitempty"`
+	Candidates    []uint32      `json:"candidates,omitempty"`
+}
+
+// @Summary		Reconcile a settlement file
+// @Description	Upload a settlement file of the partner bank, as CSV or camt.054, and match its lines against the deposits, withdrawals and card captures by amount, date and reference. Lines are reported as matched, unmatched, ambiguous, or duplicate when an earlier reconciliation matched them already; no transaction is ever matched twice. The logged in admin is recorded as the actor.
+// @Tags			Admin
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			Authorization	header		string	true	"Token"
+// @Param			format			formData	string	true	"csv or camt054"
+// @Param			file			formData	file	true	"Settlement file"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/reconciliations [post]
+func Reconcile(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if header.Size > maxSettlementFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "settlement file too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, _ := c.Get("username")
+
+	grpcReq := &pb.ReconcileRequest{
+		Format:  c.PostForm("format"),
+		Source:  header.Filename,
+		Content: content,
+		Actor:   username.(string),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.Reconcile(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reconciliationReport(c, grpcRes.(*pb.ReconcileResponse))
+}
+
+// @Summary		Reconciliation report
+// @Description	Get the report of an earlier reconciliation with the outcome of every line of its settlement file
+// @Tags			Admin
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			id				path	int		true	"Reconciliation ID"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		500
+// @Router			/admin/reconciliations/{id} [get]
+func GetReconciliation(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reconciliation id"})
+		return
+	}
+	grpcReq := &pb.GetReconciliationRequest{ReconciliationId: uint32(id)}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.GetReconciliation(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reconciliationReport(c, grpcRes.(*pb.ReconcileResponse))
+}
+
+func reconciliationReport(c *gin.Context, res *pb.ReconcileResponse) {
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	items := make([]ReconciliationItemResponse, 0, len(res.Items))
+	for _, item := range res.Items {
+		items = append(items, ReconciliationItemResponse{
+			Line:          item.Line,
+			ExternalID:    item.ExternalId,
+			Amount:        moneyFromProto(item.Amount),
+			Date:          item.Date,
+			Reference:     item.Reference,
+			Status:        item.Status,
+			TransactionID: item.TransactionId,
+			Candidates:    item.Candidates,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"message":           res.Message,
+		"reconciliation_id": res.ReconciliationId,
+		"format":            res.Format,
+		"source":            res.Source,
+		"actor":             res.Actor,
+		"created_at":        res.CreatedAt,
+		"lines":             res.Lines,
+		"matched":           res.Matched,
+		"unmatched":         res.Unmatched,
+		"ambiguous":         res.Ambiguous,
+		"duplicates":        res.Duplicates,
+		"items":             items,
+	})
+}
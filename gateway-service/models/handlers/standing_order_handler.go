@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	"github.com/m-dehghani/gateway-service/models/valueobjects"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+)
+
+// StandingOrderRequest represents the request body for the CreateStandingOrder endpoint
+type StandingOrderRequest struct {
+	CustomerID        uint32 `json:"customer_id"`
+	FromAccountNumber string `json:"from_account_number" example:"000000000197"`
+	ToAccountNumber   string `json:"to_account_number" example:"000000000294"`
+	Amount            string `json:"amount" example:"850.00"`
+	Currency          string `json:"currency" example:"USD"`
+	Reference         string `json:"reference" example:"rent"`
+	Frequency         string `json:"frequency" example:"monthly"`
+	DayOfMonth        int32  `json:"day_of_month" example:"1"`
+	StartDate         string `json:"start_date" example:"2026-11-01"`
+	EndDate           string `json:"end_date"`
+}
+
+// StandingOrderResponse represents one standing order of a customer
+type StandingOrderResponse struct {
+	ID                uint32        `json:"id"`
+	FromAccountNumber string        `json:"from_account_number"`
+	ToAccountNumber   string        `json:"to_account_number"`
+	Amount            MoneyResponse `json:"amount"`
+	Reference         string        `json:"reference,omitempty"`
+	Frequency         string        `json:"frequency"`
+	DayOfMonth        int32         `json:"day_of_month,omitempty"`
+	StartDate         string        `json:"start_date"`
+	EndDate           string        `json:"end_date,omitempty"`
+	NextDueDate       string        `json:"next_due_date,omitempty"`
+	NextRunAt         string        `json:"next_run_at,omitempty"`
+	Status            string        `json:"status"`
+}
+
+// StandingOrderExecutionResponse represents one execution attempt of a standing order
+type StandingOrderExecutionResponse struct {
+	ID            uint32 `json:"id"`
+	DueDate       string `json:"due_date"`
+	Attempt       int32  `json:"attempt"`
+	Status        string `json:"status"`
+	TransactionID uint32 `json:"transaction_id,omitempty"`
+	Message       string `json:"message,omitempty"`
+	ExecutedAt    string `json:"executed_at"`
+}
+
+func standingOrderFromProto(o *pb.StandingOrder) StandingOrderResponse {
+	return StandingOrderResponse{
+		ID:                o.Id,
+		FromAccountNumber: o.FromAccountNumber,
+		ToAccountNumber:   o.ToAccountNumber,
+		Amount:            moneyFromProto(o.Amount),
+		Reference:         o.Reference,
+		Frequency:         o.Frequency,
+		DayOfMonth:        o.DayOfMonth,
+		StartDate:         o.StartDate,
+		EndDate:           o.EndDate,
+		NextDueDate:       o.NextDueDate,
+		NextRunAt:         o.NextRunAt,
+		Status:            o.Status,
+	}
+}
+
+// authorizeCustomer makes sure the customer ID belongs to the logged in user
+// and answers 401 when it does not.
+func authorizeCustomer(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker, customerID uint32) bool {
+	username, _ := c.Get("username")
+
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: customerID,
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(context.Background(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return false
+	}
+	return true
+}
+
+// @Summary		Create a standing order
+// @Description	Schedule a recurring transfer from one of the customer's accounts: monthly on day_of_month, weekly or biweekly, from start_date until the optional end_date
+// @Tags			Standing orders
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header	string					true	"Token"
+// @Param			request			body	StandingOrderRequest	true	"Standing Order Request"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/standing-orders [post]
+func CreateStandingOrder(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	var req StandingOrderRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount, err := valueobjects.NewMoney(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeCustomer(c, grpcClient, cb, req.CustomerID) {
+		return
+	}
+
+	grpcReq := &pb.CreateStandingOrderRequest{
+		Customerid:        req.CustomerID,
+		FromAccountNumber: req.FromAccountNumber,
+		ToAccountNumber:   req.ToAccountNumber,
+		Amount:            moneyToProto(amount),
+		Reference:         req.Reference,
+		Frequency:         req.Frequency,
+		DayOfMonth:        req.DayOfMonth,
+		StartDate:         req.StartDate,
+		EndDate:           req.EndDate,
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.CreateStandingOrder(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.CreateStandingOrderResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        res.Message,
+		"standing_order": standingOrderFromProto(res.StandingOrder),
+	})
+}
+
+// @Summary		List standing orders
+// @Description	List the standing orders of the customer, or of one account, including cancelled and completed ones
+// @Tags			Standing orders
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Param			account_number	query	string	false	"Account number"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/standing-orders [get]
+func ListStandingOrders(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if !authorizeCustomer(c, grpcClient, cb, uint32(customerID)) {
+		return
+	}
+
+	grpcReq := &pb.ListStandingOrdersRequest{
+		Customerid:    uint32(customerID),
+		AccountNumber: c.Query("account_number"),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.ListStandingOrders(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.ListStandingOrdersResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	orders := make([]StandingOrderResponse, 0, len(res.StandingOrders))
+	for _, o := range res.StandingOrders {
+		orders = append(orders, standingOrderFromProto(o))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"message":         res.Message,
+		"standing_orders": orders,
+	})
+}
+
+// @Summary		Cancel a standing order
+// @Description	Stop a standing order of the customer; its execution history is kept
+// @Tags			Standing orders
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			id				path	uint32	true	"Standing order ID"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/standing-orders/{id} [delete]
+func CancelStandingOrder(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid standing order id"})
+		return
+	}
+	if !authorizeCustomer(c, grpcClient, cb, uint32(customerID)) {
+		return
+	}
+
+	grpcReq := &pb.CancelStandingOrderRequest{
+		Customerid:      uint32(customerID),
+		StandingOrderId: uint32(id),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.CancelStandingOrder(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.CancelStandingOrderResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": res.Message})
+}
+
+// @Summary		Standing order execution history
+// @Description	List every execution attempt of a standing order with its outcome and, when it succeeded, the transfer it made
+// @Tags			Standing orders
+// @Produce		json
+// @Param			Authorization	header	string	true	"Token"
+// @Param			id				path	uint32	true	"Standing order ID"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/standing-orders/{id}/executions [get]
+func StandingOrderExecutions(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid standing order id"})
+		return
+	}
+	if !authorizeCustomer(c, grpcClient, cb, uint32(customerID)) {
+		return
+	}
+
+	grpcReq := &pb.StandingOrderExecutionsRequest{
+		Customerid:      uint32(customerID),
+		StandingOrderId: uint32(id),
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.StandingOrderExecutions(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.StandingOrderExecutionsResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	executions := make([]StandingOrderExecutionResponse, 0, len(res.Executions))
+	for _, e := range res.Executions {
+		executions = append(executions, StandingOrderExecutionResponse{
+			ID:            e.Id,
+			DueDate:       e.DueDate,
+			Attempt:       e.Attempt,
+			Status:        e.Status,
+			TransactionID: e.TransactionId,
+			Message:       e.Message,
+			ExecutedAt:    e.ExecutedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    res.Message,
+		"executions": executions,
+	})
+}
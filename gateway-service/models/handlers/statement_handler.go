@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m-dehghani/gateway-service/models/grpcclient"
+	pb "github.com/m-dehghani/gateway-service/proto"
+	"github.com/sony/gobreaker"
+)
+
+// @Summary		Download an account statement
+// @Description	Download the monthly statement of the customer's account, with opening and closing balances, a running balance per transaction and totals by type
+// @Tags			Account
+// @Produce		text/html
+// @Produce		application/pdf
+// @Param			Authorization	header	string	true	"Token"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Param			account_number	query	string	true	"Account number"
+// @Param			period			query	string	true	"Month, YYYY-MM"
+// @Param			format			query	string	false	"html (default) or pdf"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/statements [get]
+func Statement(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	downloadStatement(c, grpcClient, cb, c.Query("format"))
+}
+
+// @Summary		Export an account statement
+// @Description	Export the monthly statement of the customer's account in a format accounting software imports: ISO 20022 camt.053 XML, SWIFT MT940 or OFX
+// @Tags			Account
+// @Produce		application/xml
+// @Produce		text/plain
+// @Produce		application/x-ofx
+// @Param			Authorization	header	string	true	"Token"
+// @Param			customer_id		query	uint32	true	"Customer ID"
+// @Param			account_number	query	string	true	"Account number"
+// @Param			period			query	string	true	"Month, YYYY-MM"
+// @Param			format			query	string	true	"camt053, mt940 or ofx"
+// @Success		200
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/statements/export [get]
+func ExportStatement(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker) {
+	format := c.Query("format")
+	if !exportFormats[format] {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "format must be camt053, mt940 or ofx"})
+		return
+	}
+	downloadStatement(c, grpcClient, cb, format)
+}
+
+// exportFormats are the statement formats meant for accounting software.
+var exportFormats = map[string]bool{"camt053": true, "mt940": true, "ofx": true}
+
+func downloadStatement(c *gin.Context, grpcClient *grpcclient.GRPCClient, cb *gobreaker.CircuitBreaker, format string) {
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	username, _ := c.Get("username")
+
+	userValidationReq := &pb.VerifyCustomerIDRequest{
+		Username:   username.(string),
+		Customerid: uint32(customerID),
+	}
+
+	userValidationRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.CustomerService.VerifyCustomerID(context.Background(), userValidationReq)
+	})
+	if err != nil || !userValidationRes.(*pb.VerifyCustomerIDResponse).Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	grpcReq := &pb.GetStatementRequest{
+		Customerid:    uint32(customerID),
+		AccountNumber: c.Query("account_number"),
+		Period:        c.Query("period"),
+		Format:        format,
+	}
+
+	grpcRes, err := cb.Execute(func() (interface{}, error) {
+		return grpcClient.AccountService.GetStatement(context.Background(), grpcReq)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := grpcRes.(*pb.GetStatementResponse)
+	if !res.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": res.Message})
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": res.Filename}))
+	c.Data(http.StatusOK, res.ContentType, res.Content)
+}
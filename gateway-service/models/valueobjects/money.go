@@ -0,0 +1,85 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount   = errors.New("amount must be a positive decimal string such as \"10.50\"")
+	ErrTooManyDecimals = errors.New("amount has more decimal places than the currency allows")
+	ErrAmountTooLarge  = errors.New("amount is too large")
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+var decimalPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// currencyExponents maps ISO 4217 codes to the number of minor unit digits.
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "INR": 2, "IRR": 2,
+	"JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2, "TRY": 2, "USD": 2, "ZAR": 2,
+}
+
+// DefaultCurrency is assumed when a request does not name a currency.
+const DefaultCurrency = "USD"
+
+// Money is an exact amount in the minor unit of an ISO 4217 currency.
+type Money struct {
+	Units    int64
+	Currency string
+}
+
+// NewMoney parses a decimal string like "10.50" strictly: no sign, exponent,
+// thousands separator or surrounding spaces, and no more fractional digits
+// than the currency has minor units.
+func NewMoney(amount, currency string) (*Money, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return nil, ErrUnknownCurrency
+	}
+
+	match := decimalPattern.FindStringSubmatch(amount)
+	if match == nil {
+		return nil, ErrInvalidAmount
+	}
+	whole, fraction := match[1], strings.TrimPrefix(match[2], ".")
+	if len(fraction) > exponent {
+		return nil, ErrTooManyDecimals
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return nil, ErrAmountTooLarge
+	}
+	if units == 0 {
+		return nil, ErrInvalidAmount
+	}
+	return &Money{Units: units, Currency: currency}, nil
+}
+
+// Decimal formats the amount in major units, e.g. "10.50".
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.Currency]
+	sign := ""
+	units := m.Units
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(units, 10)
+	}
+	digits := fmt.Sprintf("%0*d", exponent+1, units)
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
@@ -6,10 +6,12 @@ BASE_URL="http://localhost:8080"
 
 # Register a new user
 echo "Registering a new user..."
-curl -X POST "$BASE_URL/register" -H "Content-Type: application/json" -d '{
+REGISTER_RESPONSE=$(curl -s -X POST "$BASE_URL/register" -H "Content-Type: application/json" -d '{
   "username": "testuser1",
   "password": "testpass1"
-}'
+}')
+ACCOUNT_NUMBER=$(echo $REGISTER_RESPONSE | jq -r '."account-number"')
+echo "Account number: $ACCOUNT_NUMBER"
 echo -e "\n"
 
 # Login the user
@@ -22,11 +24,29 @@ TOKEN=$(echo $LOGIN_RESPONSE | jq -r '.token')
 echo "Token: $TOKEN"
 echo -e "\n"
 
+# Open a savings account
+echo "Opening a savings account..."
+OPEN_RESPONSE=$(curl -s -X POST "$BASE_URL/accounts" -H "Content-Type: application/json" -H "Authorization: $TOKEN" -d '{
+  "customer_id": 1,
+  "product_type": "savings",
+  "currency": "USD"
+}')
+SAVINGS_NUMBER=$(echo $OPEN_RESPONSE | jq -r '.account.number')
+echo "Savings account number: $SAVINGS_NUMBER"
+echo -e "\n"
+
+# List accounts
+echo "Listing accounts..."
+curl -X GET "$BASE_URL/accounts?customer_id=1" -H "Authorization: $TOKEN"
+echo -e "\n"
+
 # Deposit money
 echo "Depositing money..."
 curl -X POST "$BASE_URL/deposit" -H "Content-Type: application/json" -H "Authorization: $TOKEN" -d '{
   "customer_id": 1,
-  "amount": 100.0
+  "account_number": "'"$ACCOUNT_NUMBER"'",
+  "amount": "100.00",
+  "currency": "USD"
 }'
 echo -e "\n"
 
@@ -34,13 +54,27 @@ echo -e "\n"
 echo "Withdrawing money..."
 curl -X POST "$BASE_URL/withdraw" -H "Content-Type: application/json" -H "Authorization: $TOKEN" -d '{
   "customer_id": 1,
-  "amount": 50.0
+  "account_number": "'"$ACCOUNT_NUMBER"'",
+  "amount": "50.00",
+  "currency": "USD"
+}'
+echo -e "\n"
+
+# Transfer money
+echo "Transferring money..."
+curl -X POST "$BASE_URL/transfer" -H "Content-Type: application/json" -H "Authorization: $TOKEN" -H "Idempotency-Key: $(date +%s%N)" -d '{
+  "from_customer_id": 1,
+  "from_account_number": "'"$ACCOUNT_NUMBER"'",
+  "to_account_number": "'"$SAVINGS_NUMBER"'",
+  "amount": "10.00",
+  "currency": "USD",
+  "reference": "test transfer"
 }'
 echo -e "\n"
 
 # Balance inquiry
 echo "Inquiring balance..."
-curl -X GET "$BASE_URL/balance?customer_id=1" -H "Authorization: $TOKEN"
+curl -X GET "$BASE_URL/balance?customer_id=1&account_number=$ACCOUNT_NUMBER" -H "Authorization: $TOKEN"
 echo -e "\n"
 
 # Transaction history